@@ -0,0 +1,3 @@
+module github.com/andev0x/pkg
+
+go 1.21
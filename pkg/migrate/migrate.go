@@ -0,0 +1,444 @@
+// Package migrate applies the versioned schema migrations of a service to its database and
+// records the applied versions in the schema_migrations table. The order and analytics services
+// both run their migrations with it.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrDirty is returned when a migration failed halfway through on a database that cannot roll
+// back schema changes. The schema has to be repaired by hand and the version set with Force.
+var ErrDirty = errors.New("database schema is dirty")
+
+// Database drivers with migrations, named like the database/sql drivers of the services
+const (
+	driverMySQL    = "mysql"
+	driverPostgres = "postgres"
+	driverSQLite   = "sqlite"
+)
+
+// lockID identifies the PostgreSQL advisory lock held while migrating
+const lockID = 72346851
+
+// lockName is the MySQL named lock held while migrating
+const lockName = "schema_migrations"
+
+// createTableQueries create the table recording applied migrations, by driver
+var createTableQueries = map[string]string{
+	driverMySQL: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			dirty BOOLEAN NOT NULL,
+			applied_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci
+	`,
+	driverPostgres: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			dirty BOOLEAN NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`,
+	driverSQLite: `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			dirty BOOLEAN NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`,
+}
+
+// fileName matches the name of a migration file: its version, its name and its direction
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change and the script that undoes it
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus is a migration and whether it is applied to the database
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt time.Time
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	version   int64
+	dirty     bool
+	appliedAt time.Time
+}
+
+// Migrator applies the migrations of one database driver to a database
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// New creates a migrator for a database of driver, reading its migrations from the directory of
+// source named after the driver
+func New(db *sql.DB, driver string, source fs.FS) (*Migrator, error) {
+	if _, ok := createTableQueries[driver]; !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	migrations, err := readMigrations(source, driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// readMigrations reads the migration files in dir, sorted by version
+func readMigrations(source fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		script, err := fs.ReadFile(source, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.up = string(script)
+		} else {
+			migration.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Up applies every migration that is not applied yet, in version order, and returns how many it applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkClean(applied); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Down rolls back the last n applied migrations, newest first, and returns how many it rolled back
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := checkClean(applied); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && count < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.down == "" {
+				return fmt.Errorf("migration %d_%s cannot be rolled back: it has no down script", migration.Version, migration.Name)
+			}
+			if err := m.run(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// Status returns every migration, oldest first, with whether it is applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer closeConn(conn)
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if row, ok := applied[migration.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].Dirty = row.dirty
+			statuses[i].AppliedAt = row.appliedAt
+		}
+	}
+
+	return statuses, nil
+}
+
+// Force records the migrations up to version as applied and the later ones as not applied,
+// without running any script, and clears the dirty flag. It is the way out of a failed migration
+// once the schema has been repaired by hand. Version 0 records no migration as applied.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer rollback(tx)
+
+		if _, err := tx.ExecContext(ctx, m.rebind(`DELETE FROM schema_migrations WHERE version > ?`), version); err != nil {
+			return fmt.Errorf("failed to unrecord migrations: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE schema_migrations SET dirty = FALSE`); err != nil {
+			return fmt.Errorf("failed to clear dirty flag: %w", err)
+		}
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, m.rebind(`INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, FALSE)`),
+				migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+}
+
+// run applies a migration, or rolls it back if up is false, in a transaction that also updates
+// schema_migrations. The migration is marked dirty first: MySQL commits every schema change as it
+// runs, so a script failing there halfway leaves that mark behind. Elsewhere it is rolled back.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	direction, script := "up", migration.up
+	mark := `INSERT INTO schema_migrations (version, name, dirty) VALUES (?, ?, TRUE)`
+	args := []interface{}{migration.Version, migration.Name}
+	done := `UPDATE schema_migrations SET dirty = FALSE, applied_at = CURRENT_TIMESTAMP WHERE version = ?`
+	if !up {
+		direction, script = "down", migration.down
+		mark = `UPDATE schema_migrations SET dirty = TRUE WHERE version = ?`
+		args = args[:1]
+		done = `DELETE FROM schema_migrations WHERE version = ?`
+	}
+	log.Printf("Migrating %s %d_%s", direction, migration.Version, migration.Name)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	if _, err := tx.ExecContext(ctx, m.rebind(mark), args...); err != nil {
+		return fmt.Errorf("failed to mark migration %d dirty: %w", migration.Version, err)
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+	}
+	if _, err := tx.ExecContext(ctx, m.rebind(done), migration.Version); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", migration.Version, err)
+	}
+	return nil
+}
+
+// applied creates schema_migrations if needed and returns its rows by version
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	if _, err := conn.ExecContext(ctx, createTableQueries[m.driver]); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, dirty, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.version, &row.dirty, &row.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[row.version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// withLock runs fn on a connection holding the migration lock, so that replicas migrating on
+// startup take turns. SQLite needs no lock: it is not shared between replicas, and its
+// transactions already wait for each other.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer closeConn(conn)
+
+	var unlock string
+	var key interface{}
+	switch m.driver {
+	case driverMySQL:
+		// GET_LOCK returns 1 once the lock is taken; a negative timeout waits as long as ctx allows
+		var granted sql.NullInt64
+		if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, -1)`, lockName).Scan(&granted); err != nil {
+			return fmt.Errorf("failed to take migration lock: %w", err)
+		}
+		if granted.Int64 != 1 {
+			return errors.New("failed to take migration lock")
+		}
+		unlock, key = `SELECT RELEASE_LOCK(?)`, lockName
+	case driverPostgres:
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+			return fmt.Errorf("failed to take migration lock: %w", err)
+		}
+		unlock, key = `SELECT pg_advisory_unlock($1)`, lockID
+	default:
+		return fn(conn)
+	}
+	defer func() {
+		// The lock is also released when the connection closes, so a failure here only delays others
+		if _, err := conn.ExecContext(context.Background(), unlock, key); err != nil {
+			log.Printf("Error releasing migration lock: %v", err)
+		}
+	}()
+
+	return fn(conn)
+}
+
+// find returns the migration with version, or nil if there is none
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// rebind rewrites the ? placeholders of query for drivers that number them
+func (m *Migrator) rebind(query string) string {
+	if m.driver != driverPostgres {
+		return query
+	}
+	for n := 1; strings.Contains(query, "?"); n++ {
+		query = strings.Replace(query, "?", "$"+strconv.Itoa(n), 1)
+	}
+	return query
+}
+
+// checkClean returns ErrDirty if a migration failed halfway
+func checkClean(applied map[int64]appliedMigration) error {
+	for _, row := range applied {
+		if row.dirty {
+			return fmt.Errorf("%w: migration %d failed halfway; repair the schema and run force", ErrDirty, row.version)
+		}
+	}
+	return nil
+}
+
+// rollback rolls back tx unless it was committed
+func rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		log.Printf("Error rolling back transaction: %v", err)
+	}
+}
+
+// closeConn returns conn to the pool
+func closeConn(conn *sql.Conn) {
+	if err := conn.Close(); err != nil {
+		log.Printf("Error closing database connection: %v", err)
+	}
+}
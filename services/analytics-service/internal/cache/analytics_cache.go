@@ -12,15 +12,17 @@ import (
 )
 
 const (
-	summaryKey = "analytics:summary"
-	summaryTTL = 5 * time.Minute
+	// summaryKeyPrefix is versioned so summaries cached before the per-currency breakdown are ignored
+	summaryKeyPrefix = "analytics:summary:v2:"
+	summaryTTL       = 5 * time.Minute
 )
 
-// AnalyticsCache interface defines methods for caching analytics data
+// AnalyticsCache interface defines methods for caching analytics data.
+// Summaries are cached per tenant.
 type AnalyticsCache interface {
-	GetSummary(ctx context.Context) (*model.AnalyticsSummary, error)
+	GetSummary(ctx context.Context, tenantID string) (*model.AnalyticsSummary, error)
 	SetSummary(ctx context.Context, summary *model.AnalyticsSummary) error
-	InvalidateSummary(ctx context.Context) error
+	InvalidateSummary(ctx context.Context, tenantID string) error
 }
 
 // RedisAnalyticsCache implements AnalyticsCache using Redis
@@ -33,9 +35,9 @@ func NewRedisAnalyticsCache(client *redis.Client) *RedisAnalyticsCache {
 	return &RedisAnalyticsCache{client: client}
 }
 
-// GetSummary retrieves analytics summary from cache
-func (c *RedisAnalyticsCache) GetSummary(ctx context.Context) (*model.AnalyticsSummary, error) {
-	data, err := c.client.Get(ctx, summaryKey).Bytes()
+// GetSummary retrieves the analytics summary of a tenant from cache
+func (c *RedisAnalyticsCache) GetSummary(ctx context.Context, tenantID string) (*model.AnalyticsSummary, error) {
+	data, err := c.client.Get(ctx, summaryKey(tenantID)).Bytes()
 	if err == redis.Nil {
 		return nil, fmt.Errorf("summary not found in cache")
 	}
@@ -51,28 +53,33 @@ func (c *RedisAnalyticsCache) GetSummary(ctx context.Context) (*model.AnalyticsS
 	return &summary, nil
 }
 
-// SetSummary stores analytics summary in cache
+// SetSummary stores an analytics summary in cache under its tenant
 func (c *RedisAnalyticsCache) SetSummary(ctx context.Context, summary *model.AnalyticsSummary) error {
 	data, err := json.Marshal(summary)
 	if err != nil {
 		return fmt.Errorf("failed to marshal summary: %w", err)
 	}
 
-	if err := c.client.Set(ctx, summaryKey, data, summaryTTL).Err(); err != nil {
+	if err := c.client.Set(ctx, summaryKey(summary.TenantID), data, summaryTTL).Err(); err != nil {
 		return fmt.Errorf("failed to set summary in cache: %w", err)
 	}
 
 	return nil
 }
 
-// InvalidateSummary removes analytics summary from cache
-func (c *RedisAnalyticsCache) InvalidateSummary(ctx context.Context) error {
-	if err := c.client.Del(ctx, summaryKey).Err(); err != nil {
+// InvalidateSummary removes the analytics summary of a tenant from cache
+func (c *RedisAnalyticsCache) InvalidateSummary(ctx context.Context, tenantID string) error {
+	if err := c.client.Del(ctx, summaryKey(tenantID)).Err(); err != nil {
 		return fmt.Errorf("failed to invalidate summary cache: %w", err)
 	}
 	return nil
 }
 
+// summaryKey returns the cache key of the analytics summary of tenantID
+func summaryKey(tenantID string) string {
+	return summaryKeyPrefix + tenantID
+}
+
 // InitRedis initializes Redis client
 func InitRedis(host, port string) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
@@ -35,12 +35,17 @@ func (h *AnalyticsHandler) SetHealthChecker(hc *HealthChecker) {
 	h.healthCheck = hc
 }
 
-// GetSummary handles GET /analytics/summary
+// GetSummary handles GET /analytics/summary, returning the summary of the request's tenant
 func (h *AnalyticsHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
-	summary, err := h.service.GetSummary(r.Context())
+	tenantID, ok := requestTenant(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.GetSummary(r.Context(), tenantID)
 	if err != nil {
 		log.Printf("Error getting summary: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to get analytics summary")
+		respondWithServiceError(w, err, "Failed to get analytics summary")
 		return
 	}
 
@@ -99,15 +104,17 @@ func (h *AnalyticsHandler) HealthCheck(w http.ResponseWriter, _ *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// respondWithError sends an error response
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
-}
-
 // respondWithJSON sends a JSON response
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, code, payload)
+}
+
+// writeJSON marshals payload and writes it with the status code and the Content-Type already set
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
+		w.Header().Del("Content-Type")
 		w.WriteHeader(http.StatusInternalServerError)
 		if _, writeErr := w.Write([]byte("Internal server error")); writeErr != nil {
 			log.Printf("Error writing error response: %v", writeErr)
@@ -115,7 +122,6 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	if _, err := w.Write(response); err != nil {
 		log.Printf("Error writing response: %v", err)
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/andev0x/analytics-service/internal/auth"
+	"github.com/gorilla/mux"
+)
+
+// Authenticate rejects requests without a valid bearer token with 401 and stores
+// the caller's principal in the request context. Without a verifier no token is accepted.
+// allowAnonymous lets requests without a token through, for local development only.
+func Authenticate(verifier *auth.Verifier, allowAnonymous bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				if allowAnonymous {
+					next.ServeHTTP(w, r)
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="analytics-service"`)
+				respondWithError(w, http.StatusUnauthorized, "A bearer token is required")
+				return
+			}
+			if verifier == nil {
+				respondWithError(w, http.StatusUnauthorized, "Bearer tokens are not accepted, JWT_JWKS_FILE is not set")
+				return
+			}
+
+			principal, err := verifier.Verify(token)
+			if err != nil {
+				log.Printf("Rejected token for %s %s: %v", r.Method, r.URL.Path, err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="analytics-service", error="invalid_token"`)
+				respondWithError(w, http.StatusUnauthorized, "The bearer token is invalid or expired")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireRole rejects unauthenticated callers with 401 and callers without one of roles
+// with 403, also when anonymous access is allowed
+func RequireRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="analytics-service"`)
+				respondWithError(w, http.StatusUnauthorized, "This operation requires credentials with the "+strings.Join(roles, " or ")+" role")
+				return
+			}
+			if !principal.HasRole(roles...) {
+				respondWithError(w, http.StatusForbidden, "This operation requires the "+strings.Join(roles, " or ")+" role")
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
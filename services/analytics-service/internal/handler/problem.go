@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/andev0x/analytics-service/internal/model"
+)
+
+// problemContentType is the media type of RFC 7807 error responses
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// respondWithError sends a problem response with the given status and detail
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", problemContentType)
+	writeJSON(w, code, &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: message,
+	})
+}
+
+// respondWithServiceError maps an error from the service layer to a problem response
+// by its category. Unexpected errors are logged and reported as fallback without detail.
+func respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, model.ErrValidation):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		respondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrConflict):
+		respondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, model.ErrUnavailable):
+		log.Printf("Dependency unavailable: %v", err)
+		w.Header().Set("Retry-After", "5")
+		respondWithError(w, http.StatusServiceUnavailable, fallback+": a dependency is unavailable, retry later")
+	default:
+		log.Printf("Internal error: %v", err)
+		respondWithError(w, http.StatusInternalServerError, fallback)
+	}
+}
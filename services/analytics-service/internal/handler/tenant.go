@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/andev0x/analytics-service/internal/auth"
+	"github.com/andev0x/analytics-service/internal/model"
+)
+
+// TenantHeader is the request header naming the tenant (storefront) a request is for
+const TenantHeader = "X-Tenant-ID"
+
+// requestTenant returns the tenant a request is for. Callers whose token is bound to a tenant
+// get that tenant and are rejected with 403 if they name another, and so are customers without
+// one, in model.DefaultTenantID. Admins and services without one name the tenant in the
+// TenantHeader and default to model.DefaultTenantID. It responds with an error and returns
+// false if the request may not proceed.
+func requestTenant(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenantID := r.Header.Get(TenantHeader)
+	if tenantID != "" && !model.ValidTenantID(tenantID) {
+		respondWithError(w, http.StatusBadRequest, TenantHeader+" must be up to 64 letters, digits, '-' and '_'")
+		return "", false
+	}
+
+	if bound := boundTenant(r); bound != "" {
+		if tenantID != "" && tenantID != bound {
+			respondWithError(w, http.StatusForbidden, "Access to this tenant is not allowed")
+			return "", false
+		}
+		tenantID = bound
+	}
+	if tenantID == "" {
+		tenantID = model.DefaultTenantID
+	}
+	return tenantID, true
+}
+
+// boundTenant returns the tenant the caller of r is held to, or "" if it may choose one
+func boundTenant(r *http.Request) string {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok:
+		return ""
+	case principal.TenantID != "":
+		return principal.TenantID
+	case !principal.HasFullAccess():
+		return model.DefaultTenantID
+	}
+	return ""
+}
@@ -5,33 +5,55 @@ import (
 	"time"
 )
 
-// OrderCreatedEvent represents the event consumed from RabbitMQ
+// OrderCreatedEvent represents the event consumed from RabbitMQ.
+// TenantID is empty in events published before orders carried a tenant.
 type OrderCreatedEvent struct {
-	OrderID     string    `json:"order_id"`
-	CustomerID  string    `json:"customer_id"`
-	ProductID   string    `json:"product_id"`
-	Quantity    int       `json:"quantity"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	EventType   string    `json:"event_type"`
+	OrderID     string      `json:"order_id"`
+	TenantID    string      `json:"tenant_id"`
+	CustomerID  string      `json:"customer_id"`
+	ProductID   string      `json:"product_id"`
+	Quantity    int         `json:"quantity"`
+	TotalAmount Money       `json:"total_amount"`
+	Status      string      `json:"status"`
+	Items       []OrderItem `json:"items,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	EventType   string      `json:"event_type"`
+}
+
+// OrderItem represents a line item carried in an order event
+type OrderItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	Discount  Money  `json:"discount"`
+	LineTotal Money  `json:"line_total"`
 }
 
 // OrderMetric represents aggregated order metrics
 type OrderMetric struct {
 	ID          int       `json:"id"`
 	OrderID     string    `json:"order_id"`
+	TenantID    string    `json:"tenant_id"`
 	CustomerID  string    `json:"customer_id"`
 	ProductID   string    `json:"product_id"`
 	Quantity    int       `json:"quantity"`
-	TotalAmount float64   `json:"total_amount"`
+	TotalAmount Money     `json:"total_amount"`
 	ProcessedAt time.Time `json:"processed_at"`
 }
 
-// AnalyticsSummary represents aggregated analytics data
+// AnalyticsSummary represents aggregated analytics data of one tenant.
+// Revenue is only summed within a currency, so it is reported per currency.
 type AnalyticsSummary struct {
-	TotalOrders      int       `json:"total_orders"`
-	TotalRevenue     float64   `json:"total_revenue"`
-	AverageOrderSize float64   `json:"average_order_size"`
-	LastUpdated      time.Time `json:"last_updated"`
+	TenantID    string            `json:"tenant_id"`
+	TotalOrders int               `json:"total_orders"`
+	ByCurrency  []CurrencySummary `json:"by_currency"`
+	LastUpdated time.Time         `json:"last_updated"`
+}
+
+// CurrencySummary represents aggregated analytics data for orders in one currency
+type CurrencySummary struct {
+	Currency         string `json:"currency"`
+	TotalOrders      int    `json:"total_orders"`
+	TotalRevenue     Money  `json:"total_revenue"`
+	AverageOrderSize Money  `json:"average_order_size"`
 }
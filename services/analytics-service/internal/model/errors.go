@@ -0,0 +1,16 @@
+package model
+
+import "errors"
+
+// Error categories shared by all layers. Specific errors wrap one of these so
+// handlers can map any error to a status code with errors.Is.
+var (
+	// ErrNotFound means the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrValidation means the request is malformed
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict means the request conflicts with the current state of a resource
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable means a dependency such as the database could not be reached
+	ErrUnavailable = errors.New("service unavailable")
+)
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// DefaultCurrency is assumed for amounts written as plain decimal numbers
+const DefaultCurrency = "USD"
+
+// currencyExponents maps ISO-4217 codes to their number of minor unit digits; unknown codes use 2
+var currencyExponents = map[string]int{"BHD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "VND": 0}
+
+// Money is an exact monetary amount in the minor units of an ISO-4217 currency
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// UnmarshalJSON accepts {"amount": 1999, "currency": "USD"} as well as a plain
+// decimal number such as 19.99, which events published before amounts carried
+// a currency use for DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' && data[0] != 'n' {
+		value, err := strconv.ParseFloat(string(data), 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s: %w", data, err)
+		}
+		*m = Money{Amount: int64(math.Round(value * 100)), Currency: DefaultCurrency}
+		return nil
+	}
+
+	type plain Money
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
+
+// String formats the amount in major units with its currency, e.g. "19.99 USD"
+func (m Money) String() string {
+	exp, ok := currencyExponents[m.Currency]
+	if !ok {
+		exp = 2
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	amount, sign := m.Amount, ""
+	if amount < 0 {
+		amount, sign = -amount, "-"
+	}
+	digits := fmt.Sprintf("%0*d", exp+1, amount)
+	return fmt.Sprintf("%s%s.%s %s", sign, digits[:len(digits)-exp], digits[len(digits)-exp:], m.Currency)
+}
@@ -0,0 +1,15 @@
+package model
+
+import "regexp"
+
+// DefaultTenantID is the tenant of requests that do not name one and of events published
+// before orders carried a tenant
+const DefaultTenantID = "default"
+
+// tenantIDPattern restricts tenant IDs to characters that are safe in cache keys and headers
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// ValidTenantID reports whether id is a well-formed tenant ID
+func ValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
@@ -149,34 +149,7 @@ func (c *RabbitMQConsumer) StartConsuming(ctx context.Context, handler func(*mod
 					return
 				}
 
-				// Parse event
-				var event model.OrderCreatedEvent
-				if err := json.Unmarshal(msg.Body, &event); err != nil {
-					log.Printf("Error unmarshaling event: %v", err)
-					if nackErr := msg.Nack(false, false); nackErr != nil {
-						log.Printf("Error nacking message: %v", nackErr)
-					}
-					continue
-				}
-
-				log.Printf("Received OrderCreated event: OrderID=%s, CustomerID=%s, Amount=%.2f",
-					event.OrderID, event.CustomerID, event.TotalAmount)
-
-				// Process event
-				if err := handler(&event); err != nil {
-					log.Printf("Error processing event: %v", err)
-					// Requeue the message for retry
-					if nackErr := msg.Nack(false, true); nackErr != nil {
-						log.Printf("Error nacking message: %v", nackErr)
-					}
-					continue
-				}
-
-				// Acknowledge successful processing
-				if ackErr := msg.Ack(false); ackErr != nil {
-					log.Printf("Error acking message: %v", ackErr)
-				}
-				log.Printf("Successfully processed event for order: %s", event.OrderID)
+				HandleDelivery(msg, handler)
 			}
 		}
 	}()
@@ -184,6 +157,39 @@ func (c *RabbitMQConsumer) StartConsuming(ctx context.Context, handler func(*mod
 	return nil
 }
 
+// HandleDelivery processes one order event message with handler and acknowledges it. Messages
+// that cannot be parsed are dropped; messages handler fails on are requeued for retry.
+func HandleDelivery(msg amqp.Delivery, handler func(*model.OrderCreatedEvent) error) {
+	// Parse event
+	var event model.OrderCreatedEvent
+	if err := json.Unmarshal(msg.Body, &event); err != nil {
+		log.Printf("Error unmarshaling event: %v", err)
+		if nackErr := msg.Nack(false, false); nackErr != nil {
+			log.Printf("Error nacking message: %v", nackErr)
+		}
+		return
+	}
+
+	log.Printf("Received OrderCreated event: OrderID=%s, TenantID=%s, CustomerID=%s, Amount=%s",
+		event.OrderID, event.TenantID, event.CustomerID, event.TotalAmount)
+
+	// Process event
+	if err := handler(&event); err != nil {
+		log.Printf("Error processing event: %v", err)
+		// Requeue the message for retry
+		if nackErr := msg.Nack(false, true); nackErr != nil {
+			log.Printf("Error nacking message: %v", nackErr)
+		}
+		return
+	}
+
+	// Acknowledge successful processing
+	if ackErr := msg.Ack(false); ackErr != nil {
+		log.Printf("Error acking message: %v", ackErr)
+	}
+	log.Printf("Successfully processed event for order: %s", event.OrderID)
+}
+
 // Close closes the RabbitMQ connection
 func (c *RabbitMQConsumer) Close() error {
 	if c.channel != nil {
@@ -3,102 +3,101 @@ package repository
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
+	"log"
 	"time"
 
 	"github.com/andev0x/analytics-service/internal/model"
-	// Import MySQL driver for database/sql
-	_ "github.com/go-sql-driver/mysql"
 )
 
 // AnalyticsRepository interface defines methods for analytics persistence
 type AnalyticsRepository interface {
 	SaveOrderMetric(ctx context.Context, metric *model.OrderMetric) error
-	GetSummary(ctx context.Context) (*model.AnalyticsSummary, error)
+	GetSummary(ctx context.Context, tenantID string) (*model.AnalyticsSummary, error)
 }
 
-// MySQLAnalyticsRepository implements AnalyticsRepository using MySQL
-type MySQLAnalyticsRepository struct {
-	db *sql.DB
+// SQLAnalyticsRepository implements AnalyticsRepository on any supported database
+type SQLAnalyticsRepository struct {
+	db *DB
 }
 
-// NewMySQLAnalyticsRepository creates a new MySQL analytics repository
-func NewMySQLAnalyticsRepository(db *sql.DB) *MySQLAnalyticsRepository {
-	return &MySQLAnalyticsRepository{db: db}
+// NewSQLAnalyticsRepository creates a new analytics repository
+func NewSQLAnalyticsRepository(db *DB) *SQLAnalyticsRepository {
+	return &SQLAnalyticsRepository{db: db}
 }
 
-// SaveOrderMetric inserts a new order metric into the database
-func (r *MySQLAnalyticsRepository) SaveOrderMetric(ctx context.Context, metric *model.OrderMetric) error {
+// SaveOrderMetric inserts a new order metric into the database. Order events are delivered
+// at least once, so a metric for an order that already has one is skipped.
+func (r *SQLAnalyticsRepository) SaveOrderMetric(ctx context.Context, metric *model.OrderMetric) error {
 	query := `
-		INSERT INTO order_metrics (order_id, customer_id, product_id, quantity, total_amount, processed_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
+		INSERT INTO order_metrics (order_id, tenant_id, customer_id, product_id, quantity, total_amount_minor, currency, processed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)` + r.db.dialect.skipDuplicates
 
 	_, err := r.db.ExecContext(ctx, query,
 		metric.OrderID,
+		metric.TenantID,
 		metric.CustomerID,
 		metric.ProductID,
 		metric.Quantity,
-		metric.TotalAmount,
+		metric.TotalAmount.Amount,
+		metric.TotalAmount.Currency,
 		metric.ProcessedAt,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to save order metric: %w", err)
+		return wrapDBError("save order metric", err)
 	}
 
 	return nil
 }
 
-// GetSummary retrieves aggregated analytics summary
-func (r *MySQLAnalyticsRepository) GetSummary(ctx context.Context) (*model.AnalyticsSummary, error) {
+// GetSummary retrieves the aggregated analytics summary of a tenant, grouped by currency
+func (r *SQLAnalyticsRepository) GetSummary(ctx context.Context, tenantID string) (*model.AnalyticsSummary, error) {
 	query := `
-		SELECT 
+		SELECT
+			currency,
 			COUNT(*) as total_orders,
-			COALESCE(SUM(total_amount), 0) as total_revenue,
-			COALESCE(AVG(total_amount), 0) as average_order_size
+			COALESCE(SUM(total_amount_minor), 0) as total_revenue,
+			COALESCE(ROUND(AVG(total_amount_minor)), 0) as average_order_size
 		FROM order_metrics
+		WHERE tenant_id = ?
+		GROUP BY currency
+		ORDER BY currency
 	`
 
-	summary := &model.AnalyticsSummary{
-		LastUpdated: time.Now(),
-	}
-
-	err := r.db.QueryRowContext(ctx, query).Scan(
-		&summary.TotalOrders,
-		&summary.TotalRevenue,
-		&summary.AverageOrderSize,
-	)
-
+	rows, err := r.db.QueryContext(ctx, query, tenantID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get summary: %w", err)
+		return nil, wrapDBError("get summary", err)
 	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
 
-	return summary, nil
-}
-
-// InitDB initializes the database connection
-func InitDB(host, port, user, password, dbname string) (*sql.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		user, password, host, port, dbname)
-
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+	summary := &model.AnalyticsSummary{
+		TenantID:    tenantID,
+		ByCurrency:  []model.CurrencySummary{},
+		LastUpdated: time.Now(),
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
-
-	// Ping to verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	for rows.Next() {
+		var cs model.CurrencySummary
+		if err := rows.Scan(
+			&cs.Currency,
+			&cs.TotalOrders,
+			&cs.TotalRevenue.Amount,
+			&cs.AverageOrderSize.Amount,
+		); err != nil {
+			return nil, wrapDBError("scan summary", err)
+		}
+		cs.TotalRevenue.Currency = cs.Currency
+		cs.AverageOrderSize.Currency = cs.Currency
+
+		summary.TotalOrders += cs.TotalOrders
+		summary.ByCurrency = append(summary.ByCurrency, cs)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("get summary", err)
 	}
 
-	return db, nil
+	return summary, nil
 }
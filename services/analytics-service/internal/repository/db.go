@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	// Import MySQL driver for database/sql
+	_ "github.com/go-sql-driver/mysql"
+	// Import PostgreSQL driver for database/sql
+	_ "github.com/lib/pq"
+	// Import pure Go SQLite driver for database/sql
+	_ "modernc.org/sqlite"
+)
+
+// Database drivers accepted by InitDB and OpenDB, selected with DB_DRIVER
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqliteMaxOpenConns is the size of the connection pool to a SQLite database
+const sqliteMaxOpenConns = 10
+
+// sqliteTimeFormat is how timestamps are stored in SQLite, which has no date type. Written in
+// UTC, the text of two timestamps sorts like the timestamps themselves.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// dialect describes the SQL syntax that differs between the supported databases. Queries are
+// written with ? placeholders, which DB rewrites for drivers that number their parameters.
+type dialect struct {
+	driver string
+	// numbered reports whether placeholders are written $1, $2, ...
+	numbered bool
+	// skipDuplicates ends an INSERT so that rows violating a unique key are skipped
+	skipDuplicates string
+	// timeFormat, if set, is the layout time arguments are written as text with
+	timeFormat string
+}
+
+var dialects = map[string]*dialect{
+	DriverMySQL:    {driver: DriverMySQL, skipDuplicates: " ON DUPLICATE KEY UPDATE id = id"},
+	DriverPostgres: {driver: DriverPostgres, numbered: true, skipDuplicates: " ON CONFLICT DO NOTHING"},
+	DriverSQLite:   {driver: DriverSQLite, skipDuplicates: " ON CONFLICT DO NOTHING", timeFormat: sqliteTimeFormat},
+}
+
+// rebind rewrites the ? placeholders of query into the syntax of the driver
+func (d *dialect) rebind(query string) string {
+	if !d.numbered || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// bind converts the time arguments of a query into UTC text for databases without a date type,
+// so that they compare in order
+func (d *dialect) bind(args []interface{}) []interface{} {
+	if d.timeFormat == "" {
+		return args
+	}
+
+	bound := make([]interface{}, len(args))
+	for i, arg := range args {
+		if t, ok := arg.(time.Time); ok {
+			arg = t.UTC().Format(d.timeFormat)
+		}
+		bound[i] = arg
+	}
+	return bound
+}
+
+// DB is a connection pool to one of the supported databases. Its query methods accept
+// ? placeholders whatever the driver.
+type DB struct {
+	*sql.DB
+	dialect *dialect
+}
+
+// ExecContext executes a query without returning any rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.dialect.rebind(query), db.dialect.bind(args)...)
+}
+
+// QueryContext executes a query that returns rows
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, db.dialect.rebind(query), db.dialect.bind(args)...)
+}
+
+// DefaultPort returns the port the database of driver listens on by default
+func DefaultPort(driver string) string {
+	switch driver {
+	case DriverPostgres:
+		return "5432"
+	case DriverSQLite:
+		return ""
+	default:
+		return "3306"
+	}
+}
+
+// InitDB initializes the database connection for the given driver
+func InitDB(driver, host, port, user, password, dbname string) (*DB, error) {
+	return initDB(driver, host, port, user, password, dbname, false)
+}
+
+// InitMigrationDB initializes a database connection for running migrations. Migrations are
+// scripts of several statements, which MySQL only runs with multiStatements; connections
+// from InitDB leave it off so that an injected query cannot stack further statements.
+func InitMigrationDB(driver, host, port, user, password, dbname string) (*DB, error) {
+	return initDB(driver, host, port, user, password, dbname, true)
+}
+
+// initDB opens a connection pool for driver, allowing several statements per query on MySQL
+// when multiStatements is set
+func initDB(driver, host, port, user, password, dbname string, multiStatements bool) (*DB, error) {
+	var dsn string
+	switch driver {
+	case DriverMySQL:
+		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, password, host, port, dbname)
+		if multiStatements {
+			dsn += "&multiStatements=true"
+		}
+	case DriverPostgres:
+		// sslmode and the other connection settings come from the PG* environment variables
+		dsn = (&url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(user, password),
+			Host:   net.JoinHostPort(host, port),
+			Path:   "/" + dbname,
+		}).String()
+	case DriverSQLite:
+		// dbname is the path of the database file, created if missing. Transactions take the
+		// write lock when they begin, so that connections and processes sharing the file wait for
+		// each other.
+		dsn = "file:" + dbname + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	return OpenDB(driver, dsn)
+}
+
+// OpenDB opens a connection pool with a data source name in the format of the driver and
+// verifies that the database is reachable
+func OpenDB(driver, dsn string) (*DB, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Configure connection pool
+	if driver == DriverSQLite {
+		// In WAL mode readers do not block the single writer or each other, so reads and
+		// exports get connections of their own while writers take turns for the lock
+		db.SetMaxOpenConns(sqliteMaxOpenConns)
+		db.SetMaxIdleConns(sqliteMaxOpenConns)
+	} else {
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(5)
+		db.SetConnMaxLifetime(5 * time.Minute)
+	}
+
+	// Ping to verify connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Error closing database connection: %v", closeErr)
+		}
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{DB: db, dialect: d}, nil
+}
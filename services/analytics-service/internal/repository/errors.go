@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/andev0x/analytics-service/internal/model"
+	"github.com/go-sql-driver/mysql"
+)
+
+// wrapDBError describes a failed database operation, marking it model.ErrUnavailable
+// when the database could not be reached so callers can tell an outage from a bug
+func wrapDBError(op string, err error) error {
+	if isUnavailable(err) {
+		return fmt.Errorf("failed to %s: %w: %w", op, model.ErrUnavailable, err)
+	}
+	return fmt.Errorf("failed to %s: %w", op, err)
+}
+
+// isUnavailable reports whether err comes from a lost or unreachable database connection
+func isUnavailable(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr)
+}
@@ -28,12 +28,30 @@ func NewAnalyticsService(repo repository.AnalyticsRepository, cache cache.Analyt
 
 // ProcessOrderEvent processes an order created event
 func (s *AnalyticsService) ProcessOrderEvent(ctx context.Context, event *model.OrderCreatedEvent) error {
+	// Events with line items carry the authoritative breakdown;
+	// older events only have the single product and quantity
+	productID, quantity := event.ProductID, event.Quantity
+	if len(event.Items) > 0 {
+		productID = event.Items[0].ProductID
+		quantity = 0
+		for _, item := range event.Items {
+			quantity += item.Quantity
+		}
+	}
+
+	// Orders created before tenants were introduced belong to the default tenant
+	tenantID := event.TenantID
+	if tenantID == "" {
+		tenantID = model.DefaultTenantID
+	}
+
 	// Create metric from event
 	metric := &model.OrderMetric{
 		OrderID:     event.OrderID,
+		TenantID:    tenantID,
 		CustomerID:  event.CustomerID,
-		ProductID:   event.ProductID,
-		Quantity:    event.Quantity,
+		ProductID:   productID,
+		Quantity:    quantity,
 		TotalAmount: event.TotalAmount,
 		ProcessedAt: time.Now(),
 	}
@@ -44,27 +62,27 @@ func (s *AnalyticsService) ProcessOrderEvent(ctx context.Context, event *model.O
 	}
 
 	// Invalidate cache to force fresh calculation on next request
-	if err := s.cache.InvalidateSummary(ctx); err != nil {
+	if err := s.cache.InvalidateSummary(ctx, tenantID); err != nil {
 		log.Printf("Warning: failed to invalidate cache: %v", err)
 	}
 
-	log.Printf("Successfully processed order event: OrderID=%s, Amount=%.2f", event.OrderID, event.TotalAmount)
+	log.Printf("Successfully processed order event: OrderID=%s, Tenant=%s, Amount=%s", event.OrderID, tenantID, event.TotalAmount)
 	return nil
 }
 
-// GetSummary retrieves analytics summary (cache-aside pattern)
-func (s *AnalyticsService) GetSummary(ctx context.Context) (*model.AnalyticsSummary, error) {
+// GetSummary retrieves the analytics summary of a tenant (cache-aside pattern)
+func (s *AnalyticsService) GetSummary(ctx context.Context, tenantID string) (*model.AnalyticsSummary, error) {
 	// Try to get from cache first
-	summary, err := s.cache.GetSummary(ctx)
+	summary, err := s.cache.GetSummary(ctx, tenantID)
 	if err == nil {
-		log.Println("Cache hit for analytics summary")
+		log.Printf("Cache hit for analytics summary of tenant %s", tenantID)
 		return summary, nil
 	}
 
-	log.Println("Cache miss for analytics summary, fetching from database")
+	log.Printf("Cache miss for analytics summary of tenant %s, fetching from database", tenantID)
 
 	// Cache miss, get from database
-	summary, err = s.repo.GetSummary(ctx)
+	summary, err = s.repo.GetSummary(ctx, tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get summary: %w", err)
 	}
@@ -0,0 +1,2 @@
+-- Drop order_metrics table
+DROP TABLE IF EXISTS order_metrics;
@@ -0,0 +1,4 @@
+-- Allow several metrics per order again
+ALTER TABLE order_metrics
+    DROP INDEX idx_order_id,
+    ADD INDEX idx_order_id (order_id);
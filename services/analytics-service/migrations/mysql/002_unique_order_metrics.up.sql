@@ -0,0 +1,8 @@
+-- Record each order once, so that order.created events delivered again by the outbox relay
+-- are not counted twice. Duplicates recorded before are removed, keeping the first.
+DELETE m FROM order_metrics m
+    JOIN order_metrics first_metric ON first_metric.order_id = m.order_id AND first_metric.id < m.id;
+
+ALTER TABLE order_metrics
+    DROP INDEX idx_order_id,
+    ADD UNIQUE INDEX idx_order_id (order_id);
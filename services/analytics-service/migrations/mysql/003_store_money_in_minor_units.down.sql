@@ -0,0 +1,11 @@
+-- Store order totals as DECIMAL amounts again. Totals are converted back at 100 minor units per
+-- unit and the currency codes are dropped, so totals in other currencies than USD lose their currency.
+UPDATE order_metrics
+SET total_amount = total_amount_minor / 100
+WHERE total_amount IS NULL;
+
+ALTER TABLE order_metrics
+    MODIFY total_amount DECIMAL(10, 2) NOT NULL,
+    DROP INDEX idx_currency,
+    DROP COLUMN total_amount_minor,
+    DROP COLUMN currency;
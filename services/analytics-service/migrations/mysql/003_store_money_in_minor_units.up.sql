@@ -0,0 +1,13 @@
+-- Store order totals as integer minor units plus an ISO-4217 currency code.
+-- Existing DECIMAL totals were always in USD, so they are converted at 100 minor units per dollar.
+-- The DECIMAL column is kept but made nullable, so that the down migration can restore it;
+-- new rows leave it NULL.
+ALTER TABLE order_metrics
+    ADD COLUMN total_amount_minor BIGINT NOT NULL DEFAULT 0 AFTER total_amount,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER total_amount_minor,
+    ADD INDEX idx_currency (currency);
+
+UPDATE order_metrics
+SET total_amount_minor = ROUND(total_amount * 100);
+
+ALTER TABLE order_metrics MODIFY total_amount DECIMAL(10, 2) NULL DEFAULT NULL;
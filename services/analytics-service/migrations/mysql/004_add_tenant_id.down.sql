@@ -0,0 +1,4 @@
+-- Drop tenants. Metrics of every tenant are summed together again.
+ALTER TABLE order_metrics
+    DROP INDEX idx_tenant_currency,
+    DROP COLUMN tenant_id;
@@ -0,0 +1,5 @@
+-- Scope order metrics to the storefront (tenant) the order was placed in.
+-- Existing metrics belong to the default tenant; summaries are grouped per tenant and currency.
+ALTER TABLE order_metrics
+    ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER order_id,
+    ADD INDEX idx_tenant_currency (tenant_id, currency);
@@ -0,0 +1,2 @@
+-- Drop the analytics-service schema on SQLite
+DROP TABLE IF EXISTS order_metrics;
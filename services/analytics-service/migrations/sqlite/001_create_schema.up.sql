@@ -0,0 +1,18 @@
+-- Create the analytics-service schema on SQLite, as on PostgreSQL. Timestamps are stored as
+-- UTC text, which is parsed back into time.Time because the columns are declared TIMESTAMP.
+CREATE TABLE IF NOT EXISTS order_metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id VARCHAR(36) NOT NULL,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    customer_id VARCHAR(36) NOT NULL,
+    product_id VARCHAR(36) NOT NULL,
+    quantity INT NOT NULL,
+    total_amount_minor BIGINT NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    processed_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_metrics_order_id ON order_metrics (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_metrics_customer_id ON order_metrics (customer_id);
+CREATE INDEX IF NOT EXISTS idx_order_metrics_product_id ON order_metrics (product_id);
+CREATE INDEX IF NOT EXISTS idx_order_metrics_processed_at ON order_metrics (processed_at);
+CREATE INDEX IF NOT EXISTS idx_tenant_currency ON order_metrics (tenant_id, currency);
@@ -0,0 +1,150 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andev0x/analytics-service/internal/model"
+	"github.com/andev0x/analytics-service/internal/repository"
+	"github.com/andev0x/analytics-service/migrations"
+	"github.com/andev0x/pkg/migrate"
+)
+
+// conformanceBackends are the databases the repository conformance suite runs against. A backend
+// is skipped unless its environment variable holds the data source name of a database the suite
+// may create tables in. MySQL data source names need parseTime=true&multiStatements=true.
+// SQLite always runs, on a new database file.
+var conformanceBackends = []struct {
+	driver string
+	env    string
+}{
+	{repository.DriverMySQL, "TEST_MYSQL_DSN"},
+	{repository.DriverPostgres, "TEST_POSTGRES_DSN"},
+	{repository.DriverSQLite, ""},
+}
+
+// TestRepositoryConformance runs the same repository tests against every database backend
+func TestRepositoryConformance(t *testing.T) {
+	for _, backend := range conformanceBackends {
+		t.Run(backend.driver, func(t *testing.T) {
+			var db *repository.DB
+			if backend.env == "" {
+				db = openSQLiteDB(t)
+			} else {
+				dsn := os.Getenv(backend.env)
+				if dsn == "" {
+					t.Skipf("%s is not set", backend.env)
+				}
+				db = openConformanceDB(t, backend.driver, dsn)
+			}
+
+			testAnalyticsRepository(t, repository.NewSQLAnalyticsRepository(db))
+		})
+	}
+}
+
+// openConformanceDB connects to a backend and applies its pending migrations
+func openConformanceDB(t *testing.T, driver, dsn string) *repository.DB {
+	t.Helper()
+
+	db, err := repository.OpenDB(driver, dsn)
+	if err != nil {
+		t.Fatalf("OpenDB(%s) error = %v", driver, err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	migrateUp(t, db, driver)
+	return db
+}
+
+// openSQLiteDB creates a migrated SQLite database in a temporary directory
+func openSQLiteDB(t *testing.T) *repository.DB {
+	t.Helper()
+
+	db, err := repository.InitDB(repository.DriverSQLite, "", "", "", "", filepath.Join(t.TempDir(), "analytics.db"))
+	if err != nil {
+		t.Fatalf("InitDB(sqlite) error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	migrateUp(t, db, repository.DriverSQLite)
+	return db
+}
+
+// migrateUp applies the embedded migrations of driver to db
+func migrateUp(t *testing.T, db *repository.DB, driver string) {
+	t.Helper()
+
+	migrator, err := migrate.New(db.DB, driver, migrations.FS)
+	if err != nil {
+		t.Fatalf("migrate.New(%s) error = %v", driver, err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+}
+
+// testAnalyticsRepository checks that metrics are summed per tenant and currency, once per order
+func testAnalyticsRepository(t *testing.T, repo repository.AnalyticsRepository) {
+	ctx := context.Background()
+	// Runs against a shared database only see the metrics of their own tenants
+	runID := time.Now().UnixNano()
+	tenantID := fmt.Sprintf("conformance-%d", runID)
+	otherTenantID := tenantID + "-other"
+
+	metrics := []struct {
+		tenantID string
+		amount   model.Money
+	}{
+		{tenantID, model.Money{Amount: 1000, Currency: "USD"}},
+		{tenantID, model.Money{Amount: 2001, Currency: "USD"}},
+		{tenantID, model.Money{Amount: 500, Currency: "EUR"}},
+		{otherTenantID, model.Money{Amount: 9999, Currency: "USD"}},
+	}
+	// Saving the first metric again, as for a redelivered event, does not count it twice
+	metrics = append(metrics, metrics[0])
+	for i, m := range metrics {
+		err := repo.SaveOrderMetric(ctx, &model.OrderMetric{
+			OrderID:     fmt.Sprintf("%d-%d", runID, i%(len(metrics)-1)),
+			TenantID:    m.tenantID,
+			CustomerID:  "customer-1",
+			ProductID:   "product-1",
+			Quantity:    1,
+			TotalAmount: m.amount,
+			ProcessedAt: time.Now().UTC().Truncate(time.Second),
+		})
+		if err != nil {
+			t.Fatalf("SaveOrderMetric() error = %v", err)
+		}
+	}
+
+	summary, err := repo.GetSummary(ctx, tenantID)
+	if err != nil {
+		t.Fatalf("GetSummary() error = %v", err)
+	}
+	want := []model.CurrencySummary{
+		{Currency: "EUR", TotalOrders: 1, TotalRevenue: model.Money{Amount: 500, Currency: "EUR"}, AverageOrderSize: model.Money{Amount: 500, Currency: "EUR"}},
+		{Currency: "USD", TotalOrders: 2, TotalRevenue: model.Money{Amount: 3001, Currency: "USD"}, AverageOrderSize: model.Money{Amount: 1501, Currency: "USD"}},
+	}
+	if summary.TenantID != tenantID || summary.TotalOrders != 3 || !reflect.DeepEqual(summary.ByCurrency, want) {
+		t.Errorf("GetSummary() = %+v, want 3 orders in %+v", summary, want)
+	}
+
+	empty, err := repo.GetSummary(ctx, tenantID+"-empty")
+	if err != nil || empty.TotalOrders != 0 || len(empty.ByCurrency) != 0 {
+		t.Errorf("GetSummary() of a tenant without orders = %+v, %v, want no orders", empty, err)
+	}
+}
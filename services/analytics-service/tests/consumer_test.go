@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/andev0x/analytics-service/internal/model"
+	"github.com/andev0x/analytics-service/internal/mq"
+	"github.com/andev0x/analytics-service/internal/repository"
+	"github.com/andev0x/analytics-service/internal/service"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// MockAcknowledger records how deliveries are acknowledged
+type MockAcknowledger struct {
+	Acked, Nacked int
+}
+
+func (m *MockAcknowledger) Ack(_ uint64, _ bool) error {
+	m.Acked++
+	return nil
+}
+
+func (m *MockAcknowledger) Nack(_ uint64, _, _ bool) error {
+	m.Nacked++
+	return nil
+}
+
+func (m *MockAcknowledger) Reject(_ uint64, _ bool) error {
+	m.Nacked++
+	return nil
+}
+
+// MockAnalyticsCache is a mock implementation of AnalyticsCache that never holds a summary
+type MockAnalyticsCache struct{}
+
+func (m *MockAnalyticsCache) GetSummary(_ context.Context, _ string) (*model.AnalyticsSummary, error) {
+	return nil, errors.New("not found")
+}
+
+func (m *MockAnalyticsCache) SetSummary(_ context.Context, _ *model.AnalyticsSummary) error {
+	return nil
+}
+
+func (m *MockAnalyticsCache) InvalidateSummary(_ context.Context, _ string) error {
+	return nil
+}
+
+// TestConsumerCountsRedeliveredEventOnce tests that an order event delivered twice, as the
+// at-least-once outbox relay may do, is counted once
+func TestConsumerCountsRedeliveredEventOnce(t *testing.T) {
+	ctx := context.Background()
+	analytics := service.NewAnalyticsService(repository.NewSQLAnalyticsRepository(openSQLiteDB(t)), &MockAnalyticsCache{})
+	handler := func(event *model.OrderCreatedEvent) error {
+		return analytics.ProcessOrderEvent(ctx, event)
+	}
+
+	body, err := json.Marshal(model.OrderCreatedEvent{
+		OrderID:     "order-1",
+		TenantID:    "acme",
+		CustomerID:  "customer-1",
+		ProductID:   "product-1",
+		Quantity:    2,
+		TotalAmount: model.Money{Amount: 2500, Currency: "USD"},
+		EventType:   "OrderCreated",
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+
+	ack := &MockAcknowledger{}
+	for i := 0; i < 2; i++ {
+		mq.HandleDelivery(amqp.Delivery{Acknowledger: ack, Body: body, Redelivered: i > 0}, handler)
+	}
+	if ack.Acked != 2 || ack.Nacked != 0 {
+		t.Errorf("deliveries acked %d and nacked %d times, want both acked", ack.Acked, ack.Nacked)
+	}
+
+	summary, err := analytics.GetSummary(ctx, "acme")
+	if err != nil {
+		t.Fatalf("GetSummary() error = %v", err)
+	}
+	if summary.TotalOrders != 1 || len(summary.ByCurrency) != 1 || summary.ByCurrency[0].TotalRevenue.Amount != 2500 {
+		t.Errorf("GetSummary() = %+v, want the order counted once", summary)
+	}
+}
@@ -3,13 +3,16 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -21,18 +24,82 @@ const (
 	exchangeType = "topic"
 	queueName    = "notifications.orders"
 	routingKey   = "order.created"
+
+	// defaultTenantID is the storefront of orders created before orders carried a tenant
+	defaultTenantID = "default"
 )
 
-// OrderCreatedEvent represents the event consumed from RabbitMQ
+// OrderCreatedEvent represents the event consumed from RabbitMQ.
+// TenantID is empty in events published before orders carried a tenant.
 type OrderCreatedEvent struct {
-	OrderID     string    `json:"order_id"`
-	CustomerID  string    `json:"customer_id"`
-	ProductID   string    `json:"product_id"`
-	Quantity    int       `json:"quantity"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	EventType   string    `json:"event_type"`
+	OrderID     string      `json:"order_id"`
+	TenantID    string      `json:"tenant_id"`
+	CustomerID  string      `json:"customer_id"`
+	ProductID   string      `json:"product_id"`
+	Quantity    int         `json:"quantity"`
+	TotalAmount Money       `json:"total_amount"`
+	Status      string      `json:"status"`
+	Items       []OrderItem `json:"items,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	EventType   string      `json:"event_type"`
+}
+
+// OrderItem represents a line item carried in an order event
+type OrderItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	Discount  Money  `json:"discount"`
+	LineTotal Money  `json:"line_total"`
+}
+
+// currencyExponents maps ISO-4217 codes to their number of minor unit digits; unknown codes use 2
+var currencyExponents = map[string]int{"BHD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "VND": 0}
+
+// Money is an exact monetary amount in the minor units of an ISO-4217 currency
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// UnmarshalJSON accepts {"amount": 1999, "currency": "USD"} as well as a plain
+// decimal number such as 19.99, which older events use for amounts in USD
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' && data[0] != 'n' {
+		value, err := strconv.ParseFloat(string(data), 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s: %w", data, err)
+		}
+		*m = Money{Amount: int64(math.Round(value * 100)), Currency: "USD"}
+		return nil
+	}
+
+	type plain Money
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
+
+// String formats the amount in major units with its currency, e.g. "19.99 USD"
+func (m Money) String() string {
+	exp, ok := currencyExponents[m.Currency]
+	if !ok {
+		exp = 2
+	}
+	if exp == 0 {
+		return fmt.Sprintf("%d %s", m.Amount, m.Currency)
+	}
+
+	amount, sign := m.Amount, ""
+	if amount < 0 {
+		amount, sign = -amount, "-"
+	}
+	digits := fmt.Sprintf("%0*d", exp+1, amount)
+	return fmt.Sprintf("%s%s.%s %s", sign, digits[:len(digits)-exp], digits[len(digits)-exp:], m.Currency)
 }
 
 func main() {
@@ -191,8 +258,8 @@ func main() {
 				continue
 			}
 
-			log.Printf("Received OrderCreated event: OrderID=%s, CustomerID=%s",
-				event.OrderID, event.CustomerID)
+			log.Printf("Received OrderCreated event: OrderID=%s, TenantID=%s, CustomerID=%s",
+				event.OrderID, event.TenantID, event.CustomerID)
 
 			// Process notification
 			if err := sendNotification(&event); err != nil {
@@ -218,10 +285,24 @@ func sendNotification(event *OrderCreatedEvent) error {
 	// Simulate notification delay
 	time.Sleep(500 * time.Millisecond)
 
+	// Notifications are sent on behalf of the tenant's storefront
+	tenantID := event.TenantID
+	if tenantID == "" {
+		tenantID = defaultTenantID
+	}
+
 	// In a real system, this would integrate with email service, SMS gateway, etc.
-	log.Printf("📧 [NOTIFICATION] Order %s created for customer %s", event.OrderID, event.CustomerID)
-	log.Printf("   Product: %s, Quantity: %d, Total: $%.2f",
-		event.ProductID, event.Quantity, event.TotalAmount)
+	log.Printf("📧 [NOTIFICATION] Order %s created for customer %s in storefront %s", event.OrderID, event.CustomerID, tenantID)
+	if len(event.Items) == 0 {
+		log.Printf("   Product: %s, Quantity: %d, Total: %s",
+			event.ProductID, event.Quantity, event.TotalAmount)
+	} else {
+		for _, item := range event.Items {
+			log.Printf("   Product: %s, Quantity: %d x %s = %s",
+				item.ProductID, item.Quantity, item.UnitPrice, item.LineTotal)
+		}
+		log.Printf("   Total: %s", event.TotalAmount)
+	}
 
 	// Simulate occasional failures for demonstration
 	// In production, this would be actual failure from external service
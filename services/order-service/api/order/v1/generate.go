@@ -0,0 +1,5 @@
+// Package orderv1 contains the protobuf messages and the generated gRPC client
+// and server interfaces of the order service API defined in order.proto.
+package orderv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative order/v1/order.proto
@@ -0,0 +1,66 @@
+// Package main provides a command that issues an API key directly in the database, to
+// create the first admin key before any caller can use POST /admin/api-keys.
+//
+// Usage:
+//
+//	create-api-key -name NAME [-role admin] [-customer ID] [-tenant ID] [-rate-limit N]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+)
+
+func main() {
+	req := &model.CreateAPIKeyRequest{}
+	flag.StringVar(&req.Name, "name", "", "name of the key, e.g. the partner or operator it is for")
+	flag.StringVar(&req.Role, "role", auth.RoleAdmin, "role of the key: customer, service or admin")
+	flag.StringVar(&req.CustomerID, "customer", "", "customer a customer key acts for")
+	flag.StringVar(&req.TenantID, "tenant", "", "tenant the key is bound to; empty for every tenant")
+	flag.IntVar(&req.RateLimit, "rate-limit", 0, "requests per minute; 0 for the default limit")
+	flag.Parse()
+
+	dbDriver := getEnv("DB_DRIVER", repository.DriverMySQL)
+	db, err := repository.InitDB(
+		dbDriver,
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", repository.DefaultPort(dbDriver)),
+		getEnv("DB_USER", "orderuser"),
+		getEnv("DB_PASSWORD", "orderpass"),
+		getEnv("DB_NAME", "order_db"),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database connection: %v", err)
+		}
+	}()
+
+	issued, err := service.NewAPIKeyService(repository.NewSQLAPIKeyRepository(db)).IssueKey(context.Background(), req)
+	if err != nil {
+		log.Printf("Failed to issue API key: %v", err)
+		os.Exit(1)
+	}
+	log.Printf("Issued %s API key %s (%s)", issued.Role, issued.ID, issued.Name)
+
+	// The key is printed alone on stdout so it can be captured by a script
+	fmt.Println(issued.Key)
+}
+
+// getEnv gets an environment variable or returns a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
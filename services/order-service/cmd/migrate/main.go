@@ -0,0 +1,127 @@
+// Package main provides a command that applies the schema migrations embedded in it to the
+// database configured with the DB_* environment variables.
+//
+// Usage:
+//
+//	migrate up         # apply every pending migration
+//	migrate down N     # roll back the last N applied migrations
+//	migrate status     # list the migrations and whether they are applied
+//	migrate force V    # record migrations up to version V as applied and clear the dirty flag
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/migrations"
+	"github.com/andev0x/pkg/migrate"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	dbDriver := getEnv("DB_DRIVER", repository.DriverMySQL)
+	db, err := repository.InitMigrationDB(
+		dbDriver,
+		getEnv("DB_HOST", "localhost"),
+		getEnv("DB_PORT", repository.DefaultPort(dbDriver)),
+		getEnv("DB_USER", "orderuser"),
+		getEnv("DB_PASSWORD", "orderpass"),
+		getEnv("DB_NAME", "order_db"),
+	)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database connection: %v", err)
+		}
+	}()
+
+	migrator, err := migrate.New(db.DB, dbDriver, migrations.FS)
+	if err != nil {
+		log.Printf("Failed to load migrations: %v", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, migrator, os.Args[1], os.Args[2:]); err != nil {
+		log.Printf("Migration failed: %v", err)
+		os.Exit(1)
+	}
+}
+
+// run executes one subcommand
+func run(ctx context.Context, migrator *migrate.Migrator, command string, args []string) error {
+	switch {
+	case command == "up" && len(args) == 0:
+		applied, err := migrator.Up(ctx)
+		log.Printf("Applied %d migrations", applied)
+		return err
+
+	case command == "down" && len(args) == 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q", args[0])
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		log.Printf("Rolled back %d migrations", rolledBack)
+		return err
+
+	case command == "status" && len(args) == 0:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Dirty:
+				state = "dirty"
+			case status.Applied:
+				state = "applied " + status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d  %-40s %s\n", status.Version, status.Name, state)
+		}
+		return nil
+
+	case command == "force" && len(args) == 1:
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[0])
+		}
+		if err := migrator.Force(ctx, version); err != nil {
+			return err
+		}
+		log.Printf("Forced schema version %d", version)
+		return nil
+
+	default:
+		usage()
+		return nil
+	}
+}
+
+// usage prints how to run the command and exits
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate up | down N | status | force V")
+	os.Exit(2)
+}
+
+// getEnv gets an environment variable or returns a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
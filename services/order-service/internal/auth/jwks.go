@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// jsonWebKey is a single key of a JWKS document (RFC 7517).
+// RSA keys carry N and E; symmetric ("oct") keys carry K.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+// verificationKey is a parsed key and the signing algorithm it verifies
+type verificationKey struct {
+	id  string
+	alg string
+	key interface{} // *rsa.PublicKey for RS256, []byte for HS256
+}
+
+// KeySet holds the keys tokens may be signed with
+type KeySet struct {
+	keys []verificationKey
+}
+
+// LoadJWKS reads a JWKS document from a file
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JWKS document. RSA keys verify RS256 tokens and symmetric
+// keys verify HS256 tokens; keys of other types or for other algorithms are rejected.
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	if len(doc.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no keys")
+	}
+
+	set := &KeySet{}
+	for i, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseKey(jwk)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %d (%q): %w", i, jwk.Kid, err)
+		}
+		set.keys = append(set.keys, key)
+	}
+	if len(set.keys) == 0 {
+		return nil, fmt.Errorf("JWKS contains no signing keys")
+	}
+	return set, nil
+}
+
+func parseKey(jwk jsonWebKey) (verificationKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		if jwk.Alg != "" && jwk.Alg != "RS256" {
+			return verificationKey{}, fmt.Errorf("unsupported algorithm %q for RSA key", jwk.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil || len(n) == 0 {
+			return verificationKey{}, fmt.Errorf("invalid modulus")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return verificationKey{}, fmt.Errorf("invalid exponent")
+		}
+		pub := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return verificationKey{id: jwk.Kid, alg: "RS256", key: pub}, nil
+	case "oct":
+		if jwk.Alg != "" && jwk.Alg != "HS256" {
+			return verificationKey{}, fmt.Errorf("unsupported algorithm %q for symmetric key", jwk.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil || len(secret) == 0 {
+			return verificationKey{}, fmt.Errorf("invalid symmetric key")
+		}
+		return verificationKey{id: jwk.Kid, alg: "HS256", key: secret}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+// lookup finds the key for a token's kid and algorithm. A token without a kid
+// is accepted only when exactly one key matches its algorithm.
+func (s *KeySet) lookup(kid, alg string) (interface{}, bool) {
+	var found interface{}
+	matches := 0
+	for _, k := range s.keys {
+		if k.alg != alg || (kid != "" && k.id != kid) {
+			continue
+		}
+		found = k.key
+		matches++
+	}
+	return found, matches == 1
+}
@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// IdempotencyLockTTL is how long a reservation lasts unless it is extended
+	IdempotencyLockTTL = 30 * time.Second
+
+	idempotencyKeyPrefix = "idempotency:"
+	idempotencyTTL       = 24 * time.Hour
+)
+
+// extendReservationScript pushes back the expiry of a reservation that is still in progress,
+// leaving completed records alone
+var extendReservationScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// IdempotencyStore interface defines methods for tracking idempotent requests
+type IdempotencyStore interface {
+	// Reserve claims the key for a new request. It returns nil if the key was
+	// claimed, or the existing record if another request already holds it.
+	Reserve(ctx context.Context, key, requestHash string) (*model.IdempotencyRecord, error)
+	// Extend keeps the reservation of the key from expiring while its request runs
+	Extend(ctx context.Context, key, requestHash string) error
+	Complete(ctx context.Context, key string, record *model.IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+}
+
+// RedisIdempotencyStore implements IdempotencyStore using Redis
+type RedisIdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewRedisIdempotencyStore creates a new Redis idempotency store
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+// Reserve claims the key with an in-progress record, or returns the record already stored under it
+func (s *RedisIdempotencyStore) Reserve(ctx context.Context, key, requestHash string) (*model.IdempotencyRecord, error) {
+	redisKey := idempotencyKeyPrefix + key
+	data, err := json.Marshal(&model.IdempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	// The existing record may expire between SETNX and GET, so retry once
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := s.client.SetNX(ctx, redisKey, data, IdempotencyLockTTL).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		existing, err := s.client.Get(ctx, redisKey).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get idempotency record: %w", err)
+		}
+
+		var record model.IdempotencyRecord
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal idempotency record: %w", err)
+		}
+		return &record, nil
+	}
+
+	return nil, fmt.Errorf("failed to reserve idempotency key: key is changing concurrently")
+}
+
+// Extend restarts the expiry of an in-progress reservation of the key
+func (s *RedisIdempotencyStore) Extend(ctx context.Context, key, requestHash string) error {
+	data, err := json.Marshal(&model.IdempotencyRecord{RequestHash: requestHash})
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	err = extendReservationScript.Run(ctx, s.client, []string{idempotencyKeyPrefix + key},
+		string(data), IdempotencyLockTTL.Milliseconds()).Err()
+	if err != nil {
+		return fmt.Errorf("failed to extend idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Complete stores the final response for the key
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, record *model.IdempotencyRecord) error {
+	record.Completed = true
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	if err := s.client.Set(ctx, idempotencyKeyPrefix+key, data, idempotencyTTL).Err(); err != nil {
+		return fmt.Errorf("failed to store idempotency record: %w", err)
+	}
+
+	return nil
+}
+
+// Release removes an in-progress reservation so the request can be retried
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, idempotencyKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
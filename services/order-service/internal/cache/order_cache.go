@@ -4,7 +4,10 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/rand"
 	"time"
 
 	"github.com/andev0x/order-service/internal/model"
@@ -14,12 +17,32 @@ import (
 const (
 	orderKeyPrefix = "order:"
 	orderTTL       = 15 * time.Minute
+
+	// notFoundTTL is how long an order ID that does not exist is remembered, short so that
+	// an order created under that ID is seen soon even if its cache write failed
+	notFoundTTL = 30 * time.Second
+	// notFoundValue marks an order ID that does not exist; it never decodes as an order
+	notFoundValue = "-"
+
+	// earlyExpiryDelta scales probabilistic early expiration: an entry with r left to live
+	// is treated as expired with probability exp(-r/earlyExpiryDelta), so a busy order is
+	// refreshed by one request shortly before it expires rather than by every request after
+	earlyExpiryDelta = time.Second
 )
 
-// OrderCache interface defines methods for caching orders
+var (
+	// ErrMiss is returned by Get when the order is not cached
+	ErrMiss = errors.New("order not found in cache")
+	// ErrNotFound is returned by Get when the cache records that the order does not exist
+	ErrNotFound = errors.New("order cached as not found")
+)
+
+// OrderCache interface defines methods for caching orders.
+// Orders are cached per tenant: Get, SetNotFound and Delete look in the tenant of their context.
 type OrderCache interface {
 	Get(ctx context.Context, id string) (*model.Order, error)
 	Set(ctx context.Context, order *model.Order) error
+	SetNotFound(ctx context.Context, id string) error
 	Delete(ctx context.Context, id string) error
 }
 
@@ -33,16 +56,31 @@ func NewRedisOrderCache(client *redis.Client) *RedisOrderCache {
 	return &RedisOrderCache{client: client}
 }
 
-// Get retrieves an order from cache
+// Get retrieves an order from cache. An order close to expiring may be reported as a miss
+// early so that it is reloaded before it expires.
 func (c *RedisOrderCache) Get(ctx context.Context, id string) (*model.Order, error) {
-	key := orderKeyPrefix + id
-	data, err := c.client.Get(ctx, key).Bytes()
+	tenantID, _ := model.TenantFromContext(ctx)
+	key := orderKey(tenantID, id)
+
+	pipe := c.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to get order from cache: %w", err)
+	}
+	data, err := get.Bytes()
 	if err == redis.Nil {
-		return nil, fmt.Errorf("order not found in cache")
+		return nil, ErrMiss
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order from cache: %w", err)
 	}
+	if string(data) == notFoundValue {
+		return nil, ErrNotFound
+	}
+	if expiresEarly(ttl.Val()) {
+		return nil, ErrMiss
+	}
 
 	var order model.Order
 	if err := json.Unmarshal(data, &order); err != nil {
@@ -54,7 +92,7 @@ func (c *RedisOrderCache) Get(ctx context.Context, id string) (*model.Order, err
 
 // Set stores an order in cache
 func (c *RedisOrderCache) Set(ctx context.Context, order *model.Order) error {
-	key := orderKeyPrefix + order.ID
+	key := orderKey(order.TenantID, order.ID)
 	data, err := json.Marshal(order)
 	if err != nil {
 		return fmt.Errorf("failed to marshal order: %w", err)
@@ -67,15 +105,41 @@ func (c *RedisOrderCache) Set(ctx context.Context, order *model.Order) error {
 	return nil
 }
 
+// SetNotFound records that an order does not exist for a short time
+func (c *RedisOrderCache) SetNotFound(ctx context.Context, id string) error {
+	tenantID, _ := model.TenantFromContext(ctx)
+	key := orderKey(tenantID, id)
+	if err := c.client.Set(ctx, key, notFoundValue, notFoundTTL).Err(); err != nil {
+		return fmt.Errorf("failed to set order not found in cache: %w", err)
+	}
+	return nil
+}
+
 // Delete removes an order from cache
 func (c *RedisOrderCache) Delete(ctx context.Context, id string) error {
-	key := orderKeyPrefix + id
+	tenantID, _ := model.TenantFromContext(ctx)
+	key := orderKey(tenantID, id)
 	if err := c.client.Del(ctx, key).Err(); err != nil {
 		return fmt.Errorf("failed to delete order from cache: %w", err)
 	}
 	return nil
 }
 
+// expiresEarly reports whether an entry with ttl left to live should be treated as expired.
+// Entries without an expiry, reported by Redis as a negative ttl, never expire early.
+func expiresEarly(ttl time.Duration) bool {
+	if ttl <= 0 {
+		return false
+	}
+	//nolint:gosec // the early expiration draw is not security sensitive
+	return -float64(earlyExpiryDelta)*math.Log(rand.Float64()) >= float64(ttl)
+}
+
+// orderKey returns the cache key of an order of tenantID
+func orderKey(tenantID, id string) string {
+	return orderKeyPrefix + tenantID + ":" + id
+}
+
 // InitRedis initializes Redis client
 func InitRedis(host, port string) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
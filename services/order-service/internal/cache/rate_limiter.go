@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimiter interface defines methods for distributed rate limiting
+type RateLimiter interface {
+	// Allow takes one request from the token bucket named key, which holds up to
+	// limit.Requests tokens and refills at limit.Requests per limit.Period
+	Allow(ctx context.Context, key string, limit model.RateLimit) (*model.RateLimitResult, error)
+}
+
+// tokenBucketScript refills and takes from a token bucket atomically, using the Redis
+// clock so that every replica sees the same time. The bucket is a hash of the remaining
+// tokens and the time they were counted, and expires once it would be full again.
+// It returns {allowed, remaining, reset_ms, retry_after_ms}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate)
+end
+
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// RedisRateLimiter implements RateLimiter with token buckets shared by all replicas through Redis
+type RedisRateLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimiter creates a new Redis rate limiter
+func NewRedisRateLimiter(client *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{client: client}
+}
+
+// Allow takes one request from the bucket named key
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (*model.RateLimitResult, error) {
+	ratePerMs := float64(limit.Requests) / float64(limit.Period.Milliseconds())
+	values, err := tokenBucketScript.Run(ctx, l.client, []string{rateLimitKeyPrefix + key}, limit.Requests, ratePerMs).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take from rate limit bucket: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+
+	return &model.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
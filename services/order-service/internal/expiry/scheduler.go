@@ -0,0 +1,122 @@
+// Package expiry cancels pending orders that were not confirmed in time.
+package expiry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// LeaseName is the scheduler lease held by the replica running the sweep
+const LeaseName = "order-expiry"
+
+var expiredTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "orders_expired_total",
+	Help: "Total number of pending orders cancelled because they expired",
+})
+
+// OrderExpirer expires pending orders created before a cutoff
+type OrderExpirer interface {
+	ExpirePendingOrders(ctx context.Context, cutoff time.Time, limit int) (int, error)
+}
+
+// Config holds scheduler tuning parameters
+type Config struct {
+	TTL       time.Duration // how long an order may stay pending
+	Interval  time.Duration // how often to sweep for expired orders
+	BatchSize int           // maximum orders expired per sweep
+	Lease     time.Duration // how long a sweep keeps other replicas out
+}
+
+// DefaultConfig returns the default scheduler configuration
+func DefaultConfig() Config {
+	return Config{
+		TTL:       24 * time.Hour,
+		Interval:  time.Minute,
+		BatchSize: 100,
+		Lease:     2 * time.Minute,
+	}
+}
+
+// Scheduler periodically cancels pending orders older than the TTL.
+// Every replica runs a scheduler but only the one holding the lease sweeps.
+type Scheduler struct {
+	orders OrderExpirer
+	leases repository.LeaseRepository
+	config Config
+	holder string
+}
+
+// NewScheduler creates a new expiry scheduler
+func NewScheduler(orders OrderExpirer, leases repository.LeaseRepository, config Config) *Scheduler {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &Scheduler{
+		orders: orders,
+		leases: leases,
+		config: config,
+		holder: fmt.Sprintf("%s/%d/%s", hostname, os.Getpid(), uuid.New().String()),
+	}
+}
+
+// Run sweeps for expired orders until the context is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Order expiry scheduler started (TTL %s, interval %s, batch size %d)",
+		s.config.TTL, s.config.Interval, s.config.BatchSize)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error expiring pending orders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			// Hand the lease over straight away rather than making other replicas wait it out
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := s.leases.Release(releaseCtx, LeaseName, s.holder); err != nil {
+				log.Printf("Error releasing order expiry lease: %v", err)
+			}
+			cancel()
+			log.Println("Order expiry scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep expires one batch of pending orders if this replica holds the lease,
+// and returns how many were expired
+func (s *Scheduler) Sweep(ctx context.Context) (int, error) {
+	acquired, err := s.leases.Acquire(ctx, LeaseName, s.holder, s.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if !acquired {
+		return 0, nil
+	}
+
+	ctx = model.WithActor(ctx, model.Actor{Type: model.ActorTypeScheduler, Name: LeaseName})
+	cutoff := time.Now().Add(-s.config.TTL)
+
+	expired, err := s.orders.ExpirePendingOrders(ctx, cutoff, s.config.BatchSize)
+	expiredTotal.Add(float64(expired))
+	if expired > 0 {
+		log.Printf("Expired %d pending orders created before %s", expired, cutoff.Format(time.RFC3339))
+	}
+
+	return expired, err
+}
@@ -0,0 +1,113 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadataKey is the request metadata carrying an API key
+const APIKeyMetadataKey = "x-api-key"
+
+// AuthConfig configures how calls are authenticated, with the same credentials as the HTTP API.
+// Without a Verifier bearer tokens are not accepted. AllowAnonymous lets calls without
+// credentials through, for local development only.
+type AuthConfig struct {
+	Verifier       *auth.Verifier
+	Keys           auth.APIKeyVerifier
+	AllowAnonymous bool
+}
+
+// authenticator identifies callers by an API key or a JWT bearer token in their metadata.
+// The gRPC API does not scope calls to a customer, so only admins and services may use it.
+type authenticator struct {
+	config AuthConfig
+}
+
+// unary authenticates unary calls
+func (a *authenticator) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// stream authenticates streaming calls
+func (a *authenticator) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate returns ctx carrying the caller's principal. Health checks need no credentials.
+func (a *authenticator) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	principal, err := a.principal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return ctx, nil
+	}
+	if !principal.HasFullAccess() {
+		return nil, status.Error(codes.PermissionDenied, "the gRPC API requires the admin or service role")
+	}
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// principal verifies the credentials in the incoming metadata. It returns no principal and
+// no error for anonymous calls when they are allowed.
+func (a *authenticator) principal(ctx context.Context) (*auth.Principal, error) {
+	if apiKey := firstMetadataValue(ctx, APIKeyMetadataKey); apiKey != "" {
+		principal, err := a.config.Keys.VerifyAPIKey(ctx, apiKey)
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, "the API key is invalid or revoked")
+		}
+		if err != nil {
+			return nil, statusError(err, "failed to check API key")
+		}
+		return principal, nil
+	}
+
+	scheme, token, _ := strings.Cut(firstMetadataValue(ctx, "authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, "Bearer") || token == "" {
+		if a.config.AllowAnonymous {
+			return nil, nil
+		}
+		return nil, status.Error(codes.Unauthenticated, "a bearer token or API key is required")
+	}
+	if a.config.Verifier == nil {
+		return nil, status.Error(codes.Unauthenticated, "bearer tokens are not accepted, use an API key")
+	}
+
+	principal, err := a.config.Verifier.Verify(token)
+	if err != nil {
+		log.Printf("Rejected token for gRPC call: %v", err)
+		return nil, status.Error(codes.Unauthenticated, "the bearer token is invalid or expired")
+	}
+	return principal, nil
+}
+
+// firstMetadataValue returns the first value of key in the incoming metadata of ctx
+func firstMetadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
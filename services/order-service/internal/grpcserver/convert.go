@@ -0,0 +1,87 @@
+package grpcserver
+
+import (
+	orderv1 "github.com/andev0x/order-service/api/order/v1"
+	"github.com/andev0x/order-service/internal/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProtoOrder converts an order to its protobuf message
+func toProtoOrder(order *model.Order) *orderv1.Order {
+	items := make([]*orderv1.OrderItem, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = &orderv1.OrderItem{
+			ProductId: item.ProductID,
+			Quantity:  int32(item.Quantity),
+			UnitPrice: toProtoMoney(item.UnitPrice),
+			Discount:  toProtoMoney(item.Discount),
+			LineTotal: toProtoMoney(item.LineTotal),
+		}
+	}
+
+	return &orderv1.Order{
+		Id:          order.ID,
+		TenantId:    order.TenantID,
+		CustomerId:  order.CustomerID,
+		ProductId:   order.ProductID,
+		Quantity:    int32(order.Quantity),
+		TotalAmount: toProtoMoney(order.TotalAmount),
+		Status:      order.Status,
+		Items:       items,
+		Version:     order.Version,
+		CreatedAt:   timestamppb.New(order.CreatedAt),
+		UpdatedAt:   timestamppb.New(order.UpdatedAt),
+	}
+}
+
+func toProtoMoney(m model.Money) *orderv1.Money {
+	return &orderv1.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+// fromProtoMoney converts an optional protobuf amount, returning nil when it is unset
+func fromProtoMoney(m *orderv1.Money) *model.Money {
+	if m == nil {
+		return nil
+	}
+	money := model.NewMoney(m.GetAmount(), m.GetCurrency())
+	return &money
+}
+
+// fromProtoCreateRequest converts a create request to the service layer request
+func fromProtoCreateRequest(req *orderv1.CreateOrderRequest) *model.CreateOrderRequest {
+	items := make([]model.CreateOrderItemRequest, len(req.GetItems()))
+	for i, item := range req.GetItems() {
+		items[i] = model.CreateOrderItemRequest{
+			ProductID: item.GetProductId(),
+			Quantity:  int(item.GetQuantity()),
+			UnitPrice: fromProtoMoney(item.GetUnitPrice()),
+		}
+	}
+
+	return &model.CreateOrderRequest{
+		CustomerID:  req.GetCustomerId(),
+		Items:       items,
+		TotalAmount: fromProtoMoney(req.GetTotalAmount()),
+	}
+}
+
+// fromProtoFilter converts the filter fields of a list request
+func fromProtoFilter(req *orderv1.ListOrdersRequest) model.OrderFilter {
+	filter := model.OrderFilter{
+		CustomerID: req.GetCustomerId(),
+		ProductID:  req.GetProductId(),
+		Status:     req.GetStatus(),
+		Currency:   req.GetCurrency(),
+		MinAmount:  fromProtoMoney(req.GetMinAmount()),
+		MaxAmount:  fromProtoMoney(req.GetMaxAmount()),
+	}
+	if req.GetCreatedFrom() != nil {
+		from := req.GetCreatedFrom().AsTime()
+		filter.CreatedFrom = &from
+	}
+	if req.GetCreatedTo() != nil {
+		to := req.GetCreatedTo().AsTime()
+		filter.CreatedTo = &to
+	}
+	return filter
+}
@@ -0,0 +1,83 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	orderv1 "github.com/andev0x/order-service/api/order/v1"
+	"github.com/andev0x/order-service/internal/ratelimit"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+var rateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "grpc_rate_limited_calls_total",
+	Help: "Number of gRPC calls rejected by the rate limiter, by method",
+}, []string{"method"})
+
+// httpRoutes maps the methods of the order API to the HTTP routes they mirror, so that a
+// route limit such as "POST /orders" applies to both APIs
+var httpRoutes = map[string]string{
+	orderv1.OrderService_CreateOrder_FullMethodName: "POST /orders",
+	orderv1.OrderService_GetOrder_FullMethodName:    "GET /orders/{id}",
+	orderv1.OrderService_ListOrders_FullMethodName:  "GET /orders/export",
+	orderv1.OrderService_WatchOrder_FullMethodName:  "GET /orders/{id}/events",
+}
+
+// rateLimiter takes calls from the same buckets as the HTTP requests of their caller. Streaming
+// calls are taken once, when they start.
+type rateLimiter struct {
+	limiter *ratelimit.Limiter
+}
+
+// unary rate limits unary calls
+func (l *rateLimiter) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.take(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// stream rate limits streaming calls
+func (l *rateLimiter) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := l.take(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// take takes a call to method from the caller's buckets. It returns a ResourceExhausted status
+// with RetryInfo if the caller is over its rate. Health checks are not limited.
+func (l *rateLimiter) take(ctx context.Context, method string) error {
+	if l.limiter == nil || strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	route, ok := httpRoutes[method]
+	if !ok {
+		route = method
+	}
+	var remoteAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+
+	result := l.limiter.Take(ctx, route, remoteAddr)
+	if result == nil || result.Allowed {
+		return nil
+	}
+
+	rateLimitedTotal.WithLabelValues(method).Inc()
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded, retry later")
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(result.RetryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,241 @@
+// Package grpcserver serves the order API defined in api/order/v1 over gRPC.
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"time"
+
+	orderv1 "github.com/andev0x/order-service/api/order/v1"
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/ratelimit"
+	"github.com/andev0x/order-service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultWatchInterval is how often WatchOrder checks for a new order version by default
+const DefaultWatchInterval = time.Second
+
+// TenantMetadataKey is the request metadata naming the tenant a call is for
+const TenantMetadataKey = "x-tenant-id"
+
+// Server serves the order API together with the standard gRPC health and reflection services
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+	orders *OrderServer
+}
+
+// NewServer creates a gRPC server backed by orderService whose calls are authenticated as
+// configured by authConfig and taken from the caller's buckets in limiter, unless limiter is
+// nil. WatchOrder streams check for changes every watchInterval.
+func NewServer(orderService *service.OrderService, authConfig AuthConfig, limiter *ratelimit.Limiter, watchInterval time.Duration) *Server {
+	authn := &authenticator{config: authConfig}
+	limits := &rateLimiter{limiter: limiter}
+	s := &Server{
+		grpc: grpc.NewServer(
+			grpc.ChainUnaryInterceptor(authn.unary, limits.unary, unaryActor, unaryTenant),
+			grpc.ChainStreamInterceptor(authn.stream, limits.stream, streamActor, streamTenant),
+		),
+		health: health.NewServer(),
+		orders: NewOrderServer(orderService, watchInterval),
+	}
+
+	orderv1.RegisterOrderServiceServer(s.grpc, s.orders)
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	reflection.Register(s.grpc)
+	s.health.SetServingStatus(orderv1.OrderService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
+	return s
+}
+
+// Serve accepts connections on lis until the server is stopped
+func (s *Server) Serve(lis net.Listener) error {
+	return s.grpc.Serve(lis)
+}
+
+// Shutdown reports NOT_SERVING, ends open WatchOrder streams and waits for in-flight calls
+// to finish. Calls still running when ctx is done are cancelled.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.health.Shutdown()
+	s.orders.close()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.grpc.Stop()
+	}
+}
+
+// OrderServer implements the OrderService gRPC API on top of the service layer
+type OrderServer struct {
+	orderv1.UnimplementedOrderServiceServer
+	service       *service.OrderService
+	watchInterval time.Duration
+	done          chan struct{}
+}
+
+// NewOrderServer creates an OrderServer. A non-positive watchInterval uses DefaultWatchInterval.
+func NewOrderServer(orderService *service.OrderService, watchInterval time.Duration) *OrderServer {
+	if watchInterval <= 0 {
+		watchInterval = DefaultWatchInterval
+	}
+	return &OrderServer{
+		service:       orderService,
+		watchInterval: watchInterval,
+		done:          make(chan struct{}),
+	}
+}
+
+// close ends all open and future WatchOrder streams
+func (s *OrderServer) close() {
+	close(s.done)
+}
+
+// CreateOrder prices and stores a new pending order
+func (s *OrderServer) CreateOrder(ctx context.Context, req *orderv1.CreateOrderRequest) (*orderv1.CreateOrderResponse, error) {
+	order, err := s.service.CreateOrder(ctx, fromProtoCreateRequest(req))
+	if err != nil {
+		return nil, statusError(err, "failed to create order")
+	}
+	return &orderv1.CreateOrderResponse{Order: toProtoOrder(order)}, nil
+}
+
+// GetOrder returns a single order by ID
+func (s *OrderServer) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.GetOrderResponse, error) {
+	order, err := s.service.GetOrderByID(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(err, "failed to get order")
+	}
+	return &orderv1.GetOrderResponse{Order: toProtoOrder(order)}, nil
+}
+
+// ListOrders streams every order matching the filter, oldest first, straight from a database cursor
+func (s *OrderServer) ListOrders(req *orderv1.ListOrdersRequest, stream orderv1.OrderService_ListOrdersServer) error {
+	ctx := stream.Context()
+	err := s.service.ExportOrders(ctx, fromProtoFilter(req), req.GetSince(), func(order *model.Order) error {
+		return stream.Send(&orderv1.ListOrdersResponse{
+			Order:  toProtoOrder(order),
+			Cursor: model.CursorAfter(order).Encode(),
+		})
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return statusError(err, "failed to list orders")
+	}
+	return nil
+}
+
+// WatchOrder sends the current order and then every new version of it until the order
+// reaches a final status, the client goes away or the server shuts down
+func (s *OrderServer) WatchOrder(req *orderv1.WatchOrderRequest, stream orderv1.OrderService_WatchOrderServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var version int64
+	for {
+		order, err := s.service.GetOrderByID(ctx, req.GetId())
+		if err != nil {
+			if ctx.Err() != nil {
+				return status.FromContextError(ctx.Err()).Err()
+			}
+			return statusError(err, "failed to watch order")
+		}
+
+		if order.Version > version {
+			version = order.Version
+			if err := stream.Send(&orderv1.WatchOrderResponse{Order: toProtoOrder(order)}); err != nil {
+				return err
+			}
+		}
+		if model.IsFinalStatus(order.Status) {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+	}
+}
+
+// unaryActor attributes order changes made by unary calls to the API and the called method
+func unaryActor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withMethodActor(ctx, info.FullMethod), req)
+}
+
+// streamActor attributes order changes made by streaming calls to the API and the called method
+func streamActor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: withMethodActor(ss.Context(), info.FullMethod)})
+}
+
+func withMethodActor(ctx context.Context, method string) context.Context {
+	return model.WithActor(ctx, model.Actor{Type: model.ActorTypeAPI, Name: "gRPC " + method})
+}
+
+// unaryTenant scopes unary calls to the tenant of the caller, see withMetadataTenant
+func unaryTenant(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := withMetadataTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamTenant scopes streaming calls to the tenant of the caller, see withMetadataTenant
+func streamTenant(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := withMetadataTenant(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// withMetadataTenant scopes ctx to a tenant like handler.ResolveTenant: callers whose token or
+// API key is bound to a tenant act in that tenant and may not name another in the
+// TenantMetadataKey metadata; other callers name the tenant there or use the default tenant.
+func withMetadataTenant(ctx context.Context) (context.Context, error) {
+	tenantID := firstMetadataValue(ctx, TenantMetadataKey)
+	if tenantID != "" && !model.ValidTenantID(tenantID) {
+		return nil, status.Errorf(codes.InvalidArgument, "%s must be up to 64 letters, digits, '-' and '_'", TenantMetadataKey)
+	}
+
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principal.TenantID != "" {
+		if tenantID != "" && tenantID != principal.TenantID {
+			return nil, status.Error(codes.PermissionDenied, "access to this tenant is not allowed")
+		}
+		tenantID = principal.TenantID
+	}
+	if tenantID == "" {
+		tenantID = model.DefaultTenantID
+	}
+	return model.WithTenant(ctx, tenantID), nil
+}
+
+// contextStream is a server stream whose context is replaced, to carry the principal, actor or tenant
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
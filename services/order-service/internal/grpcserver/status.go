@@ -0,0 +1,50 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusError maps an error from the service layer to a gRPC status by its category,
+// mirroring the HTTP problem responses. Invalid fields are attached as BadRequest details.
+// Unexpected errors are logged and reported as fallback without detail.
+func statusError(err error, fallback string) error {
+	var validationErr *model.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		st := status.New(codes.InvalidArgument, validationErr.Error())
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(validationErr.Fields))
+		for i, f := range validationErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		if detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailErr == nil {
+			st = detailed
+		}
+		return st.Err()
+	case errors.Is(err, model.ErrValidation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, repository.ErrVersionConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, model.ErrConflict):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, model.ErrUnavailable):
+		log.Printf("Dependency unavailable: %v", err)
+		return status.Error(codes.Unavailable, fallback+": a dependency is unavailable, retry later")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		log.Printf("Internal error: %v", err)
+		return status.Error(codes.Internal, fallback)
+	}
+}
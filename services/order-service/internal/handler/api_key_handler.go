@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHandler handles HTTP requests for administering API keys
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// CreateAPIKey handles POST /admin/api-keys.
+// The response is the only time the key itself is returned. Admins bound to a tenant
+// may only issue keys bound to the same tenant.
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateAPIKeyRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.TenantID != "" {
+		if req.TenantID != "" && req.TenantID != principal.TenantID {
+			respondWithError(w, http.StatusForbidden, "Access to this tenant is not allowed")
+			return
+		}
+		req.TenantID = principal.TenantID
+	}
+
+	key, err := h.service.IssueKey(r.Context(), &req)
+	if err != nil {
+		respondWithAPIKeyError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusCreated, key)
+}
+
+// ListAPIKeys handles GET /admin/api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListKeys(r.Context())
+	if err != nil {
+		respondWithAPIKeyError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey handles DELETE /admin/api-keys/{id}
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.service.RevokeKey(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithAPIKeyError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, key)
+}
+
+// respondWithAPIKeyError maps API key service errors to HTTP responses
+func respondWithAPIKeyError(w http.ResponseWriter, err error) {
+	log.Printf("Error handling api key request: %v", err)
+	respondWithServiceError(w, err, "Failed to process api key request")
+}
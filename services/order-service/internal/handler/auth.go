@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHeader is the request header carrying a partner's API key
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate identifies the caller by an API key or a JWT bearer token and stores
+// their principal in the request context. Requests without credentials and invalid
+// credentials are rejected with 401. Without a verifier bearer tokens are not accepted.
+// allowAnonymous lets requests without credentials through, for local development only.
+func Authenticate(verifier *auth.Verifier, keys auth.APIKeyVerifier, allowAnonymous bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+				principal, err := keys.VerifyAPIKey(r.Context(), apiKey)
+				if errors.Is(err, service.ErrInvalidAPIKey) {
+					respondWithError(w, http.StatusUnauthorized, "The API key is invalid or revoked")
+					return
+				}
+				if err != nil {
+					respondWithServiceError(w, err, "Failed to check API key")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				if allowAnonymous {
+					next.ServeHTTP(w, r)
+					return
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="order-service"`)
+				respondWithError(w, http.StatusUnauthorized, "A bearer token or API key is required")
+				return
+			}
+			if verifier == nil {
+				respondWithError(w, http.StatusUnauthorized, "Bearer tokens are not accepted, use an API key")
+				return
+			}
+
+			principal, err := verifier.Verify(token)
+			if err != nil {
+				log.Printf("Rejected token for %s %s: %v", r.Method, r.URL.Path, err)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="order-service", error="invalid_token"`)
+				respondWithError(w, http.StatusUnauthorized, "The bearer token is invalid or expired")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// RequireRole rejects unauthenticated callers with 401 and callers without one of roles
+// with 403, also when anonymous access is allowed
+func RequireRole(roles ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="order-service"`)
+				respondWithError(w, http.StatusUnauthorized, "This operation requires credentials with the "+strings.Join(roles, " or ")+" role")
+				return
+			}
+			if !principal.HasRole(roles...) {
+				respondWithError(w, http.StatusForbidden, "This operation requires the "+strings.Join(roles, " or ")+" role")
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// authorizeCustomer responds 403 and returns false if the caller may not act on orders of customerID
+func authorizeCustomer(w http.ResponseWriter, r *http.Request, customerID string) bool {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && !principal.CanAccessCustomer(customerID) {
+		respondWithError(w, http.StatusForbidden, "Access to orders of this customer is not allowed")
+		return false
+	}
+	return true
+}
+
+// scopeCustomer restricts a customer caller to their own orders: an empty customerID
+// is replaced by the caller's and any other is rejected with 403
+func scopeCustomer(w http.ResponseWriter, r *http.Request, customerID *string) bool {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || principal.HasFullAccess() {
+		return true
+	}
+	if *customerID == "" {
+		*customerID = principal.CustomerID
+	}
+	return authorizeCustomer(w, r, *customerID)
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
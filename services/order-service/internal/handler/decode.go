@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+const (
+	// maxBodyBytes is the largest request body accepted by JSON endpoints
+	maxBodyBytes = 1 << 20
+	// maxBatchBodyBytes is the largest request body accepted by batch endpoints
+	maxBatchBodyBytes = 16 << 20
+)
+
+// decodeJSON decodes the JSON request body into dst, rejecting unknown fields, trailing
+// data and bodies larger than maxBodyBytes. On failure it writes the problem response
+// and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return decodeJSONLimit(w, r, dst, maxBodyBytes)
+}
+
+// decodeJSONLimit is decodeJSON with a body size limit of limit bytes
+func decodeJSONLimit(w http.ResponseWriter, r *http.Request, dst interface{}, limit int64) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit))
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err == nil && dec.More() {
+		err = errors.New("request body must contain a single JSON object")
+	}
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		respondWithError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Request body must not be larger than %d bytes", limit))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		respondWithServiceError(w, model.InvalidField(field, "is not a known field"), "")
+	case errors.As(err, &typeErr):
+		respondWithServiceError(w, model.InvalidField(typeErr.Field, "must be of type "+typeErr.Type.String()), "")
+	case errors.Is(err, model.ErrValidation):
+		respondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, io.EOF):
+		respondWithError(w, http.StatusBadRequest, "Request body is empty")
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	}
+	return false
+}
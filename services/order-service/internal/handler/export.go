@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// Export media types
+const (
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// exportFlushEvery is the number of exported orders written between flushes to the client
+const exportFlushEvery = 100
+
+// csvHeader lists the columns of a CSV export
+var csvHeader = []string{
+	"id", "customer_id", "product_id", "quantity", "total_amount", "currency",
+	"status", "version", "created_at", "updated_at", "cursor",
+}
+
+// exportedOrder is one line of an NDJSON export: the order and the cursor to resume after it
+type exportedOrder struct {
+	*model.Order
+	Cursor string `json:"cursor"`
+}
+
+// ExportOrders handles GET /orders/export.
+// It streams every order matching the list filters, oldest first, as CSV or NDJSON depending on
+// the Accept header. Every row carries the cursor of its order; passing the last one received as
+// since resumes an interrupted export. An error after streaming has started aborts the response,
+// so a truncated export is never mistaken for a complete one.
+func (h *OrderHandler) ExportOrders(w http.ResponseWriter, r *http.Request) {
+	contentType := negotiateExportType(r.Header.Get("Accept"))
+	if contentType == "" {
+		respondWithError(w, http.StatusNotAcceptable, "Accept must be "+csvContentType+" or "+ndjsonContentType)
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := parseOrderFilter(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !scopeCustomer(w, r, &filter.CustomerID) {
+		return
+	}
+
+	// Exports outlive the server's write timeout, so lift it for this response
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Warning: could not lift write deadline for export: %v", err)
+	}
+
+	var csvWriter *csv.Writer
+	encoder := json.NewEncoder(w)
+	started := false
+	count := 0
+
+	start := func() {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		if contentType == csvContentType {
+			csvWriter = csv.NewWriter(w)
+			_ = csvWriter.Write(csvHeader)
+		}
+	}
+	flush := func() error {
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return err
+			}
+		}
+		return rc.Flush()
+	}
+
+	err = h.service.ExportOrders(r.Context(), filter, query.Get("since"), func(order *model.Order) error {
+		if !started {
+			start()
+		}
+
+		cursor := model.CursorAfter(order).Encode()
+		if csvWriter != nil {
+			if err := csvWriter.Write(csvRecord(order, cursor)); err != nil {
+				return err
+			}
+		} else if err := encoder.Encode(exportedOrder{Order: order, Cursor: cursor}); err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushEvery == 0 {
+			return flush()
+		}
+		return nil
+	})
+
+	if err != nil {
+		if !started {
+			log.Printf("Error exporting orders: %v", err)
+			respondWithServiceError(w, err, "Failed to export orders")
+			return
+		}
+		log.Printf("Error exporting orders after %d rows, aborting response: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if !started {
+		start()
+	}
+	if err := flush(); err != nil {
+		log.Printf("Error flushing order export: %v", err)
+	}
+}
+
+// negotiateExportType picks the export media type from an Accept header, preferring the first
+// supported type listed. A missing Accept header or a wildcard selects NDJSON.
+func negotiateExportType(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return ndjsonContentType
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case csvContentType:
+			return csvContentType
+		case ndjsonContentType, "application/ndjson", "application/*", "*/*":
+			return ndjsonContentType
+		}
+	}
+	return ""
+}
+
+// csvRecord formats an order as a CSV export row
+func csvRecord(order *model.Order, cursor string) []string {
+	return []string{
+		order.ID,
+		order.CustomerID,
+		order.ProductID,
+		strconv.Itoa(order.Quantity),
+		order.TotalAmount.Decimal(),
+		order.TotalAmount.Currency,
+		order.Status,
+		strconv.FormatInt(order.Version, 10),
+		order.CreatedAt.UTC().Format(time.RFC3339),
+		order.UpdatedAt.UTC().Format(time.RFC3339),
+		cursor,
+	}
+}
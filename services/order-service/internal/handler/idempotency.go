@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/cache"
+	"github.com/andev0x/order-service/internal/model"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from a stored result
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with an idempotency record and replayed with it
+var replayedHeaders = []string{"ETag", "Location"}
+
+// Idempotency makes handlers safe to retry by honouring the Idempotency-Key header
+type Idempotency struct {
+	store         cache.IdempotencyStore
+	waitTimeout   time.Duration
+	pollInterval  time.Duration
+	renewInterval time.Duration
+}
+
+// NewIdempotency creates idempotency middleware backed by the given store
+func NewIdempotency(store cache.IdempotencyStore) *Idempotency {
+	return &Idempotency{
+		store:         store,
+		waitTimeout:   10 * time.Second,
+		pollInterval:  100 * time.Millisecond,
+		renewInterval: cache.IdempotencyLockTTL / 3,
+	}
+}
+
+// SetRenewInterval sets how often the reservation of a request still running is extended
+func (m *Idempotency) SetRenewInterval(interval time.Duration) {
+	m.renewInterval = interval
+}
+
+// Wrap returns a handler that replays the stored response for a repeated key,
+// rejects a repeated key with a different body (422), and waits for an
+// in-flight request with the same key before deciding, so only one of a set
+// of concurrent duplicates runs next.
+func (m *Idempotency) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			respondWithError(w, http.StatusBadRequest, "Idempotency-Key is too long")
+			return
+		}
+		// Scope keys to the tenant and caller so one client cannot replay another's response
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			key = principal.Role + ":" + principal.Subject + "/" + key
+		}
+		tenantID, _ := model.TenantFromContext(r.Context())
+		key = tenantID + "/" + key
+
+		// Buffer up to the largest body any endpoint accepts; the wrapped handler enforces its own limit
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(w, http.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Request body must not be larger than %d bytes", maxBatchBodyBytes))
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		requestHash := hashRequest(r, body)
+
+		record, err := m.reserve(r.Context(), key, requestHash)
+		if err != nil {
+			log.Printf("Error reserving idempotency key %q: %v", key, err)
+			respondWithError(w, http.StatusServiceUnavailable, "Idempotency store unavailable")
+			return
+		}
+
+		if record != nil {
+			switch {
+			case record.RequestHash != requestHash:
+				respondWithError(w, http.StatusUnprocessableEntity,
+					"Idempotency-Key was already used with a different request")
+			case !record.Completed:
+				w.Header().Set("Retry-After", "1")
+				respondWithError(w, http.StatusConflict,
+					"A request with this Idempotency-Key is still being processed")
+			default:
+				replay(w, record)
+			}
+			return
+		}
+
+		// A large batch may run for longer than a reservation lasts, so it is extended until
+		// the request finishes
+		stopRenewing := m.keepReserved(key, requestHash)
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next(recorder, r)
+		stopRenewing()
+
+		// Server errors are not stored so the client can retry with the same key
+		if recorder.statusCode >= http.StatusInternalServerError {
+			if err := m.store.Release(context.Background(), key); err != nil {
+				log.Printf("Error releasing idempotency key %q: %v", key, err)
+			}
+			return
+		}
+
+		if err := m.store.Complete(context.Background(), key, &model.IdempotencyRecord{
+			RequestHash: requestHash,
+			StatusCode:  recorder.statusCode,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Headers:     storedHeaders(recorder.Header()),
+			Body:        recorder.body.Bytes(),
+		}); err != nil {
+			log.Printf("Error storing idempotency record for key %q: %v", key, err)
+		}
+	}
+}
+
+// reserve claims the key, waiting while another request with the same key and body is in flight
+func (m *Idempotency) reserve(ctx context.Context, key, requestHash string) (*model.IdempotencyRecord, error) {
+	deadline := time.Now().Add(m.waitTimeout)
+	for {
+		record, err := m.store.Reserve(ctx, key, requestHash)
+		if err != nil || record == nil || record.Completed || record.RequestHash != requestHash {
+			return record, err
+		}
+		if time.Now().After(deadline) {
+			return record, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(m.pollInterval):
+		}
+	}
+}
+
+// keepReserved extends the reservation of key every renewInterval until the returned function
+// is called. The function returns once no extension is in flight, so one cannot outlive the
+// completed record.
+func (m *Idempotency) keepReserved(key, requestHash string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(m.renewInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := m.store.Extend(context.Background(), key, requestHash); err != nil {
+					log.Printf("Error extending idempotency key %q: %v", key, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// hashRequest fingerprints the parts of a request that must match on replay
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storedHeaders returns the replayed headers present in a response
+func storedHeaders(header http.Header) map[string]string {
+	var stored map[string]string
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			if stored == nil {
+				stored = make(map[string]string, len(replayedHeaders))
+			}
+			stored[name] = value
+		}
+	}
+	return stored
+}
+
+// replay writes a stored response
+func replay(w http.ResponseWriter, record *model.IdempotencyRecord) {
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	for name, value := range record.Headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	if _, err := w.Write(record.Body); err != nil {
+		log.Printf("Error writing replayed response: %v", err)
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// WriteHeader records the status code
+func (r *responseRecorder) WriteHeader(code int) {
+	r.statusCode = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the body
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -2,12 +2,20 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/andev0x/order-service/internal/auth"
 	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
 	"github.com/andev0x/order-service/internal/service"
 	"github.com/gorilla/mux"
 )
@@ -23,6 +31,8 @@ type HealthChecker struct {
 	DBHealthFunc    func() error
 	CacheHealthFunc func() error
 	MQHealthFunc    func() error
+	// ConsumerHealthFunc reports whether the event consumers are connected
+	ConsumerHealthFunc func() error
 }
 
 // NewOrderHandler creates a new order handler
@@ -41,21 +51,101 @@ func (h *OrderHandler) SetHealthChecker(hc *HealthChecker) {
 // CreateOrder handles POST /orders
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var req model.CreateOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !scopeCustomer(w, r, &req.CustomerID) {
 		return
 	}
 
 	order, err := h.service.CreateOrder(r.Context(), &req)
 	if err != nil {
 		log.Printf("Error creating order: %v", err)
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, err, "Failed to create order")
 		return
 	}
 
+	setETag(w, order)
+	w.Header().Set("Location", "/orders/"+order.ID)
 	respondWithJSON(w, http.StatusCreated, order)
 }
 
+// batchOrderResult is the outcome of one order in a POST /orders:batch response
+type batchOrderResult struct {
+	Index  int          `json:"index"`
+	Status int          `json:"status"`
+	Order  *model.Order `json:"order,omitempty"`
+	Error  *Problem     `json:"error,omitempty"`
+}
+
+// batchOrderResponse is the body of a POST /orders:batch response
+type batchOrderResponse struct {
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"`
+	Results []batchOrderResult `json:"results"`
+}
+
+// CreateOrders handles POST /orders:batch.
+// It responds 201 if every order was created and 207 with per-order results otherwise.
+// With ?atomic=true nothing is created unless every order is valid, and a failed batch
+// is answered with the status of its first failure.
+func (h *OrderHandler) CreateOrders(w http.ResponseWriter, r *http.Request) {
+	atomic := false
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			respondWithError(w, http.StatusBadRequest, "atomic must be true or false")
+			return
+		}
+	}
+
+	var req model.BatchCreateOrderRequest
+	if !decodeJSONLimit(w, r, &req, maxBatchBodyBytes) {
+		return
+	}
+	for i := range req.Orders {
+		if !scopeCustomer(w, r, &req.Orders[i].CustomerID) {
+			return
+		}
+	}
+
+	results, err := h.service.CreateOrders(r.Context(), req.Orders, atomic)
+	if err != nil {
+		log.Printf("Error creating orders: %v", err)
+		respondWithServiceError(w, err, "Failed to create orders")
+		return
+	}
+
+	response := batchOrderResponse{Results: make([]batchOrderResult, len(results))}
+	status := http.StatusCreated
+	for i, result := range results {
+		item := batchOrderResult{Index: i}
+		switch {
+		case result.Err == nil:
+			item.Status = http.StatusCreated
+			item.Order = result.Order
+			response.Created++
+		case errors.Is(result.Err, service.ErrBatchAborted):
+			item.Status = http.StatusFailedDependency
+			item.Error = newProblem(item.Status, result.Err.Error())
+			response.Failed++
+		default:
+			item.Error = serviceProblem(result.Err, "Failed to create order")
+			item.Status = item.Error.Status
+			response.Failed++
+			if status == http.StatusCreated {
+				status = http.StatusMultiStatus
+				if atomic {
+					status = item.Status
+				}
+			}
+		}
+		response.Results[i] = item
+	}
+
+	respondWithJSON(w, status, response)
+}
+
 // GetOrder handles GET /orders/{id}
 func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -69,41 +159,244 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	order, err := h.service.GetOrderByID(r.Context(), id)
 	if err != nil {
 		log.Printf("Error getting order: %v", err)
-		respondWithError(w, http.StatusNotFound, "Order not found")
+		respondWithServiceError(w, err, "Failed to get order")
+		return
+	}
+	if !authorizeCustomer(w, r, order.CustomerID) {
 		return
 	}
 
+	setETag(w, order)
 	respondWithJSON(w, http.StatusOK, order)
 }
 
+// GetOrderHistory handles GET /orders/{id}/history
+func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Order ID is required")
+		return
+	}
+
+	// Customers may only see the history of their own orders
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && !principal.HasFullAccess() {
+		order, err := h.service.GetOrderByID(r.Context(), id)
+		if err != nil {
+			log.Printf("Error getting order: %v", err)
+			respondWithServiceError(w, err, "Failed to get order history")
+			return
+		}
+		if !authorizeCustomer(w, r, order.CustomerID) {
+			return
+		}
+	}
+
+	history, err := h.service.GetOrderHistory(r.Context(), id)
+	if err != nil {
+		log.Printf("Error getting order history: %v", err)
+		respondWithServiceError(w, err, "Failed to get order history")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, history)
+}
+
 // ListOrders handles GET /orders
 func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
+	query := r.URL.Query()
 
-	limit := 10
-	offset := 0
+	if query.Get("offset") != "" {
+		respondWithError(w, http.StatusBadRequest, "offset is not supported, use the cursor from next_cursor")
+		return
+	}
 
-	if limitStr != "" {
+	limit := 10
+	if limitStr := query.Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil {
 			limit = l
 		}
 	}
 
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
-		}
+	filter, err := parseOrderFilter(query)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !scopeCustomer(w, r, &filter.CustomerID) {
+		return
 	}
 
-	orders, err := h.service.ListOrders(r.Context(), limit, offset)
+	page, err := h.service.ListOrders(r.Context(), filter, query.Get("cursor"), limit)
 	if err != nil {
 		log.Printf("Error listing orders: %v", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to list orders")
+		respondWithServiceError(w, err, "Failed to list orders")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, orders)
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// parseOrderFilter reads list filters from the query string.
+// Timestamps are RFC 3339; amounts are decimals in currency, which defaults to USD.
+func parseOrderFilter(query url.Values) (model.OrderFilter, error) {
+	filter := model.OrderFilter{
+		CustomerID: query.Get("customer_id"),
+		ProductID:  query.Get("product_id"),
+		Status:     query.Get("status"),
+		Currency:   query.Get("currency"),
+	}
+
+	for _, p := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+	} {
+		if v := query.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", p.name)
+			}
+			*p.dest = &t
+		}
+	}
+
+	minAmount, maxAmount := query.Get("min_amount"), query.Get("max_amount")
+	if (minAmount != "" || maxAmount != "") && filter.Currency == "" {
+		filter.Currency = model.DefaultCurrency
+	}
+	for _, p := range []struct {
+		name  string
+		value string
+		dest  **model.Money
+	}{
+		{"min_amount", minAmount, &filter.MinAmount},
+		{"max_amount", maxAmount, &filter.MaxAmount},
+	} {
+		if p.value != "" {
+			m, err := model.ParseMoney(p.value, filter.Currency)
+			if err != nil {
+				return filter, fmt.Errorf("%s: %w", p.name, err)
+			}
+			*p.dest = &m
+		}
+	}
+
+	return filter, nil
+}
+
+// ConfirmOrder handles POST /orders/{id}/confirm
+func (h *OrderHandler) ConfirmOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, h.service.ConfirmOrder)
+}
+
+// CancelOrder handles POST /orders/{id}/cancel
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, h.service.CancelOrder)
+}
+
+// ShipOrder handles POST /orders/{id}/ship
+func (h *OrderHandler) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, h.service.ShipOrder)
+}
+
+// DeliverOrder handles POST /orders/{id}/deliver
+func (h *OrderHandler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, h.service.DeliverOrder)
+}
+
+// RefundOrder handles POST /orders/{id}/refund
+func (h *OrderHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, h.service.RefundOrder)
+}
+
+// transitionOrder applies a status transition to the order in the request path.
+// The request must carry the order's current ETag, a list of ETags or "*" in If-Match.
+func (h *OrderHandler) transitionOrder(w http.ResponseWriter, r *http.Request,
+	transition func(ctx context.Context, id string, match model.VersionMatch) (*model.Order, error)) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		respondWithError(w, http.StatusBadRequest, "Order ID is required")
+		return
+	}
+
+	ifMatch := strings.Join(r.Header.Values("If-Match"), ",")
+	if strings.TrimSpace(ifMatch) == "" {
+		respondWithError(w, http.StatusPreconditionRequired, "If-Match header with the order ETag is required")
+		return
+	}
+	match, ok := parseIfMatch(ifMatch)
+	if !ok {
+		respondWithError(w, http.StatusPreconditionFailed, "If-Match does not match the current order version")
+		return
+	}
+
+	order, err := transition(r.Context(), id, match)
+	if err != nil {
+		log.Printf("Error transitioning order %s: %v", id, err)
+		if errors.Is(err, repository.ErrVersionConflict) {
+			respondWithError(w, http.StatusPreconditionFailed, "If-Match does not match the current order version")
+			return
+		}
+		respondWithServiceError(w, err, "Failed to update order")
+		return
+	}
+
+	setETag(w, order)
+	respondWithJSON(w, http.StatusOK, order)
+}
+
+// APIActor attributes order changes made while serving a request to the API,
+// named by the request method and path
+func APIActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := model.Actor{Type: model.ActorTypeAPI, Name: r.Method + " " + r.URL.Path}
+		next.ServeHTTP(w, r.WithContext(model.WithActor(r.Context(), actor)))
+	})
+}
+
+// setETag sets the ETag header to the order's version
+func setETag(w http.ResponseWriter, order *model.Order) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(order.Version, 10)))
+}
+
+// parseIfMatch parses an If-Match header, either "*" or a comma-separated list of ETags as
+// defined in RFC 9110 section 13.1.1, into the order versions it accepts. Weak ETags and
+// ETags that are not order versions never match, since If-Match uses strong comparison.
+// It reports false for malformed headers and lists in which no ETag can match.
+func parseIfMatch(header string) (model.VersionMatch, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return model.VersionMatch{Any: true}, true
+	}
+
+	var match model.VersionMatch
+	for rest := header; rest != ""; {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			break
+		}
+		weak := strings.HasPrefix(rest, "W/")
+		rest = strings.TrimPrefix(rest, "W/")
+		if !strings.HasPrefix(rest, `"`) {
+			return model.VersionMatch{}, false
+		}
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return model.VersionMatch{}, false
+		}
+		tag := rest[1 : end+1]
+		rest = rest[end+2:]
+		if trimmed := strings.TrimLeft(rest, " \t"); trimmed != "" && trimmed[0] != ',' {
+			return model.VersionMatch{}, false
+		}
+
+		if version, err := strconv.ParseInt(tag, 10, 64); err == nil && !weak {
+			match.Versions = append(match.Versions, version)
+		}
+	}
+	return match, len(match.Versions) > 0
 }
 
 // HealthCheck handles GET /health
@@ -116,9 +409,10 @@ func (h *OrderHandler) HealthCheck(w http.ResponseWriter, _ *http.Request) {
 	// Check dependencies if health checker is configured
 	if h.healthCheck != nil {
 		checks := map[string]string{
-			"database": "healthy",
-			"cache":    "healthy",
-			"mq":       "healthy",
+			"database":  "healthy",
+			"cache":     "healthy",
+			"mq":        "healthy",
+			"consumers": "healthy",
 		}
 
 		overallHealthy := true
@@ -147,6 +441,14 @@ func (h *OrderHandler) HealthCheck(w http.ResponseWriter, _ *http.Request) {
 			}
 		}
 
+		// Check event consumers
+		if h.healthCheck.ConsumerHealthFunc != nil {
+			if err := h.healthCheck.ConsumerHealthFunc(); err != nil {
+				checks["consumers"] = "unhealthy: " + err.Error()
+				overallHealthy = false
+			}
+		}
+
 		response["checks"] = checks
 		if !overallHealthy {
 			response["status"] = "degraded"
@@ -158,15 +460,17 @@ func (h *OrderHandler) HealthCheck(w http.ResponseWriter, _ *http.Request) {
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-// respondWithError sends an error response
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
-}
-
 // respondWithJSON sends a JSON response
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	writeJSON(w, code, payload)
+}
+
+// writeJSON marshals payload and writes it with the status code and the Content-Type already set
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
+		w.Header().Del("Content-Type")
 		w.WriteHeader(http.StatusInternalServerError)
 		if _, writeErr := w.Write([]byte("Internal server error")); writeErr != nil {
 			log.Printf("Error writing error response: %v", writeErr)
@@ -174,7 +478,6 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	if _, err := w.Write(response); err != nil {
 		log.Printf("Error writing response: %v", err)
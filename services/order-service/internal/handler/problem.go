@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// problemContentType is the media type of RFC 7807 error responses
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body.
+// Errors lists the invalid fields of a validation problem.
+type Problem struct {
+	Type   string             `json:"type"`
+	Title  string             `json:"title"`
+	Status int                `json:"status"`
+	Detail string             `json:"detail,omitempty"`
+	Errors []model.FieldError `json:"errors,omitempty"`
+}
+
+// newProblem creates a problem for a status code, titled with its standard reason phrase
+func newProblem(code int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+	}
+}
+
+// respondWithError sends a problem response with the given status and detail
+func respondWithError(w http.ResponseWriter, code int, message string) {
+	respondWithProblem(w, newProblem(code, message))
+}
+
+// respondWithServiceError maps an error from the service layer to a problem response
+// by its category. Unexpected errors are logged and reported as fallback without detail.
+func respondWithServiceError(w http.ResponseWriter, err error, fallback string) {
+	problem := serviceProblem(err, fallback)
+	if problem.Status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", "5")
+	}
+	respondWithProblem(w, problem)
+}
+
+// serviceProblem maps an error from the service layer to a problem by its category
+func serviceProblem(err error, fallback string) *Problem {
+	var validationErr *model.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		problem := newProblem(http.StatusBadRequest, validationErr.Error())
+		problem.Errors = validationErr.Fields
+		return problem
+	case errors.Is(err, model.ErrValidation):
+		return newProblem(http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		return newProblem(http.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrConflict):
+		return newProblem(http.StatusConflict, err.Error())
+	case errors.Is(err, model.ErrUnavailable):
+		log.Printf("Dependency unavailable: %v", err)
+		return newProblem(http.StatusServiceUnavailable, fallback+": a dependency is unavailable, retry later")
+	default:
+		log.Printf("Internal error: %v", err)
+		return newProblem(http.StatusInternalServerError, fallback)
+	}
+}
+
+// respondWithProblem sends an RFC 7807 problem details response
+func respondWithProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	writeJSON(w, problem.Status, problem)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// ProductHandler handles HTTP requests for the product price table
+type ProductHandler struct {
+	service *service.ProductService
+}
+
+// NewProductHandler creates a new product handler
+func NewProductHandler(service *service.ProductService) *ProductHandler {
+	return &ProductHandler{service: service}
+}
+
+// CreateProduct handles POST /admin/products
+func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req model.ProductRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	product, err := h.service.CreateProduct(r.Context(), &req)
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, product)
+}
+
+// GetProduct handles GET /admin/products/{id}
+func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	product, err := h.service.GetProduct(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, product)
+}
+
+// ListProducts handles GET /admin/products
+func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.ListProducts(r.Context())
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, products)
+}
+
+// UpdateProduct handles PUT /admin/products/{id}
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	var req model.ProductRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	product, err := h.service.UpdateProduct(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, product)
+}
+
+// DeleteProduct handles DELETE /admin/products/{id}
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteProduct(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondWithProductError maps product service errors to HTTP responses
+func respondWithProductError(w http.ResponseWriter, err error) {
+	log.Printf("Error handling product request: %v", err)
+	respondWithServiceError(w, err, "Failed to process product request")
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/ratelimit"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var rateLimitedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_rate_limited_requests_total",
+	Help: "Number of requests rejected by the rate limiter, by route",
+}, []string{"route"})
+
+// RateLimit rejects requests over the client's rate with 429 and reports the state of the
+// most constrained bucket in RateLimit-* headers. Clients are identified by API key,
+// token subject, or remote address for anonymous requests. If the limiter is unavailable
+// requests are let through.
+func RateLimit(limiter *ratelimit.Limiter) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeName(r)
+			if result := limiter.Take(r.Context(), route, r.RemoteAddr); result != nil {
+				setRateLimitHeaders(w, result)
+				if !result.Allowed {
+					rateLimitedTotal.WithLabelValues(route).Inc()
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+					respondWithError(w, http.StatusTooManyRequests, "Rate limit exceeded, retry later")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeName returns the method and path template of the matched route, e.g. "GET /orders/{id}"
+func routeName(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return r.Method + " " + tmpl
+		}
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// setRateLimitHeaders describes a bucket with the RateLimit header fields of
+// draft-ietf-httpapi-ratelimit-headers
+func setRateLimitHeaders(w http.ResponseWriter, result *model.RateLimitResult) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	h.Set("RateLimit-Policy", strconv.Itoa(result.Limit.Requests)+";w="+strconv.Itoa(ceilSeconds(result.Limit.Period)))
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/andev0x/order-service/internal/stream"
+	"github.com/gorilla/mux"
+)
+
+// DefaultHeartbeatInterval is how often an idle stream sends a comment to keep proxies from closing it
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// Server-Sent Events constants
+const (
+	eventStreamContentType = "text/event-stream"
+	statusEventName        = "status"
+	// reconnectDelay is the retry hint, in milliseconds, sent to clients when a stream opens
+	reconnectDelay = 3000
+)
+
+// StreamHandler handles Server-Sent Events streams of order status changes
+type StreamHandler struct {
+	service   *service.OrderService
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+// NewStreamHandler creates a new stream handler that sends a heartbeat on idle streams at the given
+// interval, or DefaultHeartbeatInterval if it is not positive
+func NewStreamHandler(service *service.OrderService, hub *stream.Hub, heartbeat time.Duration) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeatInterval
+	}
+	return &StreamHandler{
+		service:   service,
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// OrderEvents handles GET /orders/{id}/events.
+// The stream opens with the current status of the order, or with every change after the event
+// named by Last-Event-ID, and then pushes each status change as it happens.
+func (h *StreamHandler) OrderEvents(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	tenantID, _ := model.TenantFromContext(r.Context())
+
+	// Subscribe before reading the log so no change falls between the two
+	sub := h.hub.Subscribe(stream.Filter{TenantID: tenantID, OrderID: id})
+	defer sub.Close()
+
+	updates, err := h.service.GetOrderUpdates(r.Context(), id)
+	if err != nil {
+		log.Printf("Error getting order updates: %v", err)
+		respondWithServiceError(w, err, "Failed to stream order events")
+		return
+	}
+	if !authorizeCustomer(w, r, updates[0].CustomerID) {
+		return
+	}
+
+	h.serve(w, r, sub, updatesAfter(updates, r.Header.Get("Last-Event-ID")))
+}
+
+// CustomerOrderStream handles GET /customers/{id}/orders/stream.
+// The stream pushes status changes of every order of the customer, including new orders. With
+// Last-Event-ID it first replays the changes recorded after that event.
+func (h *StreamHandler) CustomerOrderStream(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["id"]
+	if !authorizeCustomer(w, r, customerID) {
+		return
+	}
+	tenantID, _ := model.TenantFromContext(r.Context())
+
+	sub := h.hub.Subscribe(stream.Filter{TenantID: tenantID, CustomerID: customerID})
+	defer sub.Close()
+
+	var backlog []*model.OrderStatusUpdate
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		var err error
+		backlog, err = h.service.GetCustomerUpdatesAfter(r.Context(), customerID, lastEventID)
+		if err != nil {
+			log.Printf("Error getting customer order updates: %v", err)
+			respondWithServiceError(w, err, "Failed to stream customer orders")
+			return
+		}
+	}
+
+	h.serve(w, r, sub, backlog)
+}
+
+// serve writes the backlog and then live updates as Server-Sent Events until the client goes
+// away or the subscription is closed. Live updates an order has already been sent at or past
+// the version of are skipped, as they overlap the backlog.
+func (h *StreamHandler) serve(w http.ResponseWriter, r *http.Request, sub *stream.Subscription, backlog []*model.OrderStatusUpdate) {
+	// Streams outlive the server's write timeout, so lift it for this response
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Warning: could not lift write deadline for stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay); err != nil {
+		return
+	}
+
+	sent := make(map[string]int64)
+	send := func(update *model.OrderStatusUpdate) error {
+		if update.Version <= sent[update.OrderID] {
+			return nil
+		}
+		sent[update.OrderID] = update.Version
+		return writeStatusEvent(w, update)
+	}
+
+	for _, update := range backlog {
+		if err := send(update); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update, ok := <-sub.Updates():
+			if !ok {
+				return
+			}
+			if err := send(update); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// updatesAfter returns the updates following the one with the given event ID. If the ID is empty
+// or unknown, only the latest update is returned, giving the client the current status.
+func updatesAfter(updates []*model.OrderStatusUpdate, lastEventID string) []*model.OrderStatusUpdate {
+	if lastEventID != "" {
+		for i, update := range updates {
+			if update.EventID == lastEventID {
+				return updates[i+1:]
+			}
+		}
+	}
+	return updates[len(updates)-1:]
+}
+
+// writeStatusEvent writes an update as a Server-Sent Event whose ID is its event ID
+func writeStatusEvent(w http.ResponseWriter, update *model.OrderStatusUpdate) error {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	// Event IDs are UUIDs, but never let one break the framing
+	id := strings.NewReplacer("\n", "", "\r", "").Replace(update.EventID)
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, statusEventName, data)
+	return err
+}
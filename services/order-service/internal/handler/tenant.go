@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// TenantHeader is the request header naming the tenant (storefront) a request is for
+const TenantHeader = "X-Tenant-ID"
+
+// ResolveTenant scopes each request to a tenant. Callers whose token or API key is bound to a
+// tenant act in that tenant and are rejected with 403 if they name another, and so are customers
+// without one, in model.DefaultTenantID. Admins and services without one name the tenant in the
+// TenantHeader and default to model.DefaultTenantID.
+func ResolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.Header.Get(TenantHeader)
+		if tenantID != "" && !model.ValidTenantID(tenantID) {
+			respondWithError(w, http.StatusBadRequest, TenantHeader+" must be up to 64 letters, digits, '-' and '_'")
+			return
+		}
+
+		if bound := boundTenant(r); bound != "" {
+			if tenantID != "" && tenantID != bound {
+				respondWithError(w, http.StatusForbidden, "Access to this tenant is not allowed")
+				return
+			}
+			tenantID = bound
+		}
+		if tenantID == "" {
+			tenantID = model.DefaultTenantID
+		}
+
+		next.ServeHTTP(w, r.WithContext(model.WithTenant(r.Context(), tenantID)))
+	})
+}
+
+// boundTenant returns the tenant the caller of r is held to, or "" if it may choose one
+func boundTenant(r *http.Request) string {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok:
+		return ""
+	case principal.TenantID != "":
+		return principal.TenantID
+	case !principal.HasFullAccess():
+		return model.DefaultTenantID
+	}
+	return ""
+}
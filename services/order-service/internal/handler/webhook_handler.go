@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// WebhookHandler handles HTTP requests for webhook subscriptions of the current tenant
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook handles POST /webhooks.
+// The response is the only time the signing secret is returned.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req model.WebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), &req)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusCreated, sub)
+}
+
+// ListWebhooks handles GET /webhooks
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, subs)
+}
+
+// GetWebhook handles GET /webhooks/{id}
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.service.GetSubscription(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+// UpdateWebhook handles PUT /webhooks/{id}
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req model.WebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(r.Context(), mux.Vars(r)["id"], &req)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sub)
+}
+
+// DeleteWebhook handles DELETE /webhooks/{id}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteSubscription(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries handles GET /webhooks/{id}/deliveries
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := service.DefaultDeliveryLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil {
+			limit = l
+		}
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), mux.Vars(r)["id"], query.Get("status"), limit)
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// Redeliver handles POST /webhooks/{id}/deliveries/{delivery_id}/redeliver
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	delivery, err := h.service.Redeliver(r.Context(), vars["id"], vars["delivery_id"])
+	if err != nil {
+		respondWithWebhookError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, delivery)
+}
+
+// respondWithWebhookError maps webhook service errors to HTTP responses
+func respondWithWebhookError(w http.ResponseWriter, err error) {
+	log.Printf("Error handling webhook request: %v", err)
+	respondWithServiceError(w, err, "Failed to process webhook request")
+}
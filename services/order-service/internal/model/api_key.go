@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+)
+
+// APIKey is a credential issued to a partner integration. Only the SHA-256 hash of the key
+// is stored; Prefix is the start of the key so clients can tell their keys apart.
+// RateLimit overrides the default per-client rate limit, in requests per minute.
+// Keys with a TenantID act only within that tenant.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Role       string     `json:"role"`
+	CustomerID string     `json:"customer_id,omitempty"`
+	TenantID   string     `json:"tenant_id,omitempty"`
+	RateLimit  int        `json:"rate_limit,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Revoked reports whether the key can no longer be used
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// CreateAPIKeyRequest represents the request to issue an API key.
+// Customer keys act only on the orders of CustomerID; keys without a TenantID may act
+// in any tenant.
+type CreateAPIKeyRequest struct {
+	Name       string `json:"name" validate:"required,max=255"`
+	Role       string `json:"role" validate:"required,oneof=customer service admin"`
+	CustomerID string `json:"customer_id,omitempty" validate:"required_if=Role customer,max=36"`
+	TenantID   string `json:"tenant_id,omitempty" validate:"omitempty,tenant"`
+	RateLimit  int    `json:"rate_limit,omitempty" validate:"gte=0"`
+}
+
+// IssuedAPIKey is a newly issued API key together with its plaintext value,
+// which is never shown again
+type IssuedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error categories shared by all layers. Specific errors wrap one of these so
+// handlers can map any error to a status code with errors.Is.
+var (
+	// ErrNotFound means the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrValidation means the request is malformed or breaks a business rule
+	ErrValidation = errors.New("validation failed")
+	// ErrConflict means the request conflicts with the current state of a resource
+	ErrConflict = errors.New("conflict")
+	// ErrUnavailable means a dependency such as the database could not be reached
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// categorizedError is a sentinel error that belongs to one of the categories above
+type categorizedError struct {
+	msg      string
+	category error
+}
+
+func (e *categorizedError) Error() string { return e.msg }
+
+func (e *categorizedError) Unwrap() error { return e.category }
+
+// NewError returns a sentinel error with the given message that matches category with errors.Is
+func NewError(category error, msg string) error {
+	return &categorizedError{msg: msg, category: category}
+}
+
+// FieldError describes why a single request field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports one or more invalid request fields; it matches ErrValidation
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// InvalidField returns a ValidationError for a single field
+func InvalidField(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Is reports whether target is ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
@@ -0,0 +1,12 @@
+package model
+
+// IdempotencyRecord stores the outcome of a request made with an Idempotency-Key.
+// Headers holds the response headers that are replayed with the body, such as ETag and Location.
+type IdempotencyRecord struct {
+	RequestHash string            `json:"request_hash"`
+	Completed   bool              `json:"completed"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is assumed for amounts written as plain decimal numbers
+const DefaultCurrency = "USD"
+
+var (
+	// ErrInvalidAmount is returned for malformed amounts or amounts finer than the currency allows
+	ErrInvalidAmount = NewError(ErrValidation, "invalid amount")
+	// ErrCurrencyMismatch is returned when combining amounts in different currencies
+	ErrCurrencyMismatch = NewError(ErrValidation, "currency mismatch")
+	// ErrAmountOverflow is returned when arithmetic on amounts would exceed the int64 range
+	ErrAmountOverflow = NewError(ErrValidation, "amount out of range")
+)
+
+// currencyExponents maps supported ISO-4217 codes to their number of minor unit digits
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "CAD": 2, "CHF": 2, "CNY": 2, "EUR": 2, "GBP": 2, "HKD": 2,
+	"INR": 2, "JPY": 0, "KRW": 0, "KWD": 3, "NZD": 2, "SEK": 2, "SGD": 2, "USD": 2, "VND": 0,
+}
+
+// Money is an exact monetary amount in the minor units of an ISO-4217 currency,
+// e.g. {Amount: 1999, Currency: "USD"} is $19.99
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// NewMoney creates a Money value from minor units
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "19.99" in the given currency.
+// It rejects amounts with more fractional digits than the currency has minor units.
+func ParseMoney(s, currency string) (Money, error) {
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: unsupported currency %q", ErrInvalidAmount, currency)
+	}
+
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || strings.Trim(whole, "0123456789") != "" || strings.Trim(frac, "0123456789") != "" {
+		return Money{}, fmt.Errorf("%w: %q is not a decimal amount", ErrInvalidAmount, s)
+	}
+	if len(strings.TrimRight(frac, "0")) > exp {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", ErrInvalidAmount, s, exp, currency)
+	}
+	frac = (frac + strings.Repeat("0", exp))[:exp]
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, s)
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// IsSupportedCurrency reports whether the currency code is known
+func IsSupportedCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// Add returns m + other; both must share a currency
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	sum, ok := addInt64(m.Amount, other.Amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrAmountOverflow, m, other)
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+// Sub returns m - other; both must share a currency
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	diff, ok := subInt64(m.Amount, other.Amount)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrAmountOverflow, m, other)
+	}
+	return Money{Amount: diff, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by a whole quantity
+func (m Money) Mul(quantity int64) (Money, error) {
+	product, ok := mulInt64(m.Amount, quantity)
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %s x %d", ErrAmountOverflow, m, quantity)
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Percent returns the given percentage of m, rounded half away from zero to the minor unit
+func (m Money) Percent(percent float64) (Money, error) {
+	basis := math.Round(percent * 100)
+	if math.IsNaN(basis) || math.Abs(basis) > 1e15 {
+		return Money{}, fmt.Errorf("%w: %v%% of %s", ErrAmountOverflow, percent, m)
+	}
+	product, ok := mulInt64(m.Amount, int64(basis))
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %v%% of %s", ErrAmountOverflow, percent, m)
+	}
+	// Divide before rounding so that adding half a unit cannot overflow
+	quotient, remainder := product/10000, product%10000
+	if remainder >= 5000 {
+		quotient++
+	} else if remainder <= -5000 {
+		quotient--
+	}
+	return Money{Amount: quotient, Currency: m.Currency}, nil
+}
+
+// addInt64 returns a + b and whether the sum fits in an int64
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (sum > a) == (b > 0)
+}
+
+// subInt64 returns a - b and whether the difference fits in an int64
+func subInt64(a, b int64) (int64, bool) {
+	diff := a - b
+	return diff, (diff < a) == (b > 0)
+}
+
+// mulInt64 returns a * b and whether the product fits in an int64
+func mulInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return product, true
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Decimal formats the amount in major units, e.g. "19.99"
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+
+	digits := fmt.Sprintf("%0*d", exp+1, amount)
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "19.99 USD"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// UnmarshalJSON accepts {"amount": 1999, "currency": "USD"} as well as a plain
+// decimal number such as 19.99, which older clients and events use for amounts
+// in DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' && data[0] != 'n' {
+		parsed, err := ParseMoney(string(data), DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	type plain Money
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Money(v)
+	return nil
+}
@@ -2,44 +2,173 @@
 package model
 
 import (
+	"strconv"
+	"strings"
 	"time"
 )
 
-// Order represents an order in the system
+// Order represents an order in the system.
+// ProductID is the product of the first line item and Quantity is the total
+// number of units across all items; Items holds the full breakdown.
+// Version is incremented on every update and is exposed as the order's ETag.
+// TenantID is the storefront the order was placed in.
 type Order struct {
-	ID          string    `json:"id"`
-	CustomerID  string    `json:"customer_id"`
-	ProductID   string    `json:"product_id"`
-	Quantity    int       `json:"quantity"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string      `json:"id"`
+	TenantID    string      `json:"tenant_id"`
+	CustomerID  string      `json:"customer_id"`
+	ProductID   string      `json:"product_id"`
+	Quantity    int         `json:"quantity"`
+	TotalAmount Money       `json:"total_amount"`
+	Status      string      `json:"status"`
+	Items       []OrderItem `json:"items"`
+	Version     int64       `json:"version"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
-// CreateOrderRequest represents the request to create an order
+// VersionMatch is a precondition on the version of an order, as sent in an If-Match header.
+// Any matches every version of an existing order; otherwise the order must be at one of Versions.
+type VersionMatch struct {
+	Any      bool
+	Versions []int64
+}
+
+// MatchVersion returns a precondition matching only the given version
+func MatchVersion(version int64) VersionMatch {
+	return VersionMatch{Versions: []int64{version}}
+}
+
+// Matches reports whether an order at version satisfies the precondition
+func (m VersionMatch) Matches(version int64) bool {
+	if m.Any {
+		return true
+	}
+	for _, v := range m.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the precondition as an If-Match header value
+func (m VersionMatch) String() string {
+	if m.Any {
+		return "*"
+	}
+	tags := make([]string, 0, len(m.Versions))
+	for _, v := range m.Versions {
+		tags = append(tags, strconv.Quote(strconv.FormatInt(v, 10)))
+	}
+	return strings.Join(tags, ", ")
+}
+
+// OrderItem represents a single line item of an order.
+// LineTotal is UnitPrice * Quantity less Discount.
+type OrderItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	Discount  Money  `json:"discount"`
+	LineTotal Money  `json:"line_total"`
+}
+
+// CreateOrderRequest represents the request to create an order.
+// Prices are computed on the server; TotalAmount is optional and, when
+// present, must match the computed total. ProductID and Quantity describe a
+// single-item order for clients that predate line items; they are ignored
+// when Items is set.
 type CreateOrderRequest struct {
-	CustomerID  string  `json:"customer_id" validate:"required"`
-	ProductID   string  `json:"product_id" validate:"required"`
-	Quantity    int     `json:"quantity" validate:"required,gt=0"`
-	TotalAmount float64 `json:"total_amount" validate:"required,gt=0"`
+	CustomerID  string                   `json:"customer_id" validate:"required,max=36"`
+	Items       []CreateOrderItemRequest `json:"items" validate:"required,min=1,dive"`
+	TotalAmount *Money                   `json:"total_amount,omitempty" validate:"omitempty,gt=0"`
+	ProductID   string                   `json:"product_id,omitempty"`
+	Quantity    int                      `json:"quantity,omitempty"`
+}
+
+// MaxItemQuantity bounds the quantity of a single line item
+const MaxItemQuantity = 10000
+
+// CreateOrderItemRequest represents a line item in a create order request.
+// UnitPrice is optional and, when present, must match the price list.
+// Quantity is bounded by MaxItemQuantity.
+type CreateOrderItemRequest struct {
+	ProductID string `json:"product_id" validate:"required,max=36"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0,max=10000"`
+	UnitPrice *Money `json:"unit_price,omitempty" validate:"omitempty,gt=0"`
 }
 
 // OrderCreatedEvent represents the event published when an order is created
 type OrderCreatedEvent struct {
-	OrderID     string    `json:"order_id"`
-	CustomerID  string    `json:"customer_id"`
-	ProductID   string    `json:"product_id"`
-	Quantity    int       `json:"quantity"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	EventType   string    `json:"event_type"`
+	EventID     string      `json:"event_id"`
+	OrderID     string      `json:"order_id"`
+	TenantID    string      `json:"tenant_id"`
+	CustomerID  string      `json:"customer_id"`
+	ProductID   string      `json:"product_id"`
+	Quantity    int         `json:"quantity"`
+	TotalAmount Money       `json:"total_amount"`
+	Status      string      `json:"status"`
+	Items       []OrderItem `json:"items"`
+	Version     int64       `json:"version"`
+	CreatedAt   time.Time   `json:"created_at"`
+	EventType   string      `json:"event_type"`
+}
+
+// OrderStatusChangedEvent represents the event published when an order moves between statuses
+type OrderStatusChangedEvent struct {
+	EventID        string    `json:"event_id"`
+	OrderID        string    `json:"order_id"`
+	TenantID       string    `json:"tenant_id"`
+	CustomerID     string    `json:"customer_id"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Version        int64     `json:"version"`
+	ChangedAt      time.Time `json:"changed_at"`
+	EventType      string    `json:"event_type"`
 }
 
 // OrderStatus constants
 const (
 	OrderStatusPending   = "pending"
 	OrderStatusConfirmed = "confirmed"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
 	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
 )
+
+// ErrInvalidTransition is returned when an order cannot move to the requested status
+var ErrInvalidTransition = NewError(ErrConflict, "invalid order status transition")
+
+// orderTransitions lists the statuses reachable from each status
+var orderTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinalStatus reports whether an order in status can no longer change
+func IsFinalStatus(status string) bool {
+	return len(orderTransitions[status]) == 0
+}
+
+// IsValidStatus reports whether status is a known order status
+func IsValidStatus(status string) bool {
+	switch status {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package model
+
+// BatchCreateOrderRequest represents the request to create several orders at once
+type BatchCreateOrderRequest struct {
+	Orders []CreateOrderRequest `json:"orders"`
+}
+
+// OrderWrite bundles a new order with the event log entries, outbox messages and
+// history entry that must be persisted in the same transaction
+type OrderWrite struct {
+	Order    *Order
+	Events   []*OrderEvent
+	Messages []*OutboxMessage
+	History  *OrderHistoryEntry
+}
+
+// OrderResult is the outcome of creating one order of a batch: either Order or Err is set
+type OrderResult struct {
+	Order *Order
+	Err   error
+}
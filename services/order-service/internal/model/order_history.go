@@ -0,0 +1,57 @@
+package model
+
+import (
+	"context"
+	"time"
+)
+
+// Actor types record what caused an order change
+const (
+	ActorTypeAPI       = "api"
+	ActorTypeConsumer  = "consumer"
+	ActorTypeScheduler = "scheduler"
+	ActorTypeSystem    = "system"
+)
+
+// Actor identifies who or what caused an order change.
+// Name is the request line for API calls, the queue for consumers and the job for scheduled jobs.
+type Actor struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// actorKey is the context key under which the current Actor is stored
+type actorKey struct{}
+
+// WithActor returns a copy of ctx that attributes order changes to actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored in ctx, or a system actor if there is none
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Type: ActorTypeSystem}
+}
+
+// OrderHistoryEntry is one row of an order's audit trail.
+// PreviousStatus is empty for the entry that created the order, and EventIDs lists the
+// events published for the change.
+type OrderHistoryEntry struct {
+	ID             int64     `json:"-"`
+	OrderID        string    `json:"-"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	Status         string    `json:"status"`
+	Version        int64     `json:"version"`
+	Actor          Actor     `json:"actor"`
+	EventIDs       []string  `json:"event_ids"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// OrderHistory is the chronological audit trail of an order
+type OrderHistory struct {
+	OrderID string               `json:"order_id"`
+	Entries []*OrderHistoryEntry `json:"history"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidQuery is returned for malformed list filters or cursors
+var ErrInvalidQuery = NewError(ErrValidation, "invalid query")
+
+// OrderFilter narrows the orders returned by a list query.
+// Empty fields do not filter; amount bounds apply to orders in Currency.
+type OrderFilter struct {
+	CustomerID  string     `json:"customer_id,omitempty"`
+	ProductID   string     `json:"product_id,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+	Currency    string     `json:"currency,omitempty"`
+	MinAmount   *Money     `json:"min_amount,omitempty"`
+	MaxAmount   *Money     `json:"max_amount,omitempty"`
+}
+
+// Validate checks that the filter values are consistent
+func (f OrderFilter) Validate() error {
+	if f.Status != "" && !IsValidStatus(f.Status) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, f.Status)
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedTo.Before(*f.CreatedFrom) {
+		return fmt.Errorf("%w: created_to is before created_from", ErrInvalidQuery)
+	}
+	for _, bound := range []*Money{f.MinAmount, f.MaxAmount} {
+		if bound != nil && bound.Currency != f.Currency {
+			return fmt.Errorf("%w: amount bounds must be in the filtered currency", ErrInvalidQuery)
+		}
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && f.MaxAmount.Amount < f.MinAmount.Amount {
+		return fmt.Errorf("%w: max_amount is less than min_amount", ErrInvalidQuery)
+	}
+	return nil
+}
+
+// OrderCursor is the keyset position after the last order of a page.
+// Orders are listed newest first, by created_at and then by ID.
+type OrderCursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        string    `json:"id"`
+}
+
+// CursorAfter returns the cursor positioned after the given order
+func CursorAfter(order *Order) *OrderCursor {
+	return &OrderCursor{CreatedAt: order.CreatedAt, ID: order.ID}
+}
+
+// Encode serializes the cursor into an opaque URL-safe token
+func (c *OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeOrderCursor parses a token produced by OrderCursor.Encode
+func DecodeOrderCursor(token string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	var cursor OrderCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return &cursor, nil
+}
+
+// OrderPage is one page of a list query together with the filters that produced it.
+// NextCursor is empty on the last page.
+type OrderPage struct {
+	Orders     []*Order    `json:"orders"`
+	Filters    OrderFilter `json:"filters"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// OrderStatusUpdate is a change of an order's status as pushed to stream clients.
+// EventID is the ID of the order event that made the change, so clients can resume after it.
+type OrderStatusUpdate struct {
+	EventID        string    `json:"event_id"`
+	OrderID        string    `json:"order_id"`
+	TenantID       string    `json:"tenant_id"`
+	CustomerID     string    `json:"customer_id"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	Status         string    `json:"status"`
+	Version        int64     `json:"version"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// NewOrderStatusUpdate decodes an order event payload of the given event type into a status update
+func NewOrderStatusUpdate(eventType string, payload []byte) (*OrderStatusUpdate, error) {
+	var update *OrderStatusUpdate
+	switch eventType {
+	case EventTypeOrderCreated, EventTypeOrderImported:
+		var created OrderCreatedEvent
+		if err := json.Unmarshal(payload, &created); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s event: %w", eventType, err)
+		}
+		update = &OrderStatusUpdate{
+			EventID:    created.EventID,
+			OrderID:    created.OrderID,
+			TenantID:   created.TenantID,
+			CustomerID: created.CustomerID,
+			Status:     created.Status,
+			Version:    created.Version,
+			OccurredAt: created.CreatedAt,
+		}
+	case EventTypeOrderStatusChanged, EventTypeOrderExpired:
+		var changed OrderStatusChangedEvent
+		if err := json.Unmarshal(payload, &changed); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s event: %w", eventType, err)
+		}
+		update = &OrderStatusUpdate{
+			EventID:        changed.EventID,
+			OrderID:        changed.OrderID,
+			TenantID:       changed.TenantID,
+			CustomerID:     changed.CustomerID,
+			PreviousStatus: changed.PreviousStatus,
+			Status:         changed.Status,
+			Version:        changed.Version,
+			OccurredAt:     changed.ChangedAt,
+		}
+	default:
+		return nil, fmt.Errorf("unknown order event type %q", eventType)
+	}
+
+	if update.TenantID == "" {
+		update.TenantID = DefaultTenantID
+	}
+	return update, nil
+}
+
+// StatusUpdateFromEvent converts an entry of the order event log into a status update.
+// The ID, version and time recorded in the log take precedence over the payload, which
+// for imported events has no event ID.
+func StatusUpdateFromEvent(event *OrderEvent) (*OrderStatusUpdate, error) {
+	update, err := NewOrderStatusUpdate(event.EventType, event.Payload)
+	if err != nil {
+		return nil, err
+	}
+	update.EventID = event.EventID
+	update.Version = event.Version
+	update.OccurredAt = event.OccurredAt
+	return update, nil
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event type and routing key constants for order events
+const (
+	EventTypeOrderCreated       = "OrderCreated"
+	EventTypeOrderStatusChanged = "OrderStatusChanged"
+	// EventTypeOrderExpired is a status change from pending to cancelled made because the order
+	// was not confirmed in time; its payload has the shape of OrderStatusChangedEvent
+	EventTypeOrderExpired = "OrderExpired"
+
+	RoutingKeyOrderCreated = "order.created"
+	RoutingKeyOrderExpired = "order.expired"
+	routingKeyOrderPrefix  = "order."
+)
+
+// OutboxStatus constants
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+)
+
+// OutboxMessage represents an event stored in the outbox table awaiting publication
+type OutboxMessage struct {
+	ID          int64     `json:"id"`
+	EventID     string    `json:"event_id"`
+	AggregateID string    `json:"aggregate_id"`
+	EventType   string    `json:"event_type"`
+	RoutingKey  string    `json:"routing_key"`
+	Payload     []byte    `json:"payload"`
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewOutboxMessage marshals an event into an outbox message
+func NewOutboxMessage(eventID, aggregateID, eventType, routingKey string, event interface{}) (*OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return &OutboxMessage{
+		EventID:     eventID,
+		AggregateID: aggregateID,
+		EventType:   eventType,
+		RoutingKey:  routingKey,
+		Payload:     payload,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// StatusRoutingKey returns the routing key for a status change, e.g. "order.confirmed"
+func StatusRoutingKey(status string) string {
+	return routingKeyOrderPrefix + status
+}
+
+// OutboxBacklog describes the unpublished messages waiting in the outbox
+type OutboxBacklog struct {
+	Pending         int
+	OldestCreatedAt time.Time
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+)
+
+// Product represents a product and its price in the price table
+type Product struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	UnitPrice       Money     `json:"unit_price"`
+	DiscountPercent float64   `json:"discount_percent"`
+	Active          bool      `json:"active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ProductRequest represents the request to create or update a product
+type ProductRequest struct {
+	ID              string  `json:"id" validate:"required"`
+	Name            string  `json:"name" validate:"required"`
+	UnitPrice       Money   `json:"unit_price" validate:"required"`
+	DiscountPercent float64 `json:"discount_percent" validate:"gte=0,lt=100"`
+	Active          *bool   `json:"active,omitempty"`
+}
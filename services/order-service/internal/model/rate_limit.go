@@ -0,0 +1,48 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Requests requests per Period. Requests is also the burst size:
+// an idle client may send that many requests at once.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseRateLimit parses a limit written as requests/period, such as "100/1m"
+func ParseRateLimit(s string) (RateLimit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q must be written as requests/period", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q must allow a positive number of requests", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q must have a positive period", s)
+	}
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+// String formats the limit as requests/period
+func (l RateLimit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Period.String()
+}
+
+// RateLimitResult is the outcome of taking a request from a token bucket.
+// Reset is how long until the bucket is full again; RetryAfter is how long until
+// the next request is allowed and is zero when this one was.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      RateLimit
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
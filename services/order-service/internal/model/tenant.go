@@ -0,0 +1,48 @@
+package model
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenantID is the tenant of requests that do not name one and of orders created
+// before the deployment served several storefronts
+const DefaultTenantID = "default"
+
+// tenantIDPattern restricts tenant IDs to characters that are safe in cache keys and headers
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// ValidTenantID reports whether id is a well-formed tenant ID
+func ValidTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
+
+// tenantKey is the context key under which the current tenant is stored
+type tenantKey struct{}
+
+// allTenants is the tenant stored in contexts of jobs that work across tenants
+const allTenants = "*"
+
+// WithTenant returns a copy of ctx scoped to the orders of tenantID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// WithAllTenants returns a copy of ctx that is not scoped to a tenant, for system jobs
+// such as the expiry sweep and projection rebuilds
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantKey{}, allTenants)
+}
+
+// TenantFromContext returns the tenant ctx is scoped to, or DefaultTenantID if there is none.
+// The second result is false for contexts created by WithAllTenants.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantKey{}).(string)
+	switch {
+	case !ok || tenantID == "":
+		return DefaultTenantID, true
+	case tenantID == allTenants:
+		return "", false
+	}
+	return tenantID, true
+}
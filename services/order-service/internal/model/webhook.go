@@ -0,0 +1,121 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// WebhookDeliveryStatus constants. Pending deliveries are retried until they are delivered
+// or run out of attempts and become dead.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// WebhookEventAll subscribes to every order event
+const WebhookEventAll = routingKeyOrderPrefix + "*"
+
+// webhookEventTypes are the routing keys of the order events a webhook can subscribe to
+var webhookEventTypes = []string{
+	RoutingKeyOrderCreated,
+	StatusRoutingKey(OrderStatusConfirmed),
+	StatusRoutingKey(OrderStatusShipped),
+	StatusRoutingKey(OrderStatusDelivered),
+	StatusRoutingKey(OrderStatusCancelled),
+	StatusRoutingKey(OrderStatusRefunded),
+	RoutingKeyOrderExpired,
+	WebhookEventAll,
+}
+
+// IsWebhookEventType reports whether eventType is an event type a webhook can subscribe to
+func IsWebhookEventType(eventType string) bool {
+	for _, t := range webhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEventTypes returns the event types a webhook can subscribe to
+func WebhookEventTypes() []string {
+	return append([]string(nil), webhookEventTypes...)
+}
+
+// WebhookSubscription is a partner endpoint that receives the order events of its tenant.
+// EventTypes are routing keys such as "order.created", or WebhookEventAll. Secret signs
+// every payload sent to URL and is only returned when the subscription is created.
+// Inactive subscriptions receive no new events and their pending deliveries are held.
+type WebhookSubscription struct {
+	ID         string    `json:"id"`
+	TenantID   string    `json:"tenant_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"-"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the subscription receives events with the given routing key
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType || (t == WebhookEventAll && strings.HasPrefix(eventType, routingKeyOrderPrefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRequest represents the request to create or replace a webhook subscription.
+// A random secret is generated when Secret is empty; on update an empty Secret keeps the
+// current one. Active defaults to true.
+type WebhookRequest struct {
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,webhook_event"`
+	Secret     string   `json:"secret,omitempty" validate:"omitempty,min=16,max=255"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+// CreatedWebhookSubscription is a newly created subscription together with its secret,
+// which is never shown again
+type CreatedWebhookSubscription struct {
+	*WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// WebhookDelivery is one order event sent, or to be sent, to a subscription.
+// EventType is the routing key of the event and Payload its JSON body. The Last fields
+// describe the most recent attempt.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookDispatch is a delivery claimed for sending, with the endpoint and secret of its subscription
+type WebhookDispatch struct {
+	*WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// WebhookAttempt is the outcome of sending a delivery once. StatusCode is zero when no
+// response was received.
+type WebhookAttempt struct {
+	At         time.Time
+	StatusCode int
+	Err        error
+}
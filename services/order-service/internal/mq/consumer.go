@@ -0,0 +1,341 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Consumer queue constants. Both queues receive every order event.
+const (
+	webhookQueueName = "webhooks.orders"
+	orderRoutingKey  = "order.*"
+)
+
+// EventHandler processes the body of an event published with the given routing key
+type EventHandler func(ctx context.Context, routingKey string, body []byte) error
+
+// EventConsumer interface for consuming events
+type EventConsumer interface {
+	StartConsuming(ctx context.Context, handler EventHandler) error
+	Close() error
+}
+
+// RabbitMQConsumer implements EventConsumer using RabbitMQ. When the broker closes the
+// connection or channel, the consumer dials again, backing off between attempts, declares and
+// binds its queue again and resumes consuming.
+type RabbitMQConsumer struct {
+	url string
+	// name is the durable queue consumed, or empty for an exclusive server-named queue
+	name string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	queue   string
+	closed  bool
+
+	// interrupted, if set, is called whenever messages may have been missed
+	interrupted func()
+}
+
+// NewRabbitMQConsumer creates a new RabbitMQ consumer of the webhook queue, which is shared by
+// every replica so that each event is handled once
+func NewRabbitMQConsumer(url string) (*RabbitMQConsumer, error) {
+	return newRabbitMQConsumer(url, webhookQueueName)
+}
+
+// NewRabbitMQBroadcastConsumer creates a new RabbitMQ consumer of a queue of its own, deleted
+// when the consumer disconnects, so that every replica receives every event
+func NewRabbitMQBroadcastConsumer(url string) (*RabbitMQConsumer, error) {
+	return newRabbitMQConsumer(url, "")
+}
+
+// newRabbitMQConsumer creates a consumer of the named durable queue, or of an exclusive
+// server-named queue if name is empty
+func newRabbitMQConsumer(url, name string) (*RabbitMQConsumer, error) {
+	c := &RabbitMQConsumer{url: url, name: name}
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("RabbitMQ consumer connected, queue '%s' bound to exchange '%s'", c.queue, exchangeName)
+	return c, nil
+}
+
+// connect dials the broker, declares the exchange and the queue and binds them.
+// c.mu must be held, or c not yet shared.
+func (c *RabbitMQConsumer) connect() error {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Printf("Error closing connection: %v", closeErr)
+		}
+		return fmt.Errorf("failed to open channel: %w", err)
+	}
+
+	closeAll := func() {
+		if closeErr := channel.Close(); closeErr != nil {
+			log.Printf("Error closing channel: %v", closeErr)
+		}
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Printf("Error closing connection: %v", closeErr)
+		}
+	}
+
+	// Declare exchange
+	err = channel.ExchangeDeclare(
+		exchangeName, // name
+		exchangeType, // type
+		true,         // durable
+		false,        // auto-deleted
+		false,        // internal
+		false,        // no-wait
+		nil,          // arguments
+	)
+	if err != nil {
+		closeAll()
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	// Declare queue
+	shared := c.name != ""
+	queue, err := channel.QueueDeclare(
+		c.name,  // name
+		shared,  // durable
+		!shared, // delete when unused
+		!shared, // exclusive
+		false,   // no-wait
+		nil,     // arguments
+	)
+	if err != nil {
+		closeAll()
+		return fmt.Errorf("failed to declare queue: %w", err)
+	}
+
+	// Bind queue to exchange
+	err = channel.QueueBind(
+		queue.Name,      // queue name
+		orderRoutingKey, // routing key
+		exchangeName,    // exchange
+		false,
+		nil,
+	)
+	if err != nil {
+		closeAll()
+		return fmt.Errorf("failed to bind queue: %w", err)
+	}
+
+	// Set QoS to process one message at a time
+	err = channel.Qos(
+		1,     // prefetch count
+		0,     // prefetch size
+		false, // global
+	)
+	if err != nil {
+		closeAll()
+		return fmt.Errorf("failed to set QoS: %w", err)
+	}
+
+	c.conn = conn
+	c.channel = channel
+	c.queue = queue.Name
+	return nil
+}
+
+// SetInterruptHandler sets a function called when the consumer loses its connection, after
+// each failed attempt to reconnect and once it consumes again. An exclusive queue is deleted
+// with the connection, so the messages published in between are lost to the consumer. Set it
+// before StartConsuming.
+func (c *RabbitMQConsumer) SetInterruptHandler(fn func()) {
+	c.interrupted = fn
+}
+
+// interrupt calls the interrupt handler, if any
+func (c *RabbitMQConsumer) interrupt() {
+	if c.interrupted != nil {
+		c.interrupted()
+	}
+}
+
+// consume registers a consumer of the queue on the current channel. c.mu must be held.
+func (c *RabbitMQConsumer) consume() (<-chan amqp.Delivery, error) {
+	msgs, err := c.channel.Consume(
+		c.queue, // queue
+		"",      // consumer
+		false,   // auto-ack
+		false,   // exclusive
+		false,   // no-local
+		false,   // no-wait
+		nil,     // args
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register consumer: %w", err)
+	}
+	return msgs, nil
+}
+
+// StartConsuming starts consuming messages from the queue until ctx is done or the consumer is
+// closed. Messages the handler fails to process are requeued.
+func (c *RabbitMQConsumer) StartConsuming(ctx context.Context, handler EventHandler) error {
+	c.mu.Lock()
+	msgs, err := c.consume()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	go c.run(ctx, handler, msgs)
+	return nil
+}
+
+// run hands msgs to handler and consumes again after reconnecting whenever they stop
+func (c *RabbitMQConsumer) run(ctx context.Context, handler EventHandler, msgs <-chan amqp.Delivery) {
+	for {
+		c.deliver(ctx, handler, msgs)
+		if ctx.Err() != nil {
+			log.Println("Stopping consumer...")
+			return
+		}
+
+		if msgs = c.reconnect(ctx); msgs == nil {
+			return
+		}
+	}
+}
+
+// deliver hands messages to handler until msgs is closed or ctx is done
+func (c *RabbitMQConsumer) deliver(ctx context.Context, handler EventHandler, msgs <-chan amqp.Delivery) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+
+			if err := handler(ctx, msg.RoutingKey, msg.Body); err != nil {
+				log.Printf("Error processing %s event %s: %v", msg.RoutingKey, msg.MessageId, err)
+				if nackErr := msg.Nack(false, true); nackErr != nil {
+					log.Printf("Error nacking message: %v", nackErr)
+				}
+				continue
+			}
+
+			if ackErr := msg.Ack(false); ackErr != nil {
+				log.Printf("Error acking message: %v", ackErr)
+			}
+		}
+	}
+}
+
+// reconnect drops the lost connection and dials again until the queue is consumed anew,
+// backing off between attempts. It returns nil if ctx is done or the consumer is closed first.
+func (c *RabbitMQConsumer) reconnect(ctx context.Context) <-chan amqp.Delivery {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	log.Printf("RabbitMQ consumer of queue '%s' lost its connection, reconnecting", c.queue)
+	c.reset()
+	c.mu.Unlock()
+	c.interrupt()
+
+	delay := minReconnectDelay
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping consumer...")
+			return nil
+		case <-time.After(delay):
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			return nil
+		}
+		msgs, err := c.resume()
+		c.mu.Unlock()
+		c.interrupt()
+		if err == nil {
+			log.Printf("RabbitMQ consumer reconnected, queue '%s' bound to exchange '%s'", c.queue, exchangeName)
+			return msgs
+		}
+
+		log.Printf("Error reconnecting RabbitMQ consumer, retrying in %s: %v", delay, err)
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// resume connects and consumes the queue again. c.mu must be held.
+func (c *RabbitMQConsumer) resume() (<-chan amqp.Delivery, error) {
+	if err := c.connect(); err != nil {
+		return nil, err
+	}
+
+	msgs, err := c.consume()
+	if err != nil {
+		c.reset()
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// reset closes the current connection, if any. c.mu must be held.
+func (c *RabbitMQConsumer) reset() {
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			log.Printf("Error closing connection: %v", err)
+		}
+	}
+	c.conn = nil
+	c.channel = nil
+}
+
+// Close closes the RabbitMQ connection and stops consuming
+func (c *RabbitMQConsumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+
+	if c.channel != nil {
+		if err := c.channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			return err
+		}
+	}
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			return err
+		}
+	}
+	return nil
+}
+
+// HealthCheck checks if the consumer is connected to the broker. It fails while the consumer
+// is reconnecting.
+func (c *RabbitMQConsumer) HealthCheck() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil || c.channel == nil {
+		return fmt.Errorf("reconnecting to queue '%s'", c.queue)
+	}
+	if c.conn.IsClosed() || c.channel.IsClosed() {
+		return fmt.Errorf("connection to queue '%s' is closed", c.queue)
+	}
+	return nil
+}
@@ -3,9 +3,10 @@ package mq
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/andev0x/order-service/internal/model"
@@ -15,26 +16,47 @@ import (
 const (
 	exchangeName = "orders"
 	exchangeType = "topic"
-	routingKey   = "order.created"
+
+	// minReconnectDelay and maxReconnectDelay bound the wait between attempts to dial a lost broker
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
 )
 
 // EventPublisher interface for publishing events
 type EventPublisher interface {
-	PublishOrderCreated(ctx context.Context, event *model.OrderCreatedEvent) error
+	Publish(ctx context.Context, msg *model.OutboxMessage) error
+	PublishBatch(ctx context.Context, msgs []*model.OutboxMessage) []error
 	Close() error
 }
 
-// RabbitMQPublisher implements EventPublisher using RabbitMQ
+// RabbitMQPublisher implements EventPublisher using RabbitMQ. A connection or channel the
+// broker closes, or a channel a publish fails on, is replaced by dialing again on the next publish.
 type RabbitMQPublisher struct {
+	url string
+
+	mu      sync.Mutex
 	conn    *amqp.Connection
 	channel *amqp.Channel
+	closed  bool
 }
 
 // NewRabbitMQPublisher creates a new RabbitMQ publisher
 func NewRabbitMQPublisher(url string) (*RabbitMQPublisher, error) {
-	conn, err := amqp.Dial(url)
+	p := &RabbitMQPublisher{url: url}
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("RabbitMQ publisher connected and exchange '%s' declared", exchangeName)
+	return p, nil
+}
+
+// connect dials the broker, declares the exchange and opens a confirm channel.
+// p.mu must be held, or p not yet shared.
+func (p *RabbitMQPublisher) connect() error {
+	conn, err := amqp.Dial(p.url)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
 	channel, err := conn.Channel()
@@ -42,7 +64,7 @@ func NewRabbitMQPublisher(url string) (*RabbitMQPublisher, error) {
 		if closeErr := conn.Close(); closeErr != nil {
 			log.Printf("Error closing connection: %v", closeErr)
 		}
-		return nil, fmt.Errorf("failed to open channel: %w", err)
+		return fmt.Errorf("failed to open channel: %w", err)
 	}
 
 	// Declare exchange
@@ -62,56 +84,182 @@ func NewRabbitMQPublisher(url string) (*RabbitMQPublisher, error) {
 		if closeErr := conn.Close(); closeErr != nil {
 			log.Printf("Error closing connection: %v", closeErr)
 		}
-		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	log.Printf("RabbitMQ publisher connected and exchange '%s' declared", exchangeName)
+	// Enable publisher confirms so callers know the broker has accepted each message
+	if err := channel.Confirm(false); err != nil {
+		if closeErr := channel.Close(); closeErr != nil {
+			log.Printf("Error closing channel: %v", closeErr)
+		}
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Printf("Error closing connection: %v", closeErr)
+		}
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
 
-	return &RabbitMQPublisher{
-		conn:    conn,
-		channel: channel,
-	}, nil
+	p.conn = conn
+	p.channel = channel
+	go p.watch(conn, channel)
+	return nil
 }
 
-// PublishOrderCreated publishes an order created event
-func (p *RabbitMQPublisher) PublishOrderCreated(ctx context.Context, event *model.OrderCreatedEvent) error {
-	event.EventType = "OrderCreated"
+// watch waits for the broker to close conn or channel, drops them and dials again until
+// connected, backing off between attempts. A publish in the meantime dials on its own.
+func (p *RabbitMQPublisher) watch(conn *amqp.Connection, channel *amqp.Channel) {
+	var reason *amqp.Error
+	select {
+	case reason = <-conn.NotifyClose(make(chan *amqp.Error, 1)):
+	case reason = <-channel.NotifyClose(make(chan *amqp.Error, 1)):
+	}
+	p.reset(channel)
+	if reason == nil {
+		// Closed by Close or after a failed publish
+		return
+	}
 
-	body, err := json.Marshal(event)
-	if err != nil {
-		return fmt.Errorf("failed to marshal event: %w", err)
-	}
-
-	err = p.channel.PublishWithContext(
-		ctx,
-		exchangeName, // exchange
-		routingKey,   // routing key
-		false,        // mandatory
-		false,        // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent,
-			Timestamp:    time.Now(),
-		},
-	)
+	log.Printf("RabbitMQ publisher connection lost, reconnecting: %v", reason)
+	delay := minReconnectDelay
+	for {
+		time.Sleep(delay)
+
+		p.mu.Lock()
+		if p.closed || p.channel != nil {
+			p.mu.Unlock()
+			return
+		}
+		err := p.connect()
+		p.mu.Unlock()
+		if err == nil {
+			log.Println("RabbitMQ publisher reconnected")
+			return
+		}
+
+		log.Printf("Error reconnecting RabbitMQ publisher, retrying in %s: %v", delay, err)
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// reset closes the connection of channel, unless it has already been replaced
+func (p *RabbitMQPublisher) reset(channel *amqp.Channel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.channel != channel {
+		return
+	}
+
+	if err := p.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+		log.Printf("Error closing connection: %v", err)
+	}
+	p.conn = nil
+	p.channel = nil
+}
+
+// currentChannel returns the confirm channel, dialing the broker again if it was lost
+func (p *RabbitMQPublisher) currentChannel() (*amqp.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, amqp.ErrClosed
+	}
+	if p.channel != nil {
+		return p.channel, nil
+	}
+
+	if err := p.connect(); err != nil {
+		return nil, err
+	}
+	log.Println("RabbitMQ publisher reconnected")
+	return p.channel, nil
+}
+
+// Publish publishes an outbox message to the orders exchange using its routing key
+// and waits for the broker to confirm it
+func (p *RabbitMQPublisher) Publish(ctx context.Context, msg *model.OutboxMessage) error {
+	return p.PublishBatch(ctx, []*model.OutboxMessage{msg})[0]
+}
+
+// PublishBatch publishes outbox messages to the orders exchange and then waits for the broker
+// to confirm them, so a batch costs one round trip rather than one per message. It returns one
+// error per message, nil for those the broker confirmed.
+func (p *RabbitMQPublisher) PublishBatch(ctx context.Context, msgs []*model.OutboxMessage) []error {
+	errs := make([]error, len(msgs))
+	confirmations := make([]*amqp.DeferredConfirmation, len(msgs))
+
+	channel, err := p.currentChannel()
 	if err != nil {
-		return fmt.Errorf("failed to publish event: %w", err)
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
 	}
 
-	log.Printf("Published OrderCreated event for order: %s", event.OrderID)
-	return nil
+	failed := false
+	for i, msg := range msgs {
+		confirmation, err := channel.PublishWithDeferredConfirmWithContext(
+			ctx,
+			exchangeName,   // exchange
+			msg.RoutingKey, // routing key
+			false,          // mandatory
+			false,          // immediate
+			amqp.Publishing{
+				ContentType:  "application/json",
+				Body:         msg.Payload,
+				DeliveryMode: amqp.Persistent,
+				MessageId:    msg.EventID,
+				Type:         msg.EventType,
+				Timestamp:    time.Now(),
+			},
+		)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to publish event: %w", err)
+			failed = true
+			continue
+		}
+		confirmations[i] = confirmation
+	}
+
+	for i, confirmation := range confirmations {
+		if confirmation == nil {
+			continue
+		}
+
+		acked, err := confirmation.WaitContext(ctx)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to wait for publish confirmation: %w", err)
+			continue
+		}
+		if !acked {
+			errs[i] = fmt.Errorf("broker rejected event %s", msgs[i].EventID)
+			continue
+		}
+
+		log.Printf("Published %s event %s for order: %s", msgs[i].EventType, msgs[i].EventID, msgs[i].AggregateID)
+	}
+
+	// A channel that failed to publish may be unusable, so the next batch starts on a new one
+	if failed {
+		p.reset(channel)
+	}
+
+	return errs
 }
 
 // Close closes the RabbitMQ connection
 func (p *RabbitMQPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+
 	if p.channel != nil {
-		if err := p.channel.Close(); err != nil {
+		if err := p.channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 			return err
 		}
 	}
 	if p.conn != nil {
-		if err := p.conn.Close(); err != nil {
+		if err := p.conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
 			return err
 		}
 	}
@@ -120,6 +268,8 @@ func (p *RabbitMQPublisher) Close() error {
 
 // HealthCheck checks if the RabbitMQ connection is alive
 func (p *RabbitMQPublisher) HealthCheck() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	if p.conn == nil {
 		return fmt.Errorf("connection is nil")
 	}
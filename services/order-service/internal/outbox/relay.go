@@ -0,0 +1,210 @@
+// Package outbox relays events stored in the transactional outbox to the message broker.
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/andev0x/order-service/internal/mq"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pendingMessages = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_messages",
+		Help: "Number of outbox messages waiting to be published",
+	})
+	oldestPendingAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_oldest_pending_age_seconds",
+		Help: "Age in seconds of the oldest unpublished outbox message",
+	})
+	publishedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_published_total",
+		Help: "Total number of outbox messages published",
+	})
+	publishFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Total number of failed outbox publish attempts",
+	})
+	prunedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_pruned_total",
+		Help: "Total number of sent outbox messages deleted after the retention period",
+	})
+)
+
+// Config holds relay tuning parameters
+type Config struct {
+	PollInterval   time.Duration // how often to look for pending messages
+	BatchSize      int           // maximum messages claimed per poll
+	Lease          time.Duration // how long claimed messages are hidden from other relays
+	PublishTimeout time.Duration // maximum time to wait for a broker confirm
+	MinBackoff     time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound for retry delay
+	Retention      time.Duration // how long sent messages are kept; 0 keeps them forever
+	PruneInterval  time.Duration // how often sent messages past the retention are deleted
+}
+
+// DefaultConfig returns the default relay configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:   time.Second,
+		BatchSize:      100,
+		Lease:          30 * time.Second,
+		PublishTimeout: 5 * time.Second,
+		MinBackoff:     time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Retention:      7 * 24 * time.Hour,
+		PruneInterval:  time.Hour,
+	}
+}
+
+// Relay publishes pending outbox messages and marks them sent.
+// Delivery is at-least-once: a message may be published again if the relay
+// stops between the broker confirm and marking the row sent.
+type Relay struct {
+	repo      repository.OutboxRepository
+	publisher mq.EventPublisher
+	config    Config
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(repo repository.OutboxRepository, publisher mq.EventPublisher, config Config) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// Run polls the outbox until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	log.Printf("Outbox relay started (poll interval %s, batch size %d)", r.config.PollInterval, r.config.BatchSize)
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		r.drain(ctx)
+		r.updateBacklog(ctx)
+
+		if r.config.Retention > 0 && time.Since(lastPrune) >= r.config.PruneInterval {
+			lastPrune = time.Now()
+			if _, err := r.Prune(ctx); err != nil {
+				log.Printf("Error pruning sent outbox messages: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain relays batches until the outbox has no more due messages
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.RelayBatch(ctx)
+		if err != nil {
+			log.Printf("Error relaying outbox messages: %v", err)
+			return
+		}
+		if n < r.config.BatchSize {
+			return
+		}
+	}
+}
+
+// RelayBatch claims and publishes one batch of due messages, returning how many were claimed.
+// The batch is published before waiting for broker confirms and the sent messages are marked
+// with a single update.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	messages, err := r.repo.ClaimPending(ctx, r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	publishCtx, cancel := context.WithTimeout(ctx, r.config.PublishTimeout)
+	errs := r.publisher.PublishBatch(publishCtx, messages)
+	cancel()
+
+	if ctx.Err() != nil {
+		// Unconfirmed messages become due again once their lease expires
+		return len(messages), nil
+	}
+
+	sent := make([]int64, 0, len(messages))
+	for i, msg := range messages {
+		if errs[i] == nil {
+			sent = append(sent, msg.ID)
+			continue
+		}
+
+		publishFailuresTotal.Inc()
+		next := time.Now().Add(r.Backoff(msg.Attempts + 1))
+		log.Printf("Error publishing outbox message %s (attempt %d), retrying at %s: %v",
+			msg.EventID, msg.Attempts+1, next.Format(time.RFC3339), errs[i])
+		if markErr := r.repo.MarkFailed(ctx, msg.ID, next, errs[i]); markErr != nil {
+			log.Printf("Error recording outbox failure for %s: %v", msg.EventID, markErr)
+		}
+	}
+
+	publishedTotal.Add(float64(len(sent)))
+	if err := r.repo.MarkSent(ctx, sent); err != nil {
+		log.Printf("Error marking %d outbox messages sent: %v", len(sent), err)
+	}
+
+	return len(messages), nil
+}
+
+// Prune deletes the messages sent longer ago than the retention period and returns how many
+// were deleted
+func (r *Relay) Prune(ctx context.Context) (int64, error) {
+	deleted, err := r.repo.DeleteSent(ctx, time.Now().Add(-r.config.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	prunedTotal.Add(float64(deleted))
+	if deleted > 0 {
+		log.Printf("Deleted %d sent outbox messages older than %s", deleted, r.config.Retention)
+	}
+	return deleted, nil
+}
+
+// Backoff returns the retry delay after the given number of failed attempts
+func (r *Relay) Backoff(attempts int) time.Duration {
+	delay := r.config.MinBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.config.MaxBackoff {
+			return r.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// updateBacklog refreshes the backlog metrics
+func (r *Relay) updateBacklog(ctx context.Context) {
+	backlog, err := r.repo.Backlog(ctx)
+	if err != nil {
+		log.Printf("Error reading outbox backlog: %v", err)
+		return
+	}
+
+	pendingMessages.Set(float64(backlog.Pending))
+	if backlog.Pending == 0 || backlog.OldestCreatedAt.IsZero() {
+		oldestPendingAge.Set(0)
+		return
+	}
+	oldestPendingAge.Set(time.Since(backlog.OldestCreatedAt).Seconds())
+}
@@ -0,0 +1,113 @@
+// Package pricing computes order prices from the server-side price table.
+package pricing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+)
+
+var (
+	// ErrUnknownProduct is returned when an item references a product that is missing or inactive
+	ErrUnknownProduct = model.NewError(model.ErrValidation, "unknown product")
+	// ErrPriceMismatch is returned when a client-supplied price disagrees with the computed one
+	ErrPriceMismatch = model.NewError(model.ErrValidation, "price mismatch")
+)
+
+// Quote is the server-computed price of a set of line items
+type Quote struct {
+	Items    []model.OrderItem `json:"items"`
+	Subtotal model.Money       `json:"subtotal"`
+	Discount model.Money       `json:"discount"`
+	Total    model.Money       `json:"total"`
+}
+
+// Calculator prices line items using the product price table
+type Calculator struct {
+	products repository.ProductRepository
+}
+
+// NewCalculator creates a new price calculator
+func NewCalculator(products repository.ProductRepository) *Calculator {
+	return &Calculator{products: products}
+}
+
+// Quote prices the requested items. Each line total is unit price times quantity
+// less the product's percentage discount, rounded to the minor unit. All items
+// must be priced in the same currency.
+func (c *Calculator) Quote(ctx context.Context, reqItems []model.CreateOrderItemRequest) (*Quote, error) {
+	ids := make([]string, 0, len(reqItems))
+	for _, item := range reqItems {
+		ids = append(ids, item.ProductID)
+	}
+
+	products, err := c.products.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prices: %w", err)
+	}
+
+	quote := &Quote{Items: make([]model.OrderItem, 0, len(reqItems))}
+	for i, item := range reqItems {
+		product, ok := products[item.ProductID]
+		if !ok || !product.Active {
+			return nil, fmt.Errorf("%w: items[%d].product_id %q is not for sale", ErrUnknownProduct, i, item.ProductID)
+		}
+		if item.UnitPrice != nil && *item.UnitPrice != product.UnitPrice {
+			return nil, fmt.Errorf("%w: items[%d].unit_price %s does not match the current price %s",
+				ErrPriceMismatch, i, item.UnitPrice, product.UnitPrice)
+		}
+
+		if i > 0 && product.UnitPrice.Currency != quote.Subtotal.Currency {
+			return nil, fmt.Errorf("%w: items[%d] is priced in %s but the order is in %s",
+				model.ErrCurrencyMismatch, i, product.UnitPrice.Currency, quote.Subtotal.Currency)
+		}
+		gross, err := product.UnitPrice.Mul(int64(item.Quantity))
+		if err != nil {
+			return nil, fmt.Errorf("items[%d]: %w", i, err)
+		}
+		discount, err := gross.Percent(product.DiscountPercent)
+		if err != nil {
+			return nil, fmt.Errorf("items[%d]: %w", i, err)
+		}
+		lineTotal, err := gross.Sub(discount)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			zero := model.NewMoney(0, product.UnitPrice.Currency)
+			quote.Subtotal, quote.Discount, quote.Total = zero, zero, zero
+		}
+		if quote.Subtotal, err = quote.Subtotal.Add(gross); err != nil {
+			return nil, fmt.Errorf("order subtotal: %w", err)
+		}
+		if quote.Discount, err = quote.Discount.Add(discount); err != nil {
+			return nil, err
+		}
+		if quote.Total, err = quote.Total.Add(lineTotal); err != nil {
+			return nil, fmt.Errorf("order total: %w", err)
+		}
+
+		quote.Items = append(quote.Items, model.OrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: product.UnitPrice,
+			Discount:  discount,
+			LineTotal: lineTotal,
+		})
+	}
+
+	return quote, nil
+}
+
+// CheckTotal returns ErrPriceMismatch if a client-supplied total disagrees with the quote.
+// A nil total means the client did not supply one.
+func (q *Quote) CheckTotal(clientTotal *model.Money) error {
+	if clientTotal == nil || *clientTotal == q.Total {
+		return nil
+	}
+	return fmt.Errorf("%w: total_amount %s does not match the computed total %s",
+		ErrPriceMismatch, clientTotal, q.Total)
+}
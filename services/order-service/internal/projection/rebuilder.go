@@ -0,0 +1,89 @@
+// Package projection rebuilds the orders table from the order event log.
+package projection
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/andev0x/order-service/internal/cache"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+)
+
+// Rebuilder replays order event streams into the orders projection
+type Rebuilder struct {
+	store     repository.OrderEventStore
+	cache     cache.OrderCache
+	batchSize int
+}
+
+// NewRebuilder creates a new projection rebuilder.
+// Cached copies of rebuilt orders are invalidated; cache may be nil.
+func NewRebuilder(store repository.OrderEventStore, cache cache.OrderCache, batchSize int) *Rebuilder {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Rebuilder{
+		store:     store,
+		cache:     cache,
+		batchSize: batchSize,
+	}
+}
+
+// RebuildOrder replays the events of one order and overwrites its projection.
+// The order is looked up across all tenants since its projection may be missing.
+func (r *Rebuilder) RebuildOrder(ctx context.Context, id string) (*model.Order, error) {
+	events, err := r.store.GetEvents(model.WithAllTenants(ctx), id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events of order %s: %w", id, err)
+	}
+
+	order, err := model.ReplayOrder(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay order %s: %w", id, err)
+	}
+
+	if err := r.store.SaveProjection(ctx, order); err != nil {
+		return nil, fmt.Errorf("failed to save projection of order %s: %w", id, err)
+	}
+
+	if r.cache != nil {
+		if err := r.cache.Delete(model.WithTenant(ctx, order.TenantID), id); err != nil {
+			log.Printf("Warning: failed to invalidate cached order %s: %v", id, err)
+		}
+	}
+
+	return order, nil
+}
+
+// RebuildAll rebuilds the projection of every order in the event log and returns the
+// number of orders rebuilt and failed. An order whose events cannot be replayed is
+// logged and skipped so that one corrupt stream does not block the others.
+func (r *Rebuilder) RebuildAll(ctx context.Context) (rebuilt, failed int, err error) {
+	after := ""
+	for {
+		ids, err := r.store.ListOrderIDs(model.WithAllTenants(ctx), after, r.batchSize)
+		if err != nil {
+			return rebuilt, failed, fmt.Errorf("failed to list orders: %w", err)
+		}
+		if len(ids) == 0 {
+			return rebuilt, failed, nil
+		}
+
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				return rebuilt, failed, ctx.Err()
+			}
+			if _, err := r.RebuildOrder(ctx, id); err != nil {
+				log.Printf("Error rebuilding order %s: %v", id, err)
+				failed++
+				continue
+			}
+			rebuilt++
+		}
+
+		after = ids[len(ids)-1]
+		log.Printf("Rebuilt %d orders so far (%d failed)", rebuilt, failed)
+	}
+}
@@ -0,0 +1,90 @@
+// Package ratelimit takes the requests of API clients from their rate limit buckets, so that
+// a client has one budget whether it calls the HTTP or the gRPC API.
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/cache"
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// Config sets the request rates allowed per client.
+// Default applies to all of a client's requests together, unless the client's API key
+// sets its own rate; Routes additionally limits each client on the listed routes, keyed
+// by method and path template such as "POST /orders".
+type Config struct {
+	Default model.RateLimit
+	Routes  map[string]model.RateLimit
+}
+
+// Limiter takes requests from the buckets of their client
+type Limiter struct {
+	limiter cache.RateLimiter
+	config  Config
+}
+
+// NewLimiter creates a Limiter keeping its buckets in limiter
+func NewLimiter(limiter cache.RateLimiter, config Config) *Limiter {
+	return &Limiter{limiter: limiter, config: config}
+}
+
+// Take takes a request on route from the buckets of its client and returns the state of the
+// most constrained bucket. Clients are identified by the API key or token subject of the
+// principal in ctx, or by remoteAddr for anonymous requests. Take returns nil if no bucket
+// applies or the limiter is unavailable, in which case the request is let through.
+func (l *Limiter) Take(ctx context.Context, route, remoteAddr string) *model.RateLimitResult {
+	client, clientLimit := l.client(ctx, remoteAddr)
+
+	type bucket struct {
+		key   string
+		limit model.RateLimit
+	}
+	buckets := make([]bucket, 0, 2)
+	if limit, ok := l.config.Routes[route]; ok {
+		buckets = append(buckets, bucket{key: client + ":" + route, limit: limit})
+	}
+	if clientLimit.Requests > 0 {
+		buckets = append(buckets, bucket{key: client, limit: clientLimit})
+	}
+
+	var tightest *model.RateLimitResult
+	for _, b := range buckets {
+		result, err := l.limiter.Allow(ctx, b.key, b.limit)
+		if err != nil {
+			log.Printf("Warning: rate limiter unavailable, allowing request: %v", err)
+			continue
+		}
+		if tightest == nil || !result.Allowed || result.Remaining < tightest.Remaining {
+			tightest = result
+		}
+		if !result.Allowed {
+			break
+		}
+	}
+	return tightest
+}
+
+// client identifies the client of a request and the rate allowed across all its requests
+func (l *Limiter) client(ctx context.Context, remoteAddr string) (string, model.RateLimit) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	switch {
+	case ok && principal.APIKeyID != "":
+		if principal.RateLimit > 0 {
+			return "key:" + principal.APIKeyID, model.RateLimit{Requests: principal.RateLimit, Period: time.Minute}
+		}
+		return "key:" + principal.APIKeyID, l.config.Default
+	case ok:
+		return "sub:" + principal.Role + ":" + principal.Subject, l.config.Default
+	default:
+		host, _, err := net.SplitHostPort(remoteAddr)
+		if err != nil {
+			host = remoteAddr
+		}
+		return "ip:" + host, l.config.Default
+	}
+}
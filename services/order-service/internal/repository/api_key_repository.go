@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// ErrAPIKeyNotFound is returned when no API key exists with the requested ID or hash
+var ErrAPIKeyNotFound = model.NewError(model.ErrNotFound, "api key not found")
+
+// APIKeyRepository interface defines methods for API key persistence
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+	List(ctx context.Context) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, id string, at time.Time) (*model.APIKey, error)
+}
+
+// SQLAPIKeyRepository implements APIKeyRepository on any supported database
+type SQLAPIKeyRepository struct {
+	db *DB
+}
+
+// NewSQLAPIKeyRepository creates a new API key repository
+func NewSQLAPIKeyRepository(db *DB) *SQLAPIKeyRepository {
+	return &SQLAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, role, customer_id, tenant_id, rate_limit, created_at, revoked_at`
+
+// Create inserts a new API key
+func (r *SQLAPIKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	query := `INSERT INTO api_keys (` + apiKeyColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		key.Role,
+		sql.NullString{String: key.CustomerID, Valid: key.CustomerID != ""},
+		sql.NullString{String: key.TenantID, Valid: key.TenantID != ""},
+		key.RateLimit,
+		key.CreatedAt,
+		key.RevokedAt,
+	)
+	if err != nil {
+		return wrapDBError("create api key", err)
+	}
+	return nil
+}
+
+// GetByHash retrieves the API key with the given SHA-256 hash, including revoked keys
+func (r *SQLAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError("get api key", err)
+	}
+	return key, nil
+}
+
+// List retrieves every API key, newest first
+func (r *SQLAPIKeyRepository) List(ctx context.Context) ([]*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapDBError("list api keys", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, wrapDBError("scan api key", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate api keys", err)
+	}
+	return keys, nil
+}
+
+// Revoke marks an API key revoked at the given time and returns it.
+// Revoking a key twice keeps the original revocation time.
+func (r *SQLAPIKeyRepository) Revoke(ctx context.Context, id string, at time.Time) (*model.APIKey, error) {
+	var key *model.APIKey
+	err := withTx(ctx, r.db, func(tx *Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, at, id); err != nil {
+			return wrapDBError("revoke api key", err)
+		}
+
+		var err error
+		key, err = scanAPIKey(tx.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+		if err == sql.ErrNoRows {
+			return ErrAPIKeyNotFound
+		}
+		if err != nil {
+			return wrapDBError("get api key", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// scanAPIKey scans an API key row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	key := &model.APIKey{}
+	var customerID, tenantID sql.NullString
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Role,
+		&customerID,
+		&tenantID,
+		&key.RateLimit,
+		&key.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	key.CustomerID = customerID.String
+	key.TenantID = tenantID.String
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
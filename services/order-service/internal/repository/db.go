@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	// MySQL driver for database/sql
+	_ "github.com/go-sql-driver/mysql"
+	// PostgreSQL driver for database/sql
+	_ "github.com/lib/pq"
+	// Pure Go SQLite driver for database/sql
+	_ "modernc.org/sqlite"
+)
+
+// Database drivers accepted by InitDB and OpenDB, selected with DB_DRIVER
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqliteMaxOpenConns is the size of the connection pool to a SQLite database
+const sqliteMaxOpenConns = 10
+
+// sqliteTimeFormat is how timestamps are stored in SQLite, which has no date type. Written in
+// UTC, the text of two timestamps sorts like the timestamps themselves.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// dialect describes the SQL syntax that differs between the supported databases. Queries are
+// written with ? placeholders, which DB and Tx rewrite for drivers that number their parameters.
+type dialect struct {
+	driver string
+	// numbered reports whether placeholders are written $1, $2, ...
+	numbered bool
+	// jsonObject and jsonArrayAgg build a JSON array of objects out of grouped rows
+	jsonObject, jsonArrayAgg string
+	// skipDuplicates ends an INSERT so that rows conflicting with a unique key are skipped
+	skipDuplicates string
+	// maxPlaceholders is the most placeholders accepted in one prepared statement
+	maxPlaceholders int
+	// timeFormat, if set, is the layout time arguments are written as text with
+	timeFormat string
+}
+
+var dialects = map[string]*dialect{
+	DriverMySQL: {
+		driver:          DriverMySQL,
+		jsonObject:      "JSON_OBJECT",
+		jsonArrayAgg:    "JSON_ARRAYAGG",
+		skipDuplicates:  " ON DUPLICATE KEY UPDATE id = id",
+		maxPlaceholders: 65535,
+	},
+	DriverPostgres: {
+		driver:          DriverPostgres,
+		numbered:        true,
+		jsonObject:      "json_build_object",
+		jsonArrayAgg:    "json_agg",
+		skipDuplicates:  " ON CONFLICT DO NOTHING",
+		maxPlaceholders: 65535,
+	},
+	DriverSQLite: {
+		driver:          DriverSQLite,
+		jsonObject:      "json_object",
+		jsonArrayAgg:    "json_group_array",
+		skipDuplicates:  " ON CONFLICT DO NOTHING",
+		maxPlaceholders: 32766,
+		timeFormat:      sqliteTimeFormat,
+	},
+}
+
+// rebind rewrites the ? placeholders of query into the syntax of the driver
+func (d *dialect) rebind(query string) string {
+	if !d.numbered || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	n := 0
+	for _, r := range query {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// bind converts the arguments of a query into values the driver stores consistently. Time
+// arguments become UTC text for databases without a date type, so that they compare in order.
+func (d *dialect) bind(args []interface{}) []interface{} {
+	if d.timeFormat == "" {
+		return args
+	}
+
+	bound := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			arg = v.UTC().Format(d.timeFormat)
+		case *time.Time:
+			if v != nil {
+				arg = v.UTC().Format(d.timeFormat)
+			}
+		case sql.NullTime:
+			arg = nil
+			if v.Valid {
+				arg = v.Time.UTC().Format(d.timeFormat)
+			}
+		}
+		bound[i] = arg
+	}
+	return bound
+}
+
+// lockRows returns the clause that ends a SELECT to lock the selected rows of tables, or of all
+// tables if none are given, skipping the rows other transactions hold. SQLite has no row locks
+// and needs none, as a transaction that writes locks the whole database.
+func (d *dialect) lockRows(tables ...string) string {
+	switch {
+	case d.driver == DriverSQLite:
+		return ""
+	case len(tables) == 0:
+		return "FOR UPDATE SKIP LOCKED"
+	default:
+		return "FOR UPDATE OF " + strings.Join(tables, ", ") + " SKIP LOCKED"
+	}
+}
+
+// upsert returns the clause that makes an INSERT overwrite columns of the existing row with the
+// same key instead of failing
+func (d *dialect) upsert(key string, columns ...string) string {
+	sets := make([]string, len(columns))
+	if d.driver == DriverMySQL {
+		for i, column := range columns {
+			sets[i] = column + " = VALUES(" + column + ")"
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+	}
+
+	for i, column := range columns {
+		sets[i] = column + " = EXCLUDED." + column
+	}
+	return "ON CONFLICT (" + key + ") DO UPDATE SET " + strings.Join(sets, ", ")
+}
+
+// nullTime is a sql.NullTime that also scans timestamps SQLite returns as text, which it does
+// for timestamps computed by an expression such as MIN(created_at) rather than read from a column
+type nullTime struct {
+	sql.NullTime
+}
+
+// Scan implements sql.Scanner
+func (t *nullTime) Scan(value interface{}) error {
+	text, ok := value.(string)
+	if !ok {
+		return t.NullTime.Scan(value)
+	}
+
+	parsed, err := time.Parse(sqliteTimeFormat, text)
+	if err != nil {
+		return fmt.Errorf("failed to parse timestamp %q: %w", text, err)
+	}
+	t.Time, t.Valid = parsed, true
+	return nil
+}
+
+// DB is a connection pool to one of the supported databases. Its query methods accept
+// ? placeholders whatever the driver.
+type DB struct {
+	*sql.DB
+	dialect *dialect
+}
+
+// ExecContext executes a query without returning any rows
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.DB.ExecContext(ctx, db.dialect.rebind(query), db.dialect.bind(args)...)
+}
+
+// QueryContext executes a query that returns rows
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.DB.QueryContext(ctx, db.dialect.rebind(query), db.dialect.bind(args)...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.DB.QueryRowContext(ctx, db.dialect.rebind(query), db.dialect.bind(args)...)
+}
+
+// BeginTx starts a transaction
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, dialect: db.dialect}, nil
+}
+
+// Tx is a transaction on a DB. Like DB, its query methods accept ? placeholders.
+type Tx struct {
+	*sql.Tx
+	dialect *dialect
+}
+
+// ExecContext executes a query without returning any rows
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.ExecContext(ctx, tx.dialect.rebind(query), tx.dialect.bind(args)...)
+}
+
+// QueryContext executes a query that returns rows
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.QueryContext(ctx, tx.dialect.rebind(query), tx.dialect.bind(args)...)
+}
+
+// QueryRowContext executes a query that is expected to return at most one row
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRowContext(ctx, tx.dialect.rebind(query), tx.dialect.bind(args)...)
+}
+
+// DefaultPort returns the port the database of driver listens on by default
+func DefaultPort(driver string) string {
+	switch driver {
+	case DriverPostgres:
+		return "5432"
+	case DriverSQLite:
+		return ""
+	default:
+		return "3306"
+	}
+}
+
+// InitDB initializes the database connection for the given driver
+func InitDB(driver, host, port, user, password, dbname string) (*DB, error) {
+	return initDB(driver, host, port, user, password, dbname, false)
+}
+
+// InitMigrationDB initializes a database connection for running migrations. Migrations are
+// scripts of several statements, which MySQL only runs with multiStatements; connections
+// from InitDB leave it off so that an injected query cannot stack further statements.
+func InitMigrationDB(driver, host, port, user, password, dbname string) (*DB, error) {
+	return initDB(driver, host, port, user, password, dbname, true)
+}
+
+// initDB opens a connection pool for driver, allowing several statements per query on MySQL
+// when multiStatements is set
+func initDB(driver, host, port, user, password, dbname string, multiStatements bool) (*DB, error) {
+	var dsn string
+	switch driver {
+	case DriverMySQL:
+		dsn = fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", user, password, host, port, dbname)
+		if multiStatements {
+			dsn += "&multiStatements=true"
+		}
+	case DriverPostgres:
+		// sslmode and the other connection settings come from the PG* environment variables
+		dsn = (&url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(user, password),
+			Host:   net.JoinHostPort(host, port),
+			Path:   "/" + dbname,
+		}).String()
+	case DriverSQLite:
+		// dbname is the path of the database file, created if missing. Transactions other than
+		// read-only ones take the write lock when they begin, so that connections and processes
+		// sharing the file wait for each other.
+		dsn = "file:" + dbname + "?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)" +
+			"&_pragma=busy_timeout(5000)&_txlock=immediate"
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	return OpenDB(driver, dsn)
+}
+
+// OpenDB opens a connection pool with a data source name in the format of the driver and
+// verifies that the database is reachable
+func OpenDB(driver, dsn string) (*DB, error) {
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, wrapDBError("open database", err)
+	}
+
+	// Configure connection pool
+	if driver == DriverSQLite {
+		// In WAL mode readers do not block the single writer or each other, so reads and
+		// exports get connections of their own while writers take turns for the lock
+		db.SetMaxOpenConns(sqliteMaxOpenConns)
+		db.SetMaxIdleConns(sqliteMaxOpenConns)
+	} else {
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(5)
+		db.SetConnMaxLifetime(5 * time.Minute)
+	}
+
+	// Ping to verify connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.Printf("Error closing database connection: %v", closeErr)
+		}
+		return nil, wrapDBError("ping database", err)
+	}
+
+	return &DB{DB: db, dialect: d}, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// LeaseRepository interface defines methods for named leases that let one replica at a time run a job
+type LeaseRepository interface {
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+}
+
+// SQLLeaseRepository implements LeaseRepository on any supported database.
+// Expiry is measured with the database clock so replicas with skewed clocks agree.
+type SQLLeaseRepository struct {
+	db *DB
+}
+
+// NewSQLLeaseRepository creates a new lease repository
+func NewSQLLeaseRepository(db *DB) *SQLLeaseRepository {
+	return &SQLLeaseRepository{db: db}
+}
+
+// acquireLeaseQueries are the upserts that take or extend a lease, by driver
+var acquireLeaseQueries = map[string]string{
+	// Assignments apply left to right, so expires_at is only extended if holder now matches
+	DriverMySQL: `
+		INSERT INTO scheduler_leases (name, holder, expires_at)
+		VALUES (?, ?, NOW(3) + INTERVAL ? MICROSECOND)
+		ON DUPLICATE KEY UPDATE
+			holder = IF(expires_at <= NOW(3) OR holder = VALUES(holder), VALUES(holder), holder),
+			expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at)
+	`,
+	// The existing row is only updated if the lease expired or holder already has it
+	DriverPostgres: `
+		INSERT INTO scheduler_leases (name, holder, expires_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP + CAST(? AS DOUBLE PRECISION) * INTERVAL '1 microsecond')
+		ON CONFLICT (name) DO UPDATE
+			SET holder = EXCLUDED.holder, expires_at = EXCLUDED.expires_at
+			WHERE scheduler_leases.expires_at <= CURRENT_TIMESTAMP OR scheduler_leases.holder = EXCLUDED.holder
+	`,
+	// As on PostgreSQL; expiries are UTC text with milliseconds, which compares in time order
+	DriverSQLite: `
+		INSERT INTO scheduler_leases (name, holder, expires_at)
+		VALUES (?, ?, strftime('%Y-%m-%d %H:%M:%f', 'now', printf('%+.6f seconds', ? / 1000000.0)))
+		ON CONFLICT (name) DO UPDATE
+			SET holder = excluded.holder, expires_at = excluded.expires_at
+			WHERE scheduler_leases.expires_at <= strftime('%Y-%m-%d %H:%M:%f', 'now')
+				OR scheduler_leases.holder = excluded.holder
+	`,
+}
+
+// Acquire takes the named lease for ttl, or extends it if holder already has it.
+// It reports false if another holder has an unexpired lease.
+func (r *SQLLeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	upsertQuery := acquireLeaseQueries[r.db.dialect.driver]
+	selectQuery := `SELECT holder FROM scheduler_leases WHERE name = ?`
+
+	var current string
+	err := withTx(ctx, r.db, func(tx *Tx) error {
+		if _, err := tx.ExecContext(ctx, upsertQuery, name, holder, ttl.Microseconds()); err != nil {
+			return wrapDBError("acquire lease", err)
+		}
+		if err := tx.QueryRowContext(ctx, selectQuery, name).Scan(&current); err != nil {
+			return wrapDBError("read lease", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return current == holder, nil
+}
+
+// Release gives up the named lease if holder has it, so another replica can take over without waiting
+func (r *SQLLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	query := `DELETE FROM scheduler_leases WHERE name = ? AND holder = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, name, holder); err != nil {
+		return wrapDBError("release lease", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// OrderEventStore reads the order event log and rewrites the orders projection from it.
+// Reads are scoped to the tenant of the context; projection rebuilds use model.WithAllTenants.
+type OrderEventStore interface {
+	ListOrderIDs(ctx context.Context, after string, limit int) ([]string, error)
+	GetEvents(ctx context.Context, id string) ([]*model.OrderEvent, error)
+	SaveProjection(ctx context.Context, order *model.Order) error
+}
+
+// GetEvents retrieves the events of an order in version order
+func (r *SQLOrderRepository) GetEvents(ctx context.Context, id string) ([]*model.OrderEvent, error) {
+	where, args := scopeToTenant(ctx, "order_id = ?", orderTenant("order_events"), []interface{}{id})
+	query := `
+		SELECT id, event_id, order_id, version, event_type, payload, occurred_at
+		FROM order_events
+		WHERE ` + where + `
+		ORDER BY version
+	`
+
+	events, err := r.queryOrderEvents(ctx, "get order events", query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return events, nil
+}
+
+// GetCustomerEventsAfter retrieves up to limit events of a customer's orders that were appended
+// to the log after the event with the given ID, in log order. It returns no events if the ID is
+// not in the log.
+func (r *SQLOrderRepository) GetCustomerEventsAfter(ctx context.Context, customerID, afterEventID string, limit int) ([]*model.OrderEvent, error) {
+	where, args := scopeToTenant(ctx,
+		"o.customer_id = ? AND e.id > (SELECT a.id FROM order_events a WHERE a.event_id = ?)",
+		"o.tenant_id", []interface{}{customerID, afterEventID})
+	query := `
+		SELECT e.id, e.event_id, e.order_id, e.version, e.event_type, e.payload, e.occurred_at
+		FROM order_events e
+		JOIN orders o ON o.id = e.order_id
+		WHERE ` + where + `
+		ORDER BY e.id
+		LIMIT ?
+	`
+
+	return r.queryOrderEvents(ctx, "get customer order events", query, append(args, limit)...)
+}
+
+// queryOrderEvents runs a query selecting the columns of order_events
+func (r *SQLOrderRepository) queryOrderEvents(ctx context.Context, op, query string, args ...interface{}) ([]*model.OrderEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(op, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	var events []*model.OrderEvent
+	for rows.Next() {
+		event := &model.OrderEvent{}
+		if err := rows.Scan(
+			&event.ID,
+			&event.EventID,
+			&event.OrderID,
+			&event.Version,
+			&event.EventType,
+			&event.Payload,
+			&event.OccurredAt,
+		); err != nil {
+			return nil, wrapDBError("scan order event", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate order events", err)
+	}
+	return events, nil
+}
+
+// ListOrderIDs retrieves up to limit IDs of orders that have events, in ID order, starting after the given ID
+func (r *SQLOrderRepository) ListOrderIDs(ctx context.Context, after string, limit int) ([]string, error) {
+	where, args := scopeToTenant(ctx, "order_id > ?", orderTenant("order_events"), []interface{}{after})
+	query := `
+		SELECT DISTINCT order_id
+		FROM order_events
+		WHERE ` + where + `
+		ORDER BY order_id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, wrapDBError("list order ids", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, wrapDBError("scan order id", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate order ids", err)
+	}
+
+	return ids, nil
+}
+
+// SaveProjection overwrites the orders row and line items of an order with the given state,
+// inserting them if they are missing
+func (r *SQLOrderRepository) SaveProjection(ctx context.Context, order *model.Order) error {
+	query := `
+		INSERT INTO orders (id, tenant_id, customer_id, product_id, quantity, total_amount_minor, currency, status, version, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		` + r.db.dialect.upsert("id", "tenant_id", "customer_id", "product_id", "quantity",
+		"total_amount_minor", "currency", "status", "version", "created_at", "updated_at")
+
+	return withTx(ctx, r.db, func(tx *Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			order.ID,
+			order.TenantID,
+			order.CustomerID,
+			order.ProductID,
+			order.Quantity,
+			order.TotalAmount.Amount,
+			order.TotalAmount.Currency,
+			order.Status,
+			order.Version,
+			order.CreatedAt,
+			order.UpdatedAt,
+		)
+		if err != nil {
+			return wrapDBError("save order projection", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM order_items WHERE order_id = ?`, order.ID); err != nil {
+			return wrapDBError("delete order items", err)
+		}
+
+		return insertOrderItems(ctx, tx, []*model.Order{order})
+	})
+}
+
+// insertOrderEvents appends events to the order event log using the caller's transaction.
+// A duplicate (order_id, version) means another writer appended first and is reported as ErrVersionConflict.
+func insertOrderEvents(ctx context.Context, tx *Tx, events []*model.OrderEvent) error {
+	rows := make([][]interface{}, 0, len(events))
+	for _, event := range events {
+		// JSON columns are written as text: lib/pq sends []byte arguments as bytea
+		rows = append(rows, []interface{}{event.EventID, event.OrderID, event.Version, event.EventType, string(event.Payload), event.OccurredAt})
+	}
+
+	err := insertRows(ctx, tx, "append order events",
+		`INSERT INTO order_events (event_id, order_id, version, event_type, payload, occurred_at) VALUES `,
+		rows)
+	if err != nil && isDuplicateEntry(err) {
+		return ErrVersionConflict
+	}
+	return err
+}
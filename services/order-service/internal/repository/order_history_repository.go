@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// GetHistory retrieves the audit trail of an order, oldest entry first
+func (r *SQLOrderRepository) GetHistory(ctx context.Context, id string) ([]*model.OrderHistoryEntry, error) {
+	where, args := scopeToTenant(ctx, "order_id = ?", orderTenant("order_history"), []interface{}{id})
+	query := `
+		SELECT id, order_id, previous_status, status, version, actor_type, actor_name, event_ids, occurred_at
+		FROM order_history
+		WHERE ` + where + `
+		ORDER BY version, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError("get order history", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	var entries []*model.OrderHistoryEntry
+	for rows.Next() {
+		entry := &model.OrderHistoryEntry{}
+		var previousStatus sql.NullString
+		var eventIDs []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.OrderID,
+			&previousStatus,
+			&entry.Status,
+			&entry.Version,
+			&entry.Actor.Type,
+			&entry.Actor.Name,
+			&eventIDs,
+			&entry.OccurredAt,
+		); err != nil {
+			return nil, wrapDBError("scan order history", err)
+		}
+		entry.PreviousStatus = previousStatus.String
+		if err := json.Unmarshal(eventIDs, &entry.EventIDs); err != nil {
+			return nil, fmt.Errorf("failed to decode event ids of order %s: %w", id, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate order history", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return entries, nil
+}
+
+// insertOrderHistory records audit entries using the caller's transaction
+func insertOrderHistory(ctx context.Context, tx *Tx, entries []*model.OrderHistoryEntry) error {
+	rows := make([][]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		eventIDs := entry.EventIDs
+		if eventIDs == nil {
+			eventIDs = []string{}
+		}
+		encoded, err := json.Marshal(eventIDs)
+		if err != nil {
+			return fmt.Errorf("failed to encode event ids: %w", err)
+		}
+
+		rows = append(rows, []interface{}{
+			entry.OrderID,
+			sql.NullString{String: entry.PreviousStatus, Valid: entry.PreviousStatus != ""},
+			entry.Status,
+			entry.Version,
+			entry.Actor.Type,
+			entry.Actor.Name,
+			string(encoded),
+			entry.OccurredAt,
+		})
+	}
+
+	return insertRows(ctx, tx, "record order history",
+		`INSERT INTO order_history (order_id, previous_status, status, version, actor_type, actor_name, event_ids, occurred_at) VALUES `,
+		rows)
+}
@@ -4,99 +4,230 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
-	"time"
+	"strings"
 
 	"github.com/andev0x/order-service/internal/model"
-	// MySQL driver for database/sql
-	_ "github.com/go-sql-driver/mysql"
 )
 
-// OrderRepository interface defines methods for order persistence
+var (
+	// ErrOrderNotFound is returned when no order exists with the requested ID
+	ErrOrderNotFound = model.NewError(model.ErrNotFound, "order not found")
+	// ErrVersionConflict is returned when an order was modified since the version the caller read
+	ErrVersionConflict = model.NewError(model.ErrConflict, "order version conflict")
+)
+
+// OrderRepository interface defines methods for order persistence.
+// The order_events log is the source of truth; the orders table is a projection of it
+// that Create and Update keep in step within the same transaction, together with the
+// order_history audit trail. Reads and updates only see orders of the tenant of their
+// context; orders are created in the tenant set on them.
 type OrderRepository interface {
-	Create(ctx context.Context, order *model.Order) error
+	Create(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error
+	CreateBatch(ctx context.Context, writes []*model.OrderWrite) error
 	GetByID(ctx context.Context, id string) (*model.Order, error)
-	List(ctx context.Context, limit, offset int) ([]*model.Order, error)
+	GetEvents(ctx context.Context, id string) ([]*model.OrderEvent, error)
+	GetCustomerEventsAfter(ctx context.Context, customerID, afterEventID string, limit int) ([]*model.OrderEvent, error)
+	GetHistory(ctx context.Context, id string) ([]*model.OrderHistoryEntry, error)
+	List(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, limit int) ([]*model.Order, error)
+	Stream(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, fn func(*model.Order) error) error
+	Update(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error
 }
 
-// MySQLOrderRepository implements OrderRepository using MySQL
-type MySQLOrderRepository struct {
-	db *sql.DB
+// SQLOrderRepository implements OrderRepository on any supported database
+type SQLOrderRepository struct {
+	db *DB
 }
 
-// NewMySQLOrderRepository creates a new MySQL order repository
-func NewMySQLOrderRepository(db *sql.DB) *MySQLOrderRepository {
-	return &MySQLOrderRepository{db: db}
+// NewSQLOrderRepository creates a new order repository
+func NewSQLOrderRepository(db *DB) *SQLOrderRepository {
+	return &SQLOrderRepository{db: db}
 }
 
-// Create inserts a new order into the database
-func (r *MySQLOrderRepository) Create(ctx context.Context, order *model.Order) error {
-	query := `
-		INSERT INTO orders (id, customer_id, product_id, quantity, total_amount, status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
+// Create appends the events of a new order to the log and inserts its projection, line items,
+// outbox messages and history entry in a single transaction
+func (r *SQLOrderRepository) Create(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error {
+	return r.CreateBatch(ctx, []*model.OrderWrite{{
+		Order:    order,
+		Events:   events,
+		Messages: messages,
+		History:  entry,
+	}})
+}
 
-	_, err := r.db.ExecContext(ctx, query,
-		order.ID,
-		order.CustomerID,
-		order.ProductID,
-		order.Quantity,
-		order.TotalAmount,
-		order.Status,
-		order.CreatedAt,
-		order.UpdatedAt,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to create order: %w", err)
+// CreateBatch inserts several new orders in a single transaction, using one multi-row
+// INSERT per table rather than one statement per order
+func (r *SQLOrderRepository) CreateBatch(ctx context.Context, writes []*model.OrderWrite) error {
+	if len(writes) == 0 {
+		return nil
 	}
 
-	return nil
+	orders := make([]*model.Order, 0, len(writes))
+	var events []*model.OrderEvent
+	var messages []*model.OutboxMessage
+	var entries []*model.OrderHistoryEntry
+	for _, write := range writes {
+		orders = append(orders, write.Order)
+		events = append(events, write.Events...)
+		messages = append(messages, write.Messages...)
+		if write.History != nil {
+			entries = append(entries, write.History)
+		}
+	}
+
+	return withTx(ctx, r.db, func(tx *Tx) error {
+		if err := insertOrders(ctx, tx, orders); err != nil {
+			return err
+		}
+		if err := insertOrderItems(ctx, tx, orders); err != nil {
+			return err
+		}
+		if err := insertOrderEvents(ctx, tx, events); err != nil {
+			return err
+		}
+		if err := insertOrderHistory(ctx, tx, entries); err != nil {
+			return err
+		}
+
+		return insertOutboxMessages(ctx, tx, messages)
+	})
 }
 
-// GetByID retrieves an order by its ID
-func (r *MySQLOrderRepository) GetByID(ctx context.Context, id string) (*model.Order, error) {
+// GetByID retrieves an order and its line items by the order ID
+func (r *SQLOrderRepository) GetByID(ctx context.Context, id string) (*model.Order, error) {
+	where, args := scopeToTenant(ctx, "id = ?", "tenant_id", []interface{}{id})
 	query := `
-		SELECT id, customer_id, product_id, quantity, total_amount, status, created_at, updated_at
+		SELECT id, tenant_id, customer_id, product_id, quantity, total_amount_minor, currency, status, version, created_at, updated_at
 		FROM orders
-		WHERE id = ?
-	`
+		WHERE ` + where
 
 	order := &model.Order{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&order.ID,
-		&order.CustomerID,
-		&order.ProductID,
-		&order.Quantity,
-		&order.TotalAmount,
-		&order.Status,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-	)
+	err := withReadTx(ctx, r.db, func(tx *Tx) error {
+		err := tx.QueryRowContext(ctx, query, args...).Scan(
+			&order.ID,
+			&order.TenantID,
+			&order.CustomerID,
+			&order.ProductID,
+			&order.Quantity,
+			&order.TotalAmount.Amount,
+			&order.TotalAmount.Currency,
+			&order.Status,
+			&order.Version,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		)
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("order not found")
-	}
+		if err == sql.ErrNoRows {
+			return ErrOrderNotFound
+		}
+
+		if err != nil {
+			return wrapDBError("get order", err)
+		}
 
+		return loadOrderItems(ctx, tx, []*model.Order{order})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order: %w", err)
+		return nil, err
 	}
 
 	return order, nil
 }
 
-// List retrieves a list of orders with pagination
-func (r *MySQLOrderRepository) List(ctx context.Context, limit, offset int) ([]*model.Order, error) {
+// List retrieves up to limit orders matching the filter with their line items, newest first.
+// Pages are keyset-paginated on (created_at, id): after is the cursor of the previous page's last
+// order, or nil for the first page. idx_tenant_created_at serves the tenant scope, the ordering and
+// the range seek; the customer, product and status indexes serve the equality filters.
+func (r *SQLOrderRepository) List(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, limit int) ([]*model.Order, error) {
+	where, args := orderFilterClause(ctx, filter, after)
 	query := `
-		SELECT id, customer_id, product_id, quantity, total_amount, status, created_at, updated_at
-		FROM orders
-		ORDER BY created_at DESC
-		LIMIT ? OFFSET ?
+		SELECT id, tenant_id, customer_id, product_id, quantity, total_amount_minor, currency, status, version, created_at, updated_at
+		FROM orders` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+	args = append(args, limit)
+
+	var orders []*model.Order
+	err := withReadTx(ctx, r.db, func(tx *Tx) error {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		if err != nil {
+			return wrapDBError("list orders", err)
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				log.Printf("Error closing rows: %v", err)
+			}
+		}()
+
+		for rows.Next() {
+			order := &model.Order{}
+			err := rows.Scan(
+				&order.ID,
+				&order.TenantID,
+				&order.CustomerID,
+				&order.ProductID,
+				&order.Quantity,
+				&order.TotalAmount.Amount,
+				&order.TotalAmount.Currency,
+				&order.Status,
+				&order.Version,
+				&order.CreatedAt,
+				&order.UpdatedAt,
+			)
+			if err != nil {
+				return wrapDBError("scan order", err)
+			}
+			orders = append(orders, order)
+		}
+		if err := rows.Err(); err != nil {
+			return wrapDBError("iterate orders", err)
+		}
+
+		return loadOrderItems(ctx, tx, orders)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Stream calls fn with every order matching the filter, oldest first, reading them from a single
+// database cursor so memory use does not grow with the number of orders. Line items are fetched
+// in the same query as a JSON array per order. after is an export cursor: only orders after it in
+// (created_at, id) order are streamed. Returning an error from fn stops the stream with that error.
+func (r *SQLOrderRepository) Stream(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, fn func(*model.Order) error) error {
+	where, args := orderFilterClause(ctx, filter, nil)
+	if after != nil {
+		if where == "" {
+			where = "\n\t\tWHERE "
+		} else {
+			where += " AND "
+		}
+		where += "(created_at > ? OR (created_at = ? AND id > ?))"
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+	d := r.db.dialect
+	query := `
+		SELECT id, tenant_id, customer_id, product_id, quantity, total_amount_minor, currency, status, version, created_at, updated_at,
+			(SELECT ` + d.jsonArrayAgg + `(` + d.jsonObject + `(
+				'product_id', i.product_id,
+				'quantity', i.quantity,
+				'unit_price', i.unit_price_minor,
+				'discount', i.discount_minor,
+				'line_total', i.line_total_minor))
+			FROM order_items i
+			WHERE i.order_id = orders.id)
+		FROM orders` + where + `
+		ORDER BY created_at, id
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list orders: %w", err)
+		return wrapDBError("stream orders", err)
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
@@ -104,50 +235,282 @@ func (r *MySQLOrderRepository) List(ctx context.Context, limit, offset int) ([]*
 		}
 	}()
 
-	var orders []*model.Order
 	for rows.Next() {
 		order := &model.Order{}
+		var items []byte
 		err := rows.Scan(
 			&order.ID,
+			&order.TenantID,
 			&order.CustomerID,
 			&order.ProductID,
 			&order.Quantity,
-			&order.TotalAmount,
+			&order.TotalAmount.Amount,
+			&order.TotalAmount.Currency,
 			&order.Status,
+			&order.Version,
 			&order.CreatedAt,
 			&order.UpdatedAt,
+			&items,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan order: %w", err)
+			return wrapDBError("scan order", err)
+		}
+		if order.Items, err = decodeOrderItems(items, order.TotalAmount.Currency); err != nil {
+			return fmt.Errorf("failed to decode items of order %s: %w", order.ID, err)
+		}
+
+		if err := fn(order); err != nil {
+			return err
 		}
-		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return wrapDBError("iterate orders", err)
 	}
 
-	return orders, nil
+	return nil
+}
+
+// decodeOrderItems decodes line items aggregated into a JSON array, whose amounts are minor
+// units in the currency of their order
+func decodeOrderItems(data []byte, currency string) ([]model.OrderItem, error) {
+	items := []model.OrderItem{}
+	if len(data) == 0 {
+		return items, nil
+	}
+
+	var rows []struct {
+		ProductID string `json:"product_id"`
+		Quantity  int    `json:"quantity"`
+		UnitPrice int64  `json:"unit_price"`
+		Discount  int64  `json:"discount"`
+		LineTotal int64  `json:"line_total"`
+	}
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		items = append(items, model.OrderItem{
+			ProductID: row.ProductID,
+			Quantity:  row.Quantity,
+			UnitPrice: model.NewMoney(row.UnitPrice, currency),
+			Discount:  model.NewMoney(row.Discount, currency),
+			LineTotal: model.NewMoney(row.LineTotal, currency),
+		})
+	}
+	return items, nil
 }
 
-// InitDB initializes the database connection
-func InitDB(host, port, user, password, dbname string) (*sql.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		user, password, host, port, dbname)
+// Update appends the events of an existing order to the log and persists its projection, outbox
+// messages and history entry in a single transaction. The update only applies if the stored version
+// still equals order.Version; on success order.Version is incremented, otherwise ErrVersionConflict
+// is returned.
+func (r *SQLOrderRepository) Update(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error {
+	where, args := scopeToTenant(ctx, "id = ? AND version = ?", "tenant_id",
+		[]interface{}{order.Status, order.UpdatedAt, order.ID, order.Version})
+	query := `
+		UPDATE orders
+		SET status = ?, updated_at = ?, version = version + 1
+		WHERE ` + where
+
+	err := withTx(ctx, r.db, func(tx *Tx) error {
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return wrapDBError("update order", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return wrapDBError("get affected rows", err)
+		}
+		if rows == 0 {
+			return missingOrConflict(ctx, tx, order.ID)
+		}
+		if err := insertOrderEvents(ctx, tx, events); err != nil {
+			return err
+		}
+		if entry != nil {
+			if err := insertOrderHistory(ctx, tx, []*model.OrderHistoryEntry{entry}); err != nil {
+				return err
+			}
+		}
+
+		return insertOutboxMessages(ctx, tx, messages)
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Version++
+	return nil
+}
+
+// orderFilterClause builds the WHERE clause and its arguments for a list query, scoped to the tenant of ctx
+func orderFilterClause(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+
+	if tenantID, scoped := model.TenantFromContext(ctx); scoped {
+		conds = append(conds, "tenant_id = ?")
+		args = append(args, tenantID)
+	}
+	if filter.CustomerID != "" {
+		conds = append(conds, "customer_id = ?")
+		args = append(args, filter.CustomerID)
+	}
+	if filter.ProductID != "" {
+		conds = append(conds, "product_id = ?")
+		args = append(args, filter.ProductID)
+	}
+	if filter.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.CreatedFrom != nil {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		conds = append(conds, "created_at < ?")
+		args = append(args, *filter.CreatedTo)
+	}
+	if filter.Currency != "" {
+		conds = append(conds, "currency = ?")
+		args = append(args, filter.Currency)
+	}
+	if filter.MinAmount != nil {
+		conds = append(conds, "total_amount_minor >= ?")
+		args = append(args, filter.MinAmount.Amount)
+	}
+	if filter.MaxAmount != nil {
+		conds = append(conds, "total_amount_minor <= ?")
+		args = append(args, filter.MaxAmount.Amount)
+	}
+	if after != nil {
+		conds = append(conds, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return "\n\t\tWHERE " + strings.Join(conds, " AND "), args
+}
+
+// scopeToTenant appends a condition restricting column to the tenant of ctx to the WHERE
+// condition where, unless ctx spans all tenants
+func scopeToTenant(ctx context.Context, where, column string, args []interface{}) (string, []interface{}) {
+	tenantID, scoped := model.TenantFromContext(ctx)
+	if !scoped {
+		return where, args
+	}
+	return where + " AND " + column + " = ?", append(args, tenantID)
+}
+
+// orderTenant is a column expression for the tenant of the order a row of table belongs to,
+// for tables keyed by order_id that do not store the tenant themselves
+func orderTenant(table string) string {
+	return "(SELECT o.tenant_id FROM orders o WHERE o.id = " + table + ".order_id)"
+}
 
-	db, err := sql.Open("mysql", dsn)
+// missingOrConflict explains why a conditional update matched no rows
+func missingOrConflict(ctx context.Context, tx *Tx, id string) error {
+	where, args := scopeToTenant(ctx, "id = ?", "tenant_id", []interface{}{id})
+	var exists int
+	err := tx.QueryRowContext(ctx, `SELECT 1 FROM orders WHERE `+where, args...).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrOrderNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return wrapDBError("check order", err)
+	}
+	return ErrVersionConflict
+}
+
+// insertOrders inserts the orders rows of new orders using the caller's transaction
+func insertOrders(ctx context.Context, tx *Tx, orders []*model.Order) error {
+	rows := make([][]interface{}, 0, len(orders))
+	for _, order := range orders {
+		rows = append(rows, []interface{}{
+			order.ID,
+			order.TenantID,
+			order.CustomerID,
+			order.ProductID,
+			order.Quantity,
+			order.TotalAmount.Amount,
+			order.TotalAmount.Currency,
+			order.Status,
+			order.Version,
+			order.CreatedAt,
+			order.UpdatedAt,
+		})
+	}
+
+	return insertRows(ctx, tx, "create order",
+		`INSERT INTO orders (id, tenant_id, customer_id, product_id, quantity, total_amount_minor, currency, status, version, created_at, updated_at) VALUES `,
+		rows)
+}
+
+// insertOrderItems inserts the line items of the given orders using the caller's transaction
+func insertOrderItems(ctx context.Context, tx *Tx, orders []*model.Order) error {
+	var rows [][]interface{}
+	for _, order := range orders {
+		for _, item := range order.Items {
+			rows = append(rows, []interface{}{order.ID, item.ProductID, item.Quantity,
+				item.UnitPrice.Amount, item.Discount.Amount, item.LineTotal.Amount})
+		}
+	}
+
+	return insertRows(ctx, tx, "insert order items",
+		`INSERT INTO order_items (order_id, product_id, quantity, unit_price_minor, discount_minor, line_total_minor) VALUES `,
+		rows)
+}
+
+// loadOrderItems fills in the line items of the given orders with a single query
+func loadOrderItems(ctx context.Context, tx *Tx, orders []*model.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.Order, len(orders))
+	args := make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		order.Items = []model.OrderItem{}
+		byID[order.ID] = order
+		args = append(args, order.ID)
 	}
 
-	// Configure connection pool
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	query := `
+		SELECT order_id, product_id, quantity, unit_price_minor, discount_minor, line_total_minor
+		FROM order_items
+		WHERE order_id IN (` + placeholders(len(orders)) + `)
+		ORDER BY id
+	`
 
-	// Ping to verify connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return wrapDBError("load order items", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
 
-	if err := db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	for rows.Next() {
+		var orderID string
+		var item model.OrderItem
+		if err := rows.Scan(&orderID, &item.ProductID, &item.Quantity,
+			&item.UnitPrice.Amount, &item.Discount.Amount, &item.LineTotal.Amount); err != nil {
+			return wrapDBError("scan order item", err)
+		}
+		if order, ok := byID[orderID]; ok {
+			// Items are stored in the currency of their order
+			currency := order.TotalAmount.Currency
+			item.UnitPrice.Currency, item.Discount.Currency, item.LineTotal.Currency = currency, currency, currency
+			order.Items = append(order.Items, item)
+		}
 	}
 
-	return db, nil
+	return rows.Err()
 }
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// OutboxRepository interface defines methods used by the outbox relay
+type OutboxRepository interface {
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*model.OutboxMessage, error)
+	MarkSent(ctx context.Context, ids []int64) error
+	MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, cause error) error
+	Backlog(ctx context.Context) (*model.OutboxBacklog, error)
+	DeleteSent(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+// SQLOutboxRepository implements OutboxRepository on any supported database
+type SQLOutboxRepository struct {
+	db *DB
+}
+
+// NewSQLOutboxRepository creates a new outbox repository
+func NewSQLOutboxRepository(db *DB) *SQLOutboxRepository {
+	return &SQLOutboxRepository{db: db}
+}
+
+// ClaimPending locks up to limit due messages and pushes their next attempt past the lease,
+// so that other relay instances skip them while they are being published
+func (r *SQLOutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*model.OutboxMessage, error) {
+	selectQuery := `
+		SELECT id, event_id, aggregate_id, event_type, routing_key, payload, attempts, created_at
+		FROM outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?
+		` + r.db.dialect.lockRows()
+
+	var messages []*model.OutboxMessage
+	now := time.Now()
+
+	err := withTx(ctx, r.db, func(tx *Tx) error {
+		rows, err := tx.QueryContext(ctx, selectQuery, model.OutboxStatusPending, now, limit)
+		if err != nil {
+			return wrapDBError("query outbox", err)
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				log.Printf("Error closing rows: %v", err)
+			}
+		}()
+
+		for rows.Next() {
+			msg := &model.OutboxMessage{}
+			if err := rows.Scan(
+				&msg.ID,
+				&msg.EventID,
+				&msg.AggregateID,
+				&msg.EventType,
+				&msg.RoutingKey,
+				&msg.Payload,
+				&msg.Attempts,
+				&msg.CreatedAt,
+			); err != nil {
+				return wrapDBError("scan outbox message", err)
+			}
+			messages = append(messages, msg)
+		}
+		if err := rows.Err(); err != nil {
+			return wrapDBError("iterate outbox", err)
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]interface{}, 0, len(messages)+1)
+		ids = append(ids, now.Add(lease))
+		for _, msg := range messages {
+			ids = append(ids, msg.ID)
+		}
+
+		updateQuery := `UPDATE outbox SET next_attempt_at = ? WHERE id IN (` + placeholders(len(messages)) + `)`
+		if _, err := tx.ExecContext(ctx, updateQuery, ids...); err != nil {
+			return wrapDBError("lease outbox messages", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// MarkSent marks outbox messages as published
+func (r *SQLOutboxRepository) MarkSent(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE outbox
+		SET status = ?, sent_at = ?, attempts = attempts + 1, last_error = NULL
+		WHERE id IN (` + placeholders(len(ids)) + `)
+	`
+
+	args := make([]interface{}, 0, len(ids)+2)
+	args = append(args, model.OutboxStatusSent, time.Now())
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return wrapDBError("mark outbox messages sent", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed publish attempt and schedules the next one
+func (r *SQLOutboxRepository) MarkFailed(ctx context.Context, id int64, nextAttemptAt time.Time, cause error) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, cause.Error(), nextAttemptAt, id); err != nil {
+		return wrapDBError("mark outbox message failed", err)
+	}
+
+	return nil
+}
+
+// Backlog returns the number of unpublished messages and the age of the oldest one
+func (r *SQLOutboxRepository) Backlog(ctx context.Context) (*model.OutboxBacklog, error) {
+	query := `
+		SELECT COUNT(*), MIN(created_at)
+		FROM outbox
+		WHERE status = ?
+	`
+
+	backlog := &model.OutboxBacklog{}
+	var oldest nullTime
+	if err := r.db.QueryRowContext(ctx, query, model.OutboxStatusPending).Scan(&backlog.Pending, &oldest); err != nil {
+		return nil, wrapDBError("get outbox backlog", err)
+	}
+	if oldest.Valid {
+		backlog.OldestCreatedAt = oldest.Time
+	}
+
+	return backlog, nil
+}
+
+// DeleteSent deletes the messages published before sentBefore and returns how many were deleted
+func (r *SQLOutboxRepository) DeleteSent(ctx context.Context, sentBefore time.Time) (int64, error) {
+	query := `DELETE FROM outbox WHERE status = ? AND sent_at < ?`
+
+	result, err := r.db.ExecContext(ctx, query, model.OutboxStatusSent, sentBefore)
+	if err != nil {
+		return 0, wrapDBError("delete sent outbox messages", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, wrapDBError("delete sent outbox messages", err)
+	}
+	return deleted, nil
+}
+
+// insertOutboxMessages writes outbox messages using the caller's transaction
+func insertOutboxMessages(ctx context.Context, tx *Tx, messages []*model.OutboxMessage) error {
+	rows := make([][]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		rows = append(rows, []interface{}{
+			msg.EventID,
+			msg.AggregateID,
+			msg.EventType,
+			msg.RoutingKey,
+			string(msg.Payload),
+			model.OutboxStatusPending,
+			msg.CreatedAt,
+			msg.CreatedAt,
+		})
+	}
+
+	return insertRows(ctx, tx, "insert outbox messages",
+		`INSERT INTO outbox (event_id, aggregate_id, event_type, routing_key, payload, status, next_attempt_at, created_at) VALUES `,
+		rows)
+}
+
+// placeholders returns a comma separated list of n query placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Error codes of unique key violations
+const (
+	mysqlErrDuplicateEntry = 1062
+	pqErrUniqueViolation   = "23505"
+)
+
+var (
+	// ErrProductNotFound is returned when no product exists with the requested ID
+	ErrProductNotFound = model.NewError(model.ErrNotFound, "product not found")
+	// ErrProductExists is returned when creating a product whose ID is already taken
+	ErrProductExists = model.NewError(model.ErrConflict, "product already exists")
+)
+
+// ProductRepository interface defines methods for price table persistence
+type ProductRepository interface {
+	Create(ctx context.Context, product *model.Product) error
+	GetByID(ctx context.Context, id string) (*model.Product, error)
+	GetByIDs(ctx context.Context, ids []string) (map[string]*model.Product, error)
+	List(ctx context.Context) ([]*model.Product, error)
+	Update(ctx context.Context, product *model.Product) error
+	Delete(ctx context.Context, id string) error
+}
+
+// SQLProductRepository implements ProductRepository on any supported database
+type SQLProductRepository struct {
+	db *DB
+}
+
+// NewSQLProductRepository creates a new product repository
+func NewSQLProductRepository(db *DB) *SQLProductRepository {
+	return &SQLProductRepository{db: db}
+}
+
+// Create inserts a new product into the price table
+func (r *SQLProductRepository) Create(ctx context.Context, product *model.Product) error {
+	query := `
+		INSERT INTO products (id, name, unit_price_minor, currency, discount_percent, active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		product.ID,
+		product.Name,
+		product.UnitPrice.Amount,
+		product.UnitPrice.Currency,
+		product.DiscountPercent,
+		product.Active,
+		product.CreatedAt,
+		product.UpdatedAt,
+	)
+	if isDuplicateEntry(err) {
+		return ErrProductExists
+	}
+	if err != nil {
+		return wrapDBError("create product", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a product by its ID
+func (r *SQLProductRepository) GetByID(ctx context.Context, id string) (*model.Product, error) {
+	query := `
+		SELECT id, name, unit_price_minor, currency, discount_percent, active, created_at, updated_at
+		FROM products
+		WHERE id = ?
+	`
+
+	product, err := scanProduct(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError("get product", err)
+	}
+
+	return product, nil
+}
+
+// GetByIDs retrieves the products with the given IDs, keyed by ID.
+// IDs with no matching product are absent from the result.
+func (r *SQLProductRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*model.Product, error) {
+	products := make(map[string]*model.Product, len(ids))
+	if len(ids) == 0 {
+		return products, nil
+	}
+
+	query := `
+		SELECT id, name, unit_price_minor, currency, discount_percent, active, created_at, updated_at
+		FROM products
+		WHERE id IN (` + placeholders(len(ids)) + `)
+	`
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError("get products", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, wrapDBError("scan product", err)
+		}
+		products[product.ID] = product
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate products", err)
+	}
+
+	return products, nil
+}
+
+// List retrieves all products ordered by ID
+func (r *SQLProductRepository) List(ctx context.Context) ([]*model.Product, error) {
+	query := `
+		SELECT id, name, unit_price_minor, currency, discount_percent, active, created_at, updated_at
+		FROM products
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, wrapDBError("list products", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	products := []*model.Product{}
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, wrapDBError("scan product", err)
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate products", err)
+	}
+
+	return products, nil
+}
+
+// Update replaces the name, price, discount and active flag of a product
+func (r *SQLProductRepository) Update(ctx context.Context, product *model.Product) error {
+	query := `
+		UPDATE products
+		SET name = ?, unit_price_minor = ?, currency = ?, discount_percent = ?, active = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		product.Name,
+		product.UnitPrice.Amount,
+		product.UnitPrice.Currency,
+		product.DiscountPercent,
+		product.Active,
+		product.UpdatedAt,
+		product.ID,
+	)
+	if err != nil {
+		return wrapDBError("update product", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return wrapDBError("get affected rows", err)
+	}
+	if rows == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a product from the price table
+func (r *SQLProductRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM products WHERE id = ?`, id)
+	if err != nil {
+		return wrapDBError("delete product", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return wrapDBError("get affected rows", err)
+	}
+	if rows == 0 {
+		return ErrProductNotFound
+	}
+
+	return nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct scans a product row
+func scanProduct(row rowScanner) (*model.Product, error) {
+	product := &model.Product{}
+	err := row.Scan(
+		&product.ID,
+		&product.Name,
+		&product.UnitPrice.Amount,
+		&product.UnitPrice.Currency,
+		&product.DiscountPercent,
+		&product.Active,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// isDuplicateEntry reports whether err is a unique key violation
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	var sqliteErr *sqlite.Error
+	return (errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry) ||
+		(errors.As(err, &pqErr) && pqErr.Code == pqErrUniqueViolation) ||
+		(errors.As(err, &sqliteErr) && (sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE ||
+			sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY))
+}
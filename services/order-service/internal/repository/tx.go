@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+)
+
+// withTx runs fn inside a database transaction, committing on success and rolling back on error
+func withTx(ctx context.Context, db *DB, fn func(tx *Tx) error) error {
+	return runTx(ctx, db, nil, fn)
+}
+
+// withReadTx is withTx for fn that only read. SQLite begins read-only transactions without the
+// write lock, so they run alongside writers and other readers.
+func withReadTx(ctx context.Context, db *DB, fn func(tx *Tx) error) error {
+	return runTx(ctx, db, &sql.TxOptions{ReadOnly: true}, fn)
+}
+
+// runTx runs fn inside a database transaction started with opts
+func runTx(ctx context.Context, db *DB, opts *sql.TxOptions, fn func(tx *Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return wrapDBError("begin transaction", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("Error rolling back transaction: %v", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return wrapDBError("commit transaction", err)
+	}
+
+	return nil
+}
+
+// insertRows executes a multi-row INSERT of rows using the caller's transaction. prefix is the
+// statement up to and including VALUES; rows are split across statements when needed to stay
+// under the placeholder limit of the database.
+func insertRows(ctx context.Context, tx *Tx, op, prefix string, rows [][]interface{}) error {
+	return execInsert(ctx, tx, op, prefix, "", rows)
+}
+
+// insertRowsSkippingDuplicates is insertRows for rows that may already exist: rows conflicting
+// with a unique key are left out instead of failing the statement
+func insertRowsSkippingDuplicates(ctx context.Context, tx *Tx, op, prefix string, rows [][]interface{}) error {
+	return execInsert(ctx, tx, op, prefix, tx.dialect.skipDuplicates, rows)
+}
+
+// execInsert executes the multi-row INSERTs of insertRows, ending each statement with suffix
+func execInsert(ctx context.Context, tx *Tx, op, prefix, suffix string, rows [][]interface{}) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	columns := len(rows[0])
+	rowPlaceholders := "(" + placeholders(columns) + "),"
+	chunkSize := tx.dialect.maxPlaceholders / columns
+
+	for start := 0; start < len(rows); start += chunkSize {
+		end := start + chunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		query := prefix + strings.TrimSuffix(strings.Repeat(rowPlaceholders, end-start), ",") + suffix
+		args := make([]interface{}, 0, (end-start)*columns)
+		for _, row := range rows[start:end] {
+			args = append(args, row...)
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return wrapDBError(op, err)
+		}
+	}
+
+	return nil
+}
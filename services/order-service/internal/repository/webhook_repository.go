@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+var (
+	// ErrWebhookNotFound is returned when no webhook subscription exists with the requested ID
+	ErrWebhookNotFound = model.NewError(model.ErrNotFound, "webhook subscription not found")
+	// ErrWebhookDeliveryNotFound is returned when a subscription has no delivery with the requested ID
+	ErrWebhookDeliveryNotFound = model.NewError(model.ErrNotFound, "webhook delivery not found")
+)
+
+// WebhookRepository interface defines methods for managing webhook subscriptions and reading
+// their delivery log. Every query is scoped to the tenant of its context.
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, subscriptionID, id string, at time.Time) (*model.WebhookDelivery, error)
+}
+
+// WebhookDeliveryStore interface defines the methods used to fan events out to subscriptions
+// and send the resulting deliveries. It works across tenants.
+type WebhookDeliveryStore interface {
+	MatchingSubscriptions(ctx context.Context, tenantID, eventType string) ([]*model.WebhookSubscription, error)
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDispatch, error)
+	RecordAttempt(ctx context.Context, id string, attempt *model.WebhookAttempt, status string, nextAttemptAt time.Time) error
+}
+
+// SQLWebhookRepository implements WebhookRepository and WebhookDeliveryStore on any supported database
+type SQLWebhookRepository struct {
+	db *DB
+}
+
+// NewSQLWebhookRepository creates a new webhook repository
+func NewSQLWebhookRepository(db *DB) *SQLWebhookRepository {
+	return &SQLWebhookRepository{db: db}
+}
+
+const (
+	webhookSubscriptionColumns = `id, tenant_id, url, event_types, secret, active, created_at, updated_at`
+	webhookDeliveryColumns     = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+		last_attempt_at, last_status_code, last_error, created_at, delivered_at`
+
+	// deliveryTenant is the tenant of a webhook_deliveries row, which is stored on its subscription
+	deliveryTenant = "(SELECT s.tenant_id FROM webhook_subscriptions s WHERE s.id = webhook_deliveries.subscription_id)"
+)
+
+// CreateSubscription inserts a new webhook subscription
+func (r *SQLWebhookRepository) CreateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return fmt.Errorf("failed to encode event types: %w", err)
+	}
+
+	query := `INSERT INTO webhook_subscriptions (` + webhookSubscriptionColumns + `) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.ExecContext(ctx, query,
+		sub.ID,
+		sub.TenantID,
+		sub.URL,
+		string(eventTypes),
+		sub.Secret,
+		sub.Active,
+		sub.CreatedAt,
+		sub.UpdatedAt,
+	)
+	if err != nil {
+		return wrapDBError("create webhook subscription", err)
+	}
+	return nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (r *SQLWebhookRepository) GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	where, args := scopeToTenant(ctx, "id = ?", "tenant_id", []interface{}{id})
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE ` + where
+
+	sub, err := scanWebhookSubscription(r.db.QueryRowContext(ctx, query, args...))
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, wrapDBError("get webhook subscription", err)
+	}
+	return sub, nil
+}
+
+// ListSubscriptions retrieves every webhook subscription, newest first
+func (r *SQLWebhookRepository) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	where, args := scopeToTenant(ctx, "TRUE", "tenant_id", nil)
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE ` + where + ` ORDER BY created_at DESC, id`
+
+	return r.querySubscriptions(ctx, "list webhook subscriptions", query, args...)
+}
+
+// UpdateSubscription overwrites the URL, event types, secret and active flag of a subscription
+func (r *SQLWebhookRepository) UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return fmt.Errorf("failed to encode event types: %w", err)
+	}
+
+	where, args := scopeToTenant(ctx, "id = ?", "tenant_id",
+		[]interface{}{sub.URL, string(eventTypes), sub.Secret, sub.Active, sub.UpdatedAt, sub.ID})
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = ?, event_types = ?, secret = ?, active = ?, updated_at = ?
+		WHERE ` + where
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return wrapDBError("update webhook subscription", err)
+	}
+	return checkWebhookAffected(result)
+}
+
+// DeleteSubscription removes a subscription together with its delivery log
+func (r *SQLWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	where, args := scopeToTenant(ctx, "id = ?", "tenant_id", []interface{}{id})
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE `+where, args...)
+	if err != nil {
+		return wrapDBError("delete webhook subscription", err)
+	}
+	return checkWebhookAffected(result)
+}
+
+// ListDeliveries retrieves up to limit deliveries of a subscription, newest first, optionally
+// only those with the given status
+func (r *SQLWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*model.WebhookDelivery, error) {
+	where, args := "subscription_id = ?", []interface{}{subscriptionID}
+	if status != "" {
+		where, args = where+" AND status = ?", append(args, status)
+	}
+	where, args = scopeToTenant(ctx, where, deliveryTenant, args)
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, wrapDBError("list webhook deliveries", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	deliveries := []*model.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, wrapDBError("scan webhook delivery", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError("iterate webhook deliveries", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver makes a delivery pending again with a fresh set of attempts, the first at the given
+// time, whatever its current status, and returns it
+func (r *SQLWebhookRepository) Redeliver(ctx context.Context, subscriptionID, id string, at time.Time) (*model.WebhookDelivery, error) {
+	where, args := scopeToTenant(ctx, "id = ? AND subscription_id = ?", deliveryTenant, []interface{}{id, subscriptionID})
+
+	var delivery *model.WebhookDelivery
+	err := withTx(ctx, r.db, func(tx *Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = ? WHERE `+where,
+			append([]interface{}{model.WebhookDeliveryPending, at}, args...)...)
+		if err != nil {
+			return wrapDBError("redeliver webhook delivery", err)
+		}
+
+		delivery, err = scanWebhookDelivery(tx.QueryRowContext(ctx,
+			`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE `+where, args...))
+		if err == sql.ErrNoRows {
+			return ErrWebhookDeliveryNotFound
+		}
+		if err != nil {
+			return wrapDBError("get webhook delivery", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// MatchingSubscriptions retrieves the active subscriptions of a tenant that receive events with
+// the given routing key
+func (r *SQLWebhookRepository) MatchingSubscriptions(ctx context.Context, tenantID, eventType string) ([]*model.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE tenant_id = ? AND active = TRUE`
+
+	subs, err := r.querySubscriptions(ctx, "find webhook subscriptions", query, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := subs[:0]
+	for _, sub := range subs {
+		if sub.Matches(eventType) {
+			matching = append(matching, sub)
+		}
+	}
+	return matching, nil
+}
+
+// CreateDeliveries inserts pending deliveries. A delivery of an event a subscription already has
+// is skipped, so redelivered broker messages do not send an event twice.
+func (r *SQLWebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	rows := make([][]interface{}, 0, len(deliveries))
+	for _, d := range deliveries {
+		rows = append(rows, []interface{}{d.ID, d.SubscriptionID, d.EventID, d.EventType, string(d.Payload),
+			model.WebhookDeliveryPending, d.CreatedAt, d.CreatedAt})
+	}
+
+	return withTx(ctx, r.db, func(tx *Tx) error {
+		return insertRowsSkippingDuplicates(ctx, tx, "create webhook deliveries",
+			`INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, next_attempt_at, created_at) VALUES `,
+			rows)
+	})
+}
+
+// ClaimDue locks up to limit due deliveries of active subscriptions and pushes their next attempt
+// past the lease, so that other dispatchers skip them while they are being sent
+func (r *SQLWebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDispatch, error) {
+	selectQuery := `
+		SELECT d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+			d.last_attempt_at, d.last_status_code, d.last_error, d.created_at, d.delivered_at, s.url, s.secret
+		FROM webhook_deliveries d
+		JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		WHERE d.status = ? AND d.next_attempt_at <= ? AND s.active = TRUE
+		ORDER BY d.next_attempt_at
+		LIMIT ?
+		` + r.db.dialect.lockRows("d")
+
+	var dispatches []*model.WebhookDispatch
+	now := time.Now()
+
+	err := withTx(ctx, r.db, func(tx *Tx) error {
+		rows, err := tx.QueryContext(ctx, selectQuery, model.WebhookDeliveryPending, now, limit)
+		if err != nil {
+			return wrapDBError("query webhook deliveries", err)
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				log.Printf("Error closing rows: %v", err)
+			}
+		}()
+
+		for rows.Next() {
+			dispatch := &model.WebhookDispatch{}
+			dispatch.WebhookDelivery, err = scanWebhookDelivery(rows, &dispatch.URL, &dispatch.Secret)
+			if err != nil {
+				return wrapDBError("scan webhook delivery", err)
+			}
+			dispatches = append(dispatches, dispatch)
+		}
+		if err := rows.Err(); err != nil {
+			return wrapDBError("iterate webhook deliveries", err)
+		}
+
+		if len(dispatches) == 0 {
+			return nil
+		}
+
+		args := make([]interface{}, 0, len(dispatches)+1)
+		args = append(args, now.Add(lease))
+		for _, dispatch := range dispatches {
+			args = append(args, dispatch.ID)
+		}
+
+		updateQuery := `UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id IN (` + placeholders(len(dispatches)) + `)`
+		if _, err := tx.ExecContext(ctx, updateQuery, args...); err != nil {
+			return wrapDBError("lease webhook deliveries", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dispatches, nil
+}
+
+// RecordAttempt records the outcome of sending a delivery and moves it to status.
+// Pending deliveries are attempted again at nextAttemptAt.
+func (r *SQLWebhookRepository) RecordAttempt(ctx context.Context, id string, attempt *model.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, next_attempt_at = ?, last_attempt_at = ?,
+			last_status_code = ?, last_error = ?, delivered_at = COALESCE(?, delivered_at)
+		WHERE id = ?
+	`
+
+	var lastError sql.NullString
+	if attempt.Err != nil {
+		lastError = sql.NullString{String: attempt.Err.Error(), Valid: true}
+	}
+	deliveredAt := sql.NullTime{Time: attempt.At, Valid: status == model.WebhookDeliveryDelivered}
+
+	_, err := r.db.ExecContext(ctx, query,
+		status,
+		nextAttemptAt,
+		attempt.At,
+		sql.NullInt64{Int64: int64(attempt.StatusCode), Valid: attempt.StatusCode != 0},
+		lastError,
+		deliveredAt,
+		id,
+	)
+	if err != nil {
+		return wrapDBError("record webhook attempt", err)
+	}
+	return nil
+}
+
+// querySubscriptions runs a query selecting webhookSubscriptionColumns
+func (r *SQLWebhookRepository) querySubscriptions(ctx context.Context, op, query string, args ...interface{}) ([]*model.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, wrapDBError(op, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	subs := []*model.WebhookSubscription{}
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, wrapDBError("scan webhook subscription", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, wrapDBError(op, err)
+	}
+	return subs, nil
+}
+
+// checkWebhookAffected returns ErrWebhookNotFound if a statement changed no subscription
+func checkWebhookAffected(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return wrapDBError("get affected rows", err)
+	}
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// scanWebhookSubscription scans a subscription row selected with webhookSubscriptionColumns
+func scanWebhookSubscription(row rowScanner) (*model.WebhookSubscription, error) {
+	sub := &model.WebhookSubscription{}
+	var eventTypes []byte
+	err := row.Scan(
+		&sub.ID,
+		&sub.TenantID,
+		&sub.URL,
+		&eventTypes,
+		&sub.Secret,
+		&sub.Active,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(eventTypes, &sub.EventTypes); err != nil {
+		return nil, fmt.Errorf("failed to decode event types of webhook %s: %w", sub.ID, err)
+	}
+	return sub, nil
+}
+
+// scanWebhookDelivery scans a delivery row selected with webhookDeliveryColumns, followed by
+// any extra columns
+func scanWebhookDelivery(row rowScanner, extra ...interface{}) (*model.WebhookDelivery, error) {
+	d := &model.WebhookDelivery{}
+	var payload []byte
+	var nextAttemptAt time.Time
+	var lastAttemptAt, deliveredAt sql.NullTime
+	var lastStatusCode sql.NullInt64
+	var lastError sql.NullString
+	dest := append([]interface{}{
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&nextAttemptAt,
+		&lastAttemptAt,
+		&lastStatusCode,
+		&lastError,
+		&d.CreatedAt,
+		&deliveredAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	d.Payload = payload
+	if d.Status == model.WebhookDeliveryPending {
+		d.NextAttemptAt = &nextAttemptAt
+	}
+	if lastAttemptAt.Valid {
+		d.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	d.LastStatusCode = int(lastStatusCode.Int64)
+	d.LastError = lastError.String
+	return d, nil
+}
@@ -3,104 +3,335 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/andev0x/order-service/internal/cache"
 	"github.com/andev0x/order-service/internal/model"
-	"github.com/andev0x/order-service/internal/mq"
+	"github.com/andev0x/order-service/internal/pricing"
 	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/validation"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
 )
 
-// OrderService handles business logic for orders
+var (
+	cacheLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "order_cache_lookups_total",
+		Help: "Total number of order cache lookups by result (hit, negative_hit, miss)",
+	}, []string{"result"})
+	cacheCoalescedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "order_cache_coalesced_total",
+		Help: "Total number of order cache misses served by a database read already in flight",
+	})
+)
+
+// OrderService handles business logic for orders.
+//
+// Events are not published directly: they are written to the outbox in the same
+// transaction as the order and relayed to the message broker by outbox.Relay.
 type OrderService struct {
-	repo      repository.OrderRepository
-	cache     cache.OrderCache
-	publisher mq.EventPublisher
+	repo         repository.OrderRepository
+	cache        cache.OrderCache
+	pricing      *pricing.Calculator
+	maxBatchSize int
+	loads        singleflight.Group
 }
 
+// DefaultMaxBatchSize is the default number of orders accepted by CreateOrders
+const DefaultMaxBatchSize = 500
+
+// orderLoadTimeout bounds a database read shared by concurrent cache misses, which no single
+// caller can cancel
+const orderLoadTimeout = 5 * time.Second
+
+// MaxStreamReplay is the most status updates replayed to a stream client resuming after an event
+const MaxStreamReplay = 1000
+
+// ErrBatchAborted is the result of a valid order that was not created because another order
+// in an all-or-nothing batch failed
+var ErrBatchAborted = errors.New("order not created because another order in the batch failed")
+
 // NewOrderService creates a new order service
-func NewOrderService(repo repository.OrderRepository, cache cache.OrderCache, publisher mq.EventPublisher) *OrderService {
+func NewOrderService(repo repository.OrderRepository, cache cache.OrderCache, pricing *pricing.Calculator) *OrderService {
 	return &OrderService{
-		repo:      repo,
-		cache:     cache,
-		publisher: publisher,
+		repo:         repo,
+		cache:        cache,
+		pricing:      pricing,
+		maxBatchSize: DefaultMaxBatchSize,
+	}
+}
+
+// SetMaxBatchSize sets the number of orders accepted by CreateOrders
+func (s *OrderService) SetMaxBatchSize(n int) {
+	if n > 0 {
+		s.maxBatchSize = n
 	}
 }
 
 // CreateOrder creates a new order
 func (s *OrderService) CreateOrder(ctx context.Context, req *model.CreateOrderRequest) (*model.Order, error) {
-	// Validate request
-	if req.CustomerID == "" || req.ProductID == "" {
-		return nil, fmt.Errorf("customer_id and product_id are required")
+	write, err := s.newOrder(ctx, req)
+	if err != nil {
+		return nil, err
 	}
-	if req.Quantity <= 0 {
-		return nil, fmt.Errorf("quantity must be greater than 0")
+	order := write.Order
+
+	// Persist order, event and history atomically
+	if err := s.repo.Create(ctx, order, write.Events, write.Messages, write.History); err != nil {
+		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
-	if req.TotalAmount <= 0 {
-		return nil, fmt.Errorf("total_amount must be greater than 0")
+
+	// Cache the order
+	if err := s.cache.Set(ctx, order); err != nil {
+		log.Printf("Warning: failed to cache order %s: %v", order.ID, err)
 	}
 
-	// Create order entity
+	log.Printf("Order created successfully: %s", order.ID)
+	return order, nil
+}
+
+// CreateOrders creates a batch of orders, returning one result per request in request order.
+// Each request is validated and priced on its own. By default the valid orders are created even
+// if others fail; when atomic is set a single failure aborts the batch and every other order
+// fails with ErrBatchAborted. The orders are inserted in one transaction, so a database error
+// fails the whole batch and is returned as err.
+func (s *OrderService) CreateOrders(ctx context.Context, reqs []model.CreateOrderRequest, atomic bool) ([]model.OrderResult, error) {
+	if len(reqs) == 0 {
+		return nil, model.InvalidField("orders", "must contain at least one order")
+	}
+	if len(reqs) > s.maxBatchSize {
+		return nil, model.InvalidField("orders", fmt.Sprintf("must contain at most %d orders", s.maxBatchSize))
+	}
+
+	results := make([]model.OrderResult, len(reqs))
+	writes := make([]*model.OrderWrite, 0, len(reqs))
+	// indexes holds the request index of each write
+	indexes := make([]int, 0, len(reqs))
+	for i := range reqs {
+		write, err := s.newOrder(ctx, &reqs[i])
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Order = write.Order
+		writes = append(writes, write)
+		indexes = append(indexes, i)
+	}
+
+	if atomic && len(writes) < len(reqs) {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i] = model.OrderResult{Err: ErrBatchAborted}
+			}
+		}
+		return results, nil
+	}
+
+	// Orders are not cached here; they are cached on first read
+	if err := s.repo.CreateBatch(ctx, writes); err != nil {
+		if atomic {
+			return nil, fmt.Errorf("failed to create orders: %w", err)
+		}
+
+		// The batch insert is all or nothing, so each order is inserted on its own to fail
+		// only the orders the database rejects
+		log.Printf("Error creating batch of %d orders, creating them one at a time: %v", len(writes), err)
+		for j, write := range writes {
+			if err := s.repo.Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+				results[indexes[j]] = model.OrderResult{Err: fmt.Errorf("failed to create order: %w", err)}
+			}
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	log.Printf("Batch of %d orders created (%d failed)", len(reqs)-failed, failed)
+	return results, nil
+}
+
+// newOrder validates and prices a create order request and builds the order together with
+// its event log entry, outbox message and history entry
+func (s *OrderService) newOrder(ctx context.Context, req *model.CreateOrderRequest) (*model.OrderWrite, error) {
+	reqItems, err := requestItems(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prices always come from the price table, never from the client
+	quote, err := s.pricing.Quote(ctx, reqItems)
+	if err != nil {
+		return nil, err
+	}
+	if err := quote.CheckTotal(req.TotalAmount); err != nil {
+		return nil, err
+	}
+
+	quantity := 0
+	for _, item := range quote.Items {
+		quantity += item.Quantity
+	}
+
+	// Create order entity in the tenant of the request
+	tenantID, _ := model.TenantFromContext(ctx)
+	now := time.Now()
 	order := &model.Order{
 		ID:          uuid.New().String(),
+		TenantID:    tenantID,
 		CustomerID:  req.CustomerID,
-		ProductID:   req.ProductID,
-		Quantity:    req.Quantity,
-		TotalAmount: req.TotalAmount,
+		ProductID:   quote.Items[0].ProductID,
+		Quantity:    quantity,
+		TotalAmount: quote.Total,
 		Status:      model.OrderStatusPending,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		Items:       quote.Items,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
-	// Persist to database
-	if err := s.repo.Create(ctx, order); err != nil {
-		return nil, fmt.Errorf("failed to create order: %w", err)
+	// Build the log entry and outbox message for the created event
+	event := &model.OrderCreatedEvent{
+		EventID:     uuid.New().String(),
+		OrderID:     order.ID,
+		TenantID:    order.TenantID,
+		CustomerID:  order.CustomerID,
+		ProductID:   order.ProductID,
+		Quantity:    order.Quantity,
+		TotalAmount: order.TotalAmount,
+		Status:      order.Status,
+		Items:       order.Items,
+		Version:     order.Version,
+		CreatedAt:   order.CreatedAt,
+		EventType:   model.EventTypeOrderCreated,
 	}
-
-	// Cache the order
-	if err := s.cache.Set(ctx, order); err != nil {
-		log.Printf("Warning: failed to cache order %s: %v", order.ID, err)
+	logEntry, err := model.NewOrderEvent(event.EventID, order.ID, event.Version, event.EventType, order.CreatedAt, event)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := model.NewOutboxMessage(event.EventID, order.ID, event.EventType, model.RoutingKeyOrderCreated, event)
+	if err != nil {
+		return nil, err
 	}
 
-	// Publish event asynchronously
-	go func() {
-		event := &model.OrderCreatedEvent{
-			OrderID:     order.ID,
-			CustomerID:  order.CustomerID,
-			ProductID:   order.ProductID,
-			Quantity:    order.Quantity,
-			TotalAmount: order.TotalAmount,
-			Status:      order.Status,
-			CreatedAt:   order.CreatedAt,
-		}
+	return &model.OrderWrite{
+		Order:    order,
+		Events:   []*model.OrderEvent{logEntry},
+		Messages: []*model.OutboxMessage{msg},
+		History: &model.OrderHistoryEntry{
+			OrderID:    order.ID,
+			Status:     order.Status,
+			Version:    order.Version,
+			Actor:      model.ActorFromContext(ctx),
+			EventIDs:   []string{event.EventID},
+			OccurredAt: order.CreatedAt,
+		},
+	}, nil
+}
 
-		if err := s.publisher.PublishOrderCreated(context.Background(), event); err != nil {
-			log.Printf("Error: failed to publish order created event for order %s: %v", order.ID, err)
+// requestItems validates a create order request and returns its line items.
+// When Items is empty the legacy single-item fields are converted into the only line item.
+// Every invalid field is reported in one *model.ValidationError.
+func requestItems(req *model.CreateOrderRequest) ([]model.CreateOrderItemRequest, error) {
+	if len(req.Items) > 0 || (req.ProductID == "" && req.Quantity == 0) {
+		if err := validation.Struct(req); err != nil {
+			return nil, err
 		}
-	}()
+		return req.Items, nil
+	}
 
-	log.Printf("Order created successfully: %s", order.ID)
-	return order, nil
+	var legacy model.ValidationError
+	if req.ProductID == "" {
+		legacy.Fields = append(legacy.Fields, model.FieldError{Field: "product_id", Message: "is required"})
+	}
+	if req.Quantity <= 0 {
+		legacy.Fields = append(legacy.Fields, model.FieldError{Field: "quantity", Message: "must be greater than 0"})
+	} else if req.Quantity > model.MaxItemQuantity {
+		legacy.Fields = append(legacy.Fields, model.FieldError{Field: "quantity", Message: fmt.Sprintf("must be at most %d", model.MaxItemQuantity)})
+	}
+	if req.TotalAmount == nil {
+		legacy.Fields = append(legacy.Fields, model.FieldError{Field: "total_amount", Message: "is required for single-item orders"})
+	}
+	var legacyErr error
+	if len(legacy.Fields) > 0 {
+		legacyErr = &legacy
+	}
+
+	if err := validation.Merge(validation.StructExcept(req, "Items"), legacyErr); err != nil {
+		return nil, err
+	}
+
+	return []model.CreateOrderItemRequest{{
+		ProductID: req.ProductID,
+		Quantity:  req.Quantity,
+	}}, nil
 }
 
-// GetOrderByID retrieves an order by ID (cache-aside pattern)
+// GetOrderByID retrieves an order by ID (cache-aside pattern). Concurrent cache misses for
+// the same order share one database read, and orders that do not exist are cached briefly.
 func (s *OrderService) GetOrderByID(ctx context.Context, id string) (*model.Order, error) {
 	// Try to get from cache first
 	order, err := s.cache.Get(ctx, id)
-	if err == nil {
+	switch {
+	case err == nil:
+		cacheLookupsTotal.WithLabelValues("hit").Inc()
 		log.Printf("Cache hit for order: %s", id)
 		return order, nil
+	case errors.Is(err, cache.ErrNotFound):
+		cacheLookupsTotal.WithLabelValues("negative_hit").Inc()
+		return nil, fmt.Errorf("failed to get order: %w", repository.ErrOrderNotFound)
 	}
 
+	cacheLookupsTotal.WithLabelValues("miss").Inc()
 	log.Printf("Cache miss for order: %s, fetching from database", id)
 
-	// Cache miss, get from database
-	order, err = s.repo.GetByID(ctx, id)
+	// Cache miss, get from database unless another request already is
+	tenantID, _ := model.TenantFromContext(ctx)
+	loaded := false
+	result := s.loads.DoChan(tenantID+":"+id, func() (interface{}, error) {
+		loaded = true
+		return s.loadOrder(context.WithoutCancel(ctx), id)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		if !loaded {
+			cacheCoalescedTotal.Inc()
+		}
+		if res.Err != nil {
+			return nil, fmt.Errorf("failed to get order: %w", res.Err)
+		}
+		order, ok := res.Val.(*model.Order)
+		if !ok {
+			return nil, fmt.Errorf("failed to get order: unexpected result %T", res.Val)
+		}
+		return order, nil
+	}
+}
+
+// loadOrder reads an order from the database and caches it, or caches that it does not exist
+func (s *OrderService) loadOrder(ctx context.Context, id string) (*model.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, orderLoadTimeout)
+	defer cancel()
+
+	order, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		if err := s.cache.SetNotFound(ctx, id); err != nil {
+			log.Printf("Warning: failed to cache missing order %s: %v", id, err)
+		}
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get order: %w", err)
+		return nil, err
 	}
 
 	// Update cache
@@ -111,22 +342,262 @@ func (s *OrderService) GetOrderByID(ctx context.Context, id string) (*model.Orde
 	return order, nil
 }
 
-// ListOrders retrieves a list of orders
-func (s *OrderService) ListOrders(ctx context.Context, limit, offset int) ([]*model.Order, error) {
+// GetOrderHistory retrieves the chronological audit trail of an order
+func (s *OrderService) GetOrderHistory(ctx context.Context, id string) (*model.OrderHistory, error) {
+	entries, err := s.repo.GetHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order history: %w", err)
+	}
+
+	return &model.OrderHistory{OrderID: id, Entries: entries}, nil
+}
+
+// GetOrderUpdates retrieves the status updates of an order from its event log, oldest first
+func (s *OrderService) GetOrderUpdates(ctx context.Context, id string) ([]*model.OrderStatusUpdate, error) {
+	events, err := s.repo.GetEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+	return statusUpdates(events)
+}
+
+// GetCustomerUpdatesAfter retrieves the status updates of a customer's orders recorded after the
+// event with the given ID, oldest first and at most MaxStreamReplay of them
+func (s *OrderService) GetCustomerUpdatesAfter(ctx context.Context, customerID, afterEventID string) ([]*model.OrderStatusUpdate, error) {
+	events, err := s.repo.GetCustomerEventsAfter(ctx, customerID, afterEventID, MaxStreamReplay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer order events: %w", err)
+	}
+	return statusUpdates(events)
+}
+
+// statusUpdates converts order log entries into status updates
+func statusUpdates(events []*model.OrderEvent) ([]*model.OrderStatusUpdate, error) {
+	updates := make([]*model.OrderStatusUpdate, 0, len(events))
+	for _, event := range events {
+		update, err := model.StatusUpdateFromEvent(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event %s: %w", event.EventID, err)
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+// ListOrders retrieves one page of orders matching the filter, newest first.
+// cursor is the next_cursor of the previous page, or empty for the first page.
+func (s *OrderService) ListOrders(ctx context.Context, filter model.OrderFilter, cursor string, limit int) (*model.OrderPage, error) {
 	if limit <= 0 {
 		limit = 10
 	}
 	if limit > 100 {
 		limit = 100
 	}
-	if offset < 0 {
-		offset = 0
+	if err := filter.Validate(); err != nil {
+		return nil, err
 	}
 
-	orders, err := s.repo.List(ctx, limit, offset)
+	var after *model.OrderCursor
+	if cursor != "" {
+		var err error
+		if after, err = model.DecodeOrderCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra order to learn whether another page follows
+	orders, err := s.repo.List(ctx, filter, after, limit+1)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
 
-	return orders, nil
+	page := &model.OrderPage{
+		Orders:  orders,
+		Filters: filter,
+		Limit:   limit,
+	}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.NextCursor = model.CursorAfter(orders[limit-1]).Encode()
+	}
+	if page.Orders == nil {
+		page.Orders = []*model.Order{}
+	}
+
+	return page, nil
+}
+
+// ExportOrders calls fn with every order matching the filter, oldest first, without loading
+// them all into memory. since is the cursor of the last order already exported, or empty to
+// start from the beginning. Invalid filters and cursors are reported before fn is first called.
+func (s *OrderService) ExportOrders(ctx context.Context, filter model.OrderFilter, since string, fn func(*model.Order) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+
+	var after *model.OrderCursor
+	if since != "" {
+		var err error
+		if after, err = model.DecodeOrderCursor(since); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.Stream(ctx, filter, after, fn); err != nil {
+		return fmt.Errorf("failed to export orders: %w", err)
+	}
+	return nil
+}
+
+// ConfirmOrder moves a pending order to confirmed
+func (s *OrderService) ConfirmOrder(ctx context.Context, id string, match model.VersionMatch) (*model.Order, error) {
+	return s.TransitionOrder(ctx, id, model.OrderStatusConfirmed, match)
+}
+
+// CancelOrder cancels an order that has not been shipped yet
+func (s *OrderService) CancelOrder(ctx context.Context, id string, match model.VersionMatch) (*model.Order, error) {
+	return s.TransitionOrder(ctx, id, model.OrderStatusCancelled, match)
+}
+
+// ShipOrder marks a confirmed order as shipped
+func (s *OrderService) ShipOrder(ctx context.Context, id string, match model.VersionMatch) (*model.Order, error) {
+	return s.TransitionOrder(ctx, id, model.OrderStatusShipped, match)
+}
+
+// DeliverOrder marks a shipped order as delivered
+func (s *OrderService) DeliverOrder(ctx context.Context, id string, match model.VersionMatch) (*model.Order, error) {
+	return s.TransitionOrder(ctx, id, model.OrderStatusDelivered, match)
+}
+
+// RefundOrder marks a delivered order as refunded
+func (s *OrderService) RefundOrder(ctx context.Context, id string, match model.VersionMatch) (*model.Order, error) {
+	return s.TransitionOrder(ctx, id, model.OrderStatusRefunded, match)
+}
+
+// TransitionOrder moves an order to the given status if the state machine allows it.
+// match names the order versions the caller accepts, usually the one it last read; the
+// transition is rejected with repository.ErrVersionConflict if the order is at another.
+func (s *OrderService) TransitionOrder(ctx context.Context, id, status string, match model.VersionMatch) (*model.Order, error) {
+	return s.changeStatus(ctx, id, match, statusChange{
+		to:         status,
+		eventType:  model.EventTypeOrderStatusChanged,
+		routingKey: model.StatusRoutingKey(status),
+	})
+}
+
+// ExpireOrder cancels a pending order that was not confirmed in time and publishes an
+// order.expired event. Orders in any other status are rejected with model.ErrInvalidTransition.
+func (s *OrderService) ExpireOrder(ctx context.Context, id string, version int64) (*model.Order, error) {
+	return s.changeStatus(ctx, id, model.MatchVersion(version), statusChange{
+		from:       model.OrderStatusPending,
+		to:         model.OrderStatusCancelled,
+		eventType:  model.EventTypeOrderExpired,
+		routingKey: model.RoutingKeyOrderExpired,
+	})
+}
+
+// ExpirePendingOrders expires up to limit pending orders created before cutoff and returns
+// how many were expired. The sweep covers every tenant; each order is expired within its own.
+// Orders that change while the sweep runs are skipped.
+func (s *OrderService) ExpirePendingOrders(ctx context.Context, cutoff time.Time, limit int) (int, error) {
+	filter := model.OrderFilter{Status: model.OrderStatusPending, CreatedTo: &cutoff}
+	orders, err := s.repo.List(model.WithAllTenants(ctx), filter, nil, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending orders: %w", err)
+	}
+
+	expired := 0
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			return expired, ctx.Err()
+		}
+		if _, err := s.ExpireOrder(model.WithTenant(ctx, order.TenantID), order.ID, order.Version); err != nil {
+			if errors.Is(err, repository.ErrVersionConflict) || errors.Is(err, model.ErrInvalidTransition) {
+				log.Printf("Skipping expiry of order %s, it changed since it was listed: %v", order.ID, err)
+			} else {
+				log.Printf("Error expiring order %s: %v", order.ID, err)
+			}
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// statusChange describes a status transition and the event that records it
+type statusChange struct {
+	from       string // status the order must be in; empty allows any status the state machine allows
+	to         string
+	eventType  string
+	routingKey string
+}
+
+// changeStatus rebuilds an order from its events, applies the status change and persists it
+// together with its event, outbox message and history entry
+func (s *OrderService) changeStatus(ctx context.Context, id string, match model.VersionMatch, change statusChange) (*model.Order, error) {
+	// Rebuild the aggregate from its event log, never from the projection or cache
+	events, err := s.repo.GetEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order events: %w", err)
+	}
+	order, err := model.ReplayOrder(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild order %s: %w", id, err)
+	}
+
+	if !match.Matches(order.Version) {
+		return nil, fmt.Errorf("%w: order %s is at version %d, not %s", repository.ErrVersionConflict, id, order.Version, match)
+	}
+
+	if (change.from != "" && order.Status != change.from) || !model.CanTransition(order.Status, change.to) {
+		return nil, fmt.Errorf("%w: %s -> %s", model.ErrInvalidTransition, order.Status, change.to)
+	}
+
+	previousStatus := order.Status
+	order.Status = change.to
+	order.UpdatedAt = time.Now()
+
+	event := &model.OrderStatusChangedEvent{
+		EventID:        uuid.New().String(),
+		OrderID:        order.ID,
+		TenantID:       order.TenantID,
+		CustomerID:     order.CustomerID,
+		PreviousStatus: previousStatus,
+		Status:         order.Status,
+		Version:        order.Version + 1,
+		ChangedAt:      order.UpdatedAt,
+		EventType:      change.eventType,
+	}
+	logEntry, err := model.NewOrderEvent(event.EventID, order.ID, event.Version, event.EventType, event.ChangedAt, event)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := model.NewOutboxMessage(event.EventID, order.ID, event.EventType, change.routingKey, event)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &model.OrderHistoryEntry{
+		OrderID:        order.ID,
+		PreviousStatus: previousStatus,
+		Status:         order.Status,
+		Version:        event.Version,
+		Actor:          model.ActorFromContext(ctx),
+		EventIDs:       []string{event.EventID},
+		OccurredAt:     order.UpdatedAt,
+	}
+
+	// Persist the new status, event and history atomically
+	if err := s.repo.Update(ctx, order, []*model.OrderEvent{logEntry}, []*model.OutboxMessage{msg}, entry); err != nil {
+		return nil, fmt.Errorf("failed to update order: %w", err)
+	}
+
+	// Invalidate cache so readers pick up the new status
+	if err := s.cache.Delete(ctx, order.ID); err != nil {
+		log.Printf("Warning: failed to invalidate cached order %s: %v", order.ID, err)
+	}
+
+	log.Printf("Order %s transitioned from %s to %s", order.ID, previousStatus, order.Status)
+	return order, nil
 }
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+)
+
+// ErrInvalidProduct is returned when a product request fails validation
+var ErrInvalidProduct = model.NewError(model.ErrValidation, "invalid product")
+
+// ProductService handles business logic for the product price table
+type ProductService struct {
+	repo repository.ProductRepository
+}
+
+// NewProductService creates a new product service
+func NewProductService(repo repository.ProductRepository) *ProductService {
+	return &ProductService{repo: repo}
+}
+
+// CreateProduct adds a product to the price table
+func (s *ProductService) CreateProduct(ctx context.Context, req *model.ProductRequest) (*model.Product, error) {
+	if err := validateProduct(req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	product := &model.Product{
+		ID:              req.ID,
+		Name:            req.Name,
+		UnitPrice:       req.UnitPrice,
+		DiscountPercent: req.DiscountPercent,
+		Active:          req.Active == nil || *req.Active,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := s.repo.Create(ctx, product); err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
+
+	return product, nil
+}
+
+// GetProduct retrieves a product by ID
+func (s *ProductService) GetProduct(ctx context.Context, id string) (*model.Product, error) {
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+// ListProducts retrieves the full price table
+func (s *ProductService) ListProducts(ctx context.Context) ([]*model.Product, error) {
+	products, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	return products, nil
+}
+
+// UpdateProduct replaces the price table entry for a product
+func (s *ProductService) UpdateProduct(ctx context.Context, id string, req *model.ProductRequest) (*model.Product, error) {
+	if req.ID == "" {
+		req.ID = id
+	}
+	if req.ID != id {
+		return nil, fmt.Errorf("%w: id in body does not match the URL", ErrInvalidProduct)
+	}
+	if err := validateProduct(req); err != nil {
+		return nil, err
+	}
+
+	product, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	product.Name = req.Name
+	product.UnitPrice = req.UnitPrice
+	product.DiscountPercent = req.DiscountPercent
+	if req.Active != nil {
+		product.Active = *req.Active
+	}
+	product.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, product); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+
+	return product, nil
+}
+
+// DeleteProduct removes a product from the price table
+func (s *ProductService) DeleteProduct(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	return nil
+}
+
+// validateProduct checks a product request
+func validateProduct(req *model.ProductRequest) error {
+	if req.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidProduct)
+	}
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProduct)
+	}
+	if !model.IsSupportedCurrency(req.UnitPrice.Currency) {
+		return fmt.Errorf("%w: unit_price.currency %q is not a supported ISO-4217 code", ErrInvalidProduct, req.UnitPrice.Currency)
+	}
+	if req.UnitPrice.Amount <= 0 {
+		return fmt.Errorf("%w: unit_price must be greater than 0", ErrInvalidProduct)
+	}
+	if req.DiscountPercent < 0 || req.DiscountPercent >= 100 {
+		return fmt.Errorf("%w: discount_percent must be between 0 and 100", ErrInvalidProduct)
+	}
+	return nil
+}
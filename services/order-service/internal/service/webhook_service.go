@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/validation"
+	"github.com/google/uuid"
+)
+
+// webhookSecretPrefix starts every generated signing secret
+const webhookSecretPrefix = "whsec_"
+
+// Delivery log page size limits
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 200
+)
+
+// WebhookService handles webhook subscriptions of the current tenant and their delivery log
+type WebhookService struct {
+	repo repository.WebhookRepository
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// CreateSubscription subscribes an endpoint to order events of the tenant of ctx. The returned
+// secret is only shown once.
+func (s *WebhookService) CreateSubscription(ctx context.Context, req *model.WebhookRequest) (*model.CreatedWebhookSubscription, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	tenantID, _ := model.TenantFromContext(ctx)
+	now := time.Now()
+	sub := &model.WebhookSubscription{
+		ID:         uuid.New().String(),
+		TenantID:   tenantID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		Active:     req.Active == nil || *req.Active,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return &model.CreatedWebhookSubscription{WebhookSubscription: sub, Secret: secret}, nil
+}
+
+// ListSubscriptions retrieves the webhook subscriptions of the tenant of ctx
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	subs, err := s.repo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// GetSubscription retrieves a webhook subscription by ID
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// UpdateSubscription replaces the URL, event types and active flag of a subscription, and its
+// secret if the request has one
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id string, req *model.WebhookRequest) (*model.WebhookSubscription, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	sub, err := s.repo.GetSubscription(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	sub.URL = req.URL
+	sub.EventTypes = req.EventTypes
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	sub.Active = req.Active == nil || *req.Active
+	sub.UpdatedAt = time.Now()
+	if err := s.repo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// DeleteSubscription removes a subscription and its delivery log
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves the most recent deliveries of a subscription, optionally only those
+// with the given status
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*model.WebhookDelivery, error) {
+	switch status {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryDead:
+	default:
+		return nil, model.InvalidField("status", fmt.Sprintf("must be one of %s, %s, %s",
+			model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryDead))
+	}
+	if limit <= 0 {
+		limit = DefaultDeliveryLimit
+	}
+	if limit > MaxDeliveryLimit {
+		limit = MaxDeliveryLimit
+	}
+
+	// An unknown subscription is reported as such rather than as an empty log
+	if _, err := s.repo.GetSubscription(ctx, subscriptionID); err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscription: %w", err)
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, subscriptionID, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Redeliver queues a delivery to be sent again straight away with a fresh set of attempts,
+// whether it was delivered, dead or still pending
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID, id string) (*model.WebhookDelivery, error) {
+	delivery, err := s.repo.Redeliver(ctx, subscriptionID, id, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver webhook delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// generateWebhookSecret returns a random signing secret
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
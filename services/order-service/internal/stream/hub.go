@@ -0,0 +1,157 @@
+// Package stream fans order status changes out to the long-lived client streams of one replica.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultBufferSize is the number of updates a subscriber may fall behind before it is dropped
+const DefaultBufferSize = 64
+
+var (
+	activeSubscriptions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "order_stream_subscriptions",
+		Help: "Number of open order status streams",
+	})
+	droppedSubscriptionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "order_stream_dropped_total",
+		Help: "Total number of order status streams closed for falling behind",
+	})
+)
+
+// Filter selects the updates a subscription receives. Empty OrderID and CustomerID match any.
+type Filter struct {
+	TenantID   string
+	OrderID    string
+	CustomerID string
+}
+
+// Matches reports whether an update passes the filter
+func (f Filter) Matches(update *model.OrderStatusUpdate) bool {
+	return update.TenantID == f.TenantID &&
+		(f.OrderID == "" || update.OrderID == f.OrderID) &&
+		(f.CustomerID == "" || update.CustomerID == f.CustomerID)
+}
+
+// Subscription receives the updates matching its filter until it is closed
+type Subscription struct {
+	filter  Filter
+	updates chan *model.OrderStatusUpdate
+	hub     *Hub
+}
+
+// Updates returns the channel updates are delivered on. It is closed when the subscription is
+// closed or falls more than the hub's buffer size behind; clients then reconnect and resume
+// from the event log.
+func (s *Subscription) Updates() <-chan *model.OrderStatusUpdate {
+	return s.updates
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub delivers every order status update it receives to the matching subscriptions.
+// Each replica runs its own hub fed by a broadcast consumer, so clients may connect to any replica.
+type Hub struct {
+	mu         sync.Mutex
+	subs       map[*Subscription]struct{}
+	bufferSize int
+}
+
+// NewHub creates a new hub whose subscribers may fall bufferSize updates behind
+func NewHub(bufferSize int) *Hub {
+	return &Hub{
+		subs:       make(map[*Subscription]struct{}),
+		bufferSize: bufferSize,
+	}
+}
+
+// Subscribe opens a subscription to the updates matching filter
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	sub := &Subscription{
+		filter:  filter,
+		updates: make(chan *model.OrderStatusUpdate, h.bufferSize),
+		hub:     h,
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	activeSubscriptions.Inc()
+	return sub
+}
+
+// Publish delivers an update to every matching subscription without blocking.
+// Subscriptions whose buffer is full are closed.
+func (h *Hub) Publish(update *model.OrderStatusUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.filter.Matches(update) {
+			continue
+		}
+		select {
+		case sub.updates <- update:
+		default:
+			droppedSubscriptionsTotal.Inc()
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// HandleEvent publishes the status update carried by an order event from the broker.
+// Events that cannot be parsed are logged and dropped.
+func (h *Hub) HandleEvent(_ context.Context, routingKey string, body []byte) error {
+	var envelope struct {
+		EventType string `json:"event_type"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		log.Printf("Dropping malformed %s event: %v", routingKey, err)
+		return nil
+	}
+
+	update, err := model.NewOrderStatusUpdate(envelope.EventType, body)
+	if err != nil {
+		log.Printf("Dropping %s event: %v", routingKey, err)
+		return nil
+	}
+	h.Publish(update)
+	return nil
+}
+
+// Close closes every subscription, ending their streams. New subscriptions are still accepted.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		h.removeLocked(sub)
+	}
+}
+
+// remove closes a subscription if it is still open
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub)
+}
+
+// removeLocked closes a subscription if it is still open; callers hold mu
+func (h *Hub) removeLocked(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.updates)
+	activeSubscriptions.Dec()
+}
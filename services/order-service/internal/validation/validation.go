@@ -0,0 +1,132 @@
+// Package validation enforces the `validate` struct tags of request models.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is safe for concurrent use and caches struct metadata, so it is shared
+var validate = newValidator()
+
+// newValidator creates a validator that reports JSON field names, validates Money by its amount
+// and checks tenant IDs and webhook event types with the "tenant" and "webhook_event" tags
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(model.Money).Amount
+	}, model.Money{})
+	_ = v.RegisterValidation("tenant", func(fl validator.FieldLevel) bool {
+		return model.ValidTenantID(fl.Field().String())
+	})
+	_ = v.RegisterValidation("webhook_event", func(fl validator.FieldLevel) bool {
+		return model.IsWebhookEventType(fl.Field().String())
+	})
+	return v
+}
+
+// Struct validates s against its `validate` tags and returns a *model.ValidationError
+// listing every invalid field, or nil if s is valid
+func Struct(s interface{}) error {
+	return toValidationError(validate.Struct(s))
+}
+
+// StructExcept is like Struct but skips the named fields
+func StructExcept(s interface{}, fields ...string) error {
+	return toValidationError(validate.StructExcept(s, fields...))
+}
+
+// Merge combines validation errors into one, ignoring nils.
+// Any error that is not a *model.ValidationError is returned unchanged.
+func Merge(errs ...error) error {
+	var merged model.ValidationError
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		var validationErr *model.ValidationError
+		if !errors.As(err, &validationErr) {
+			return err
+		}
+		merged.Fields = append(merged.Fields, validationErr.Fields...)
+	}
+	if len(merged.Fields) == 0 {
+		return nil
+	}
+	return &merged
+}
+
+// toValidationError converts validator errors into a *model.ValidationError
+func toValidationError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	result := &model.ValidationError{Fields: make([]model.FieldError, 0, len(fieldErrs))}
+	for _, fe := range fieldErrs {
+		result.Fields = append(result.Fields, model.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Message: message(fe),
+		})
+	}
+	return result
+}
+
+// fieldPath strips the root struct name from a namespace such as CreateOrderRequest.items[0].quantity
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return path
+}
+
+// message describes a failed validation tag in words
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if":
+		return "is required"
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "tenant":
+		return "must be a tenant ID of up to 64 letters, digits, '-' and '_'"
+	case "webhook_event":
+		return "must be one of " + strings.Join(model.WebhookEventTypes(), ", ")
+	case "http_url":
+		return "must be an http or https URL"
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be at least " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			return fmt.Sprintf("must contain at least %s item(s)", fe.Param())
+		}
+		return "must be at least " + fe.Param() + " characters"
+	case "max":
+		switch fe.Kind() {
+		case reflect.Slice:
+			return fmt.Sprintf("must contain at most %s item(s)", fe.Param())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return "must be at most " + fe.Param()
+		}
+		return "must be at most " + fe.Param() + " characters"
+	default:
+		return fmt.Sprintf("failed the %q check", fe.Tag())
+	}
+}
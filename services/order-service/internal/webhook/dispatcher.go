@@ -0,0 +1,200 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// userAgent identifies webhook requests to receivers
+const userAgent = "order-service-webhooks/1.0"
+
+var deliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_deliveries_total",
+	Help: "Total number of webhook delivery attempts by result (delivered, retry, dead)",
+}, []string{"result"})
+
+// Config holds dispatcher tuning parameters
+type Config struct {
+	PollInterval time.Duration // how often to look for due deliveries
+	BatchSize    int           // maximum deliveries claimed per poll
+	Lease        time.Duration // how long claimed deliveries are hidden from other dispatchers
+	Timeout      time.Duration // maximum time to wait for a receiver to respond
+	MaxAttempts  int           // attempts after which a delivery is dead
+	MinBackoff   time.Duration // delay before the first retry
+	MaxBackoff   time.Duration // upper bound for retry delay
+}
+
+// DefaultConfig returns the default dispatcher configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    50,
+		Lease:        time.Minute,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  10,
+		MinBackoff:   10 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// Dispatcher sends due deliveries to their subscriptions and records the outcome.
+// A delivery is retried with exponential backoff until the receiver answers with a 2xx status
+// or MaxAttempts is reached, when it becomes dead until redelivered by hand.
+type Dispatcher struct {
+	store  repository.WebhookDeliveryStore
+	client *http.Client
+	config Config
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(store repository.WebhookDeliveryStore, config Config) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}
+}
+
+// Run polls for due deliveries until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	log.Printf("Webhook dispatcher started (poll interval %s, batch size %d)", d.config.PollInterval, d.config.BatchSize)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain dispatches batches until no more deliveries are due
+func (d *Dispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := d.DispatchBatch(ctx)
+		if err != nil {
+			log.Printf("Error dispatching webhooks: %v", err)
+			return
+		}
+		if n < d.config.BatchSize {
+			return
+		}
+	}
+}
+
+// DispatchBatch claims and sends one batch of due deliveries concurrently, returning how many
+// were claimed
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	dispatches, err := d.store.ClaimDue(ctx, d.config.BatchSize, d.config.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, dispatch := range dispatches {
+		wg.Add(1)
+		go func(dispatch *model.WebhookDispatch) {
+			defer wg.Done()
+			d.deliver(ctx, dispatch)
+		}(dispatch)
+	}
+	wg.Wait()
+
+	return len(dispatches), nil
+}
+
+// deliver sends one delivery and records the attempt
+func (d *Dispatcher) deliver(ctx context.Context, dispatch *model.WebhookDispatch) {
+	attempt := d.send(ctx, dispatch)
+	if ctx.Err() != nil {
+		// Interrupted deliveries become due again once their lease expires
+		return
+	}
+
+	status, next := model.WebhookDeliveryDelivered, attempt.At
+	switch {
+	case attempt.Err == nil:
+		deliveriesTotal.WithLabelValues("delivered").Inc()
+	case dispatch.Attempts+1 >= d.config.MaxAttempts:
+		status = model.WebhookDeliveryDead
+		deliveriesTotal.WithLabelValues("dead").Inc()
+		log.Printf("Webhook delivery %s to %s is dead after %d attempts: %v",
+			dispatch.ID, dispatch.URL, dispatch.Attempts+1, attempt.Err)
+	default:
+		status = model.WebhookDeliveryPending
+		next = attempt.At.Add(d.Backoff(dispatch.Attempts + 1))
+		deliveriesTotal.WithLabelValues("retry").Inc()
+		log.Printf("Error sending webhook delivery %s to %s (attempt %d), retrying at %s: %v",
+			dispatch.ID, dispatch.URL, dispatch.Attempts+1, next.Format(time.RFC3339), attempt.Err)
+	}
+
+	if err := d.store.RecordAttempt(ctx, dispatch.ID, attempt, status, next); err != nil {
+		log.Printf("Error recording webhook attempt for %s: %v", dispatch.ID, err)
+	}
+}
+
+// send POSTs the signed payload of a delivery to its subscription
+func (d *Dispatcher) send(ctx context.Context, dispatch *model.WebhookDispatch) *model.WebhookAttempt {
+	attempt := &model.WebhookAttempt{At: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatch.URL, bytes.NewReader(dispatch.Payload))
+	if err != nil {
+		attempt.Err = fmt.Errorf("failed to create request: %w", err)
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(DeliveryHeader, dispatch.ID)
+	req.Header.Set(EventHeader, dispatch.EventType)
+	req.Header.Set(SignatureHeader, Sign(dispatch.Secret, attempt.At, dispatch.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Err = fmt.Errorf("failed to send request: %w", err)
+		return attempt
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Error closing response body: %v", err)
+		}
+	}()
+	// Drain a bounded amount so the connection can be reused
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)); err != nil {
+		log.Printf("Error reading webhook response from %s: %v", dispatch.URL, err)
+	}
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Err = fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// Backoff returns the retry delay after the given number of failed attempts
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	delay := d.config.MinBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.config.MaxBackoff {
+			return d.config.MaxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,63 @@
+// Package webhook delivers order events to the HTTP endpoints of webhook subscriptions.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Fanout turns each order event into one pending delivery per matching subscription
+type Fanout struct {
+	store repository.WebhookDeliveryStore
+}
+
+// NewFanout creates a new webhook fanout
+func NewFanout(store repository.WebhookDeliveryStore) *Fanout {
+	return &Fanout{store: store}
+}
+
+// HandleEvent records deliveries of an event published with the given routing key. Events
+// that cannot be parsed are logged and dropped; store errors are returned so the event is
+// consumed again.
+func (f *Fanout) HandleEvent(ctx context.Context, routingKey string, body []byte) error {
+	var event struct {
+		EventID  string `json:"event_id"`
+		TenantID string `json:"tenant_id"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || event.EventID == "" {
+		log.Printf("Dropping malformed %s event: %v", routingKey, err)
+		return nil
+	}
+	if event.TenantID == "" {
+		event.TenantID = model.DefaultTenantID
+	}
+
+	subs, err := f.store.MatchingSubscriptions(ctx, event.TenantID, routingKey)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	deliveries := make([]*model.WebhookDelivery, 0, len(subs))
+	for _, sub := range subs {
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			ID:             uuid.New().String(),
+			SubscriptionID: sub.ID,
+			EventID:        event.EventID,
+			EventType:      routingKey,
+			Payload:        body,
+			Status:         model.WebhookDeliveryPending,
+			CreatedAt:      now,
+		})
+	}
+	return f.store.CreateDeliveries(ctx, deliveries)
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header names set on every webhook request
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	DeliveryHeader  = "X-Webhook-ID"
+	EventHeader     = "X-Webhook-Event"
+)
+
+var (
+	// ErrInvalidSignature is returned when a signature header is malformed or does not match the body
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureExpired is returned when a signature is older than the accepted tolerance
+	ErrSignatureExpired = errors.New("webhook signature expired")
+)
+
+// Sign returns the signature header value for a body sent at the given time:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with secret>".
+// Signing the timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac(secret, t, body))
+}
+
+// VerifySignature checks a signature header produced by Sign against the body. Signatures older
+// than tolerance are rejected unless tolerance is zero.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration) error {
+	var t string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			if sig, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, sig)
+			}
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 && time.Since(time.Unix(unix, 0)) > tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := mac(secret, t, body)
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// mac computes the HMAC-SHA256 of "<t>.<body>"
+func mac(secret, t string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,10 @@
+// Package migrations embeds the schema migrations of the order service. Each database driver has a
+// directory of numbered NNN_name.up.sql files, each undone by the matching NNN_name.down.sql.
+package migrations
+
+import "embed"
+
+// FS holds the migrations of every database driver, in a directory named after the driver
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,2 @@
+-- Drop order_items table. Orders keep their product and quantity columns.
+DROP TABLE IF EXISTS order_items;
@@ -0,0 +1,18 @@
+-- Create order_items table for multi-line-item orders
+CREATE TABLE IF NOT EXISTS order_items (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    order_id VARCHAR(36) NOT NULL,
+    product_id VARCHAR(36) NOT NULL,
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10, 2) NOT NULL,
+    line_total DECIMAL(10, 2) NOT NULL,
+    INDEX idx_order_id (order_id),
+    INDEX idx_product_id (product_id),
+    CONSTRAINT fk_order_items_order FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Backfill a single line item for orders created before line items existed
+INSERT INTO order_items (order_id, product_id, quantity, unit_price, line_total)
+SELECT o.id, o.product_id, o.quantity, ROUND(o.total_amount / o.quantity, 2), o.total_amount
+FROM orders o
+WHERE NOT EXISTS (SELECT 1 FROM order_items i WHERE i.order_id = o.id);
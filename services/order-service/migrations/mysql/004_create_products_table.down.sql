@@ -0,0 +1,3 @@
+-- Drop the line item discount and the products table
+ALTER TABLE order_items DROP COLUMN discount;
+DROP TABLE IF EXISTS products;
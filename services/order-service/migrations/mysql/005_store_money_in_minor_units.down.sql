@@ -0,0 +1,37 @@
+-- Store money as DECIMAL amounts again. Amounts are converted back at 100 minor units per unit
+-- and the currency codes are dropped, so amounts in other currencies than USD lose their currency.
+
+-- orders
+UPDATE orders
+SET total_amount = total_amount_minor / 100
+WHERE total_amount IS NULL;
+
+ALTER TABLE orders
+    MODIFY total_amount DECIMAL(10, 2) NOT NULL,
+    DROP COLUMN total_amount_minor,
+    DROP COLUMN currency;
+
+-- order_items
+UPDATE order_items
+SET unit_price = unit_price_minor / 100,
+    discount = discount_minor / 100,
+    line_total = line_total_minor / 100
+WHERE unit_price IS NULL;
+
+ALTER TABLE order_items
+    MODIFY unit_price DECIMAL(10, 2) NOT NULL,
+    MODIFY discount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    MODIFY line_total DECIMAL(10, 2) NOT NULL,
+    DROP COLUMN unit_price_minor,
+    DROP COLUMN discount_minor,
+    DROP COLUMN line_total_minor;
+
+-- products
+UPDATE products
+SET unit_price = unit_price_minor / 100
+WHERE unit_price IS NULL;
+
+ALTER TABLE products
+    MODIFY unit_price DECIMAL(10, 2) NOT NULL,
+    DROP COLUMN unit_price_minor,
+    DROP COLUMN currency;
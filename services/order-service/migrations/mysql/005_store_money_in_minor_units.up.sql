@@ -0,0 +1,40 @@
+-- Store money as integer minor units plus an ISO-4217 currency code.
+-- Existing DECIMAL amounts were always in USD, so they are converted at 100 minor units per dollar.
+-- The DECIMAL columns are kept but made nullable, so that the down migration can restore them;
+-- new rows leave them NULL.
+
+-- orders: add total_amount_minor and currency
+ALTER TABLE orders
+    ADD COLUMN total_amount_minor BIGINT NOT NULL DEFAULT 0 AFTER total_amount,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER total_amount_minor;
+
+UPDATE orders
+SET total_amount_minor = ROUND(total_amount * 100);
+
+ALTER TABLE orders MODIFY total_amount DECIMAL(10, 2) NULL DEFAULT NULL;
+
+-- order_items: add minor unit columns (items share the currency of their order)
+ALTER TABLE order_items
+    ADD COLUMN unit_price_minor BIGINT NOT NULL DEFAULT 0 AFTER line_total,
+    ADD COLUMN discount_minor BIGINT NOT NULL DEFAULT 0 AFTER unit_price_minor,
+    ADD COLUMN line_total_minor BIGINT NOT NULL DEFAULT 0 AFTER discount_minor;
+
+UPDATE order_items
+SET unit_price_minor = ROUND(unit_price * 100),
+    discount_minor = ROUND(discount * 100),
+    line_total_minor = ROUND(line_total * 100);
+
+ALTER TABLE order_items
+    MODIFY unit_price DECIMAL(10, 2) NULL DEFAULT NULL,
+    MODIFY discount DECIMAL(10, 2) NULL DEFAULT NULL,
+    MODIFY line_total DECIMAL(10, 2) NULL DEFAULT NULL;
+
+-- products: add unit_price_minor and currency
+ALTER TABLE products
+    ADD COLUMN unit_price_minor BIGINT NOT NULL DEFAULT 0 AFTER unit_price,
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER unit_price_minor;
+
+UPDATE products
+SET unit_price_minor = ROUND(unit_price * 100);
+
+ALTER TABLE products MODIFY unit_price DECIMAL(10, 2) NULL DEFAULT NULL;
@@ -0,0 +1,2 @@
+-- Drop the optimistic concurrency version of orders
+ALTER TABLE orders DROP COLUMN version;
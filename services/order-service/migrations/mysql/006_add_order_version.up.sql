@@ -0,0 +1,3 @@
+-- Add a version column to orders for optimistic concurrency control.
+-- Every update increments it and only applies if the caller's version is still current.
+ALTER TABLE orders ADD COLUMN version BIGINT NOT NULL DEFAULT 1 AFTER status;
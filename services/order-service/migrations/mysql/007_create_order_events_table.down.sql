@@ -0,0 +1,2 @@
+-- Drop the order event log. The orders and order_items projection keeps the current state.
+DROP TABLE IF EXISTS order_events;
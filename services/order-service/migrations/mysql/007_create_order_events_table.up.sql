@@ -0,0 +1,45 @@
+-- Create the order event log. Every change to an order is appended here and the
+-- orders and order_items tables are a projection of it.
+CREATE TABLE IF NOT EXISTS order_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(36) NOT NULL,
+    order_id VARCHAR(36) NOT NULL,
+    version BIGINT NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSON NOT NULL,
+    occurred_at TIMESTAMP(3) NOT NULL,
+    UNIQUE KEY uk_event_id (event_id),
+    UNIQUE KEY uk_order_version (order_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Backfill orders created before the event log existed with one OrderImported event
+-- holding their current state at their current version. Timestamps are written as UTC.
+SET time_zone = '+00:00';
+
+INSERT INTO order_events (event_id, order_id, version, event_type, payload, occurred_at)
+SELECT UUID(), o.id, o.version, 'OrderImported',
+    JSON_OBJECT(
+        'event_id', '',
+        'order_id', o.id,
+        'customer_id', o.customer_id,
+        'product_id', o.product_id,
+        'quantity', o.quantity,
+        'total_amount', JSON_OBJECT('amount', o.total_amount_minor, 'currency', o.currency),
+        'status', o.status,
+        'items', COALESCE((
+            SELECT JSON_ARRAYAGG(JSON_OBJECT(
+                'product_id', i.product_id,
+                'quantity', i.quantity,
+                'unit_price', JSON_OBJECT('amount', i.unit_price_minor, 'currency', o.currency),
+                'discount', JSON_OBJECT('amount', i.discount_minor, 'currency', o.currency),
+                'line_total', JSON_OBJECT('amount', i.line_total_minor, 'currency', o.currency)))
+            FROM order_items i
+            WHERE i.order_id = o.id
+        ), JSON_ARRAY()),
+        'version', o.version,
+        'created_at', DATE_FORMAT(o.created_at, '%Y-%m-%dT%H:%i:%sZ'),
+        'event_type', 'OrderImported'
+    ),
+    o.updated_at
+FROM orders o
+WHERE NOT EXISTS (SELECT 1 FROM order_events e WHERE e.order_id = o.id);
@@ -0,0 +1,2 @@
+-- Drop the order history audit trail
+DROP TABLE IF EXISTS order_history;
@@ -0,0 +1,29 @@
+-- Create the order history audit trail. One row is written per order change, in the same
+-- transaction as the change, recording who or what caused it and the events published for it.
+CREATE TABLE IF NOT EXISTS order_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    order_id VARCHAR(36) NOT NULL,
+    previous_status VARCHAR(20) NULL,
+    status VARCHAR(20) NOT NULL,
+    version BIGINT NOT NULL,
+    actor_type VARCHAR(20) NOT NULL,
+    actor_name VARCHAR(255) NOT NULL DEFAULT '',
+    event_ids JSON NOT NULL,
+    occurred_at TIMESTAMP(3) NOT NULL,
+    INDEX idx_order_version (order_id, version)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Backfill history from the event log for orders changed before the audit trail existed.
+-- Their actor is unknown, so they are attributed to the system. OrderImported events were
+-- never published and list no event IDs.
+INSERT INTO order_history (order_id, previous_status, status, version, actor_type, actor_name, event_ids, occurred_at)
+SELECT e.order_id,
+    JSON_UNQUOTE(JSON_EXTRACT(e.payload, '$.previous_status')),
+    JSON_UNQUOTE(JSON_EXTRACT(e.payload, '$.status')),
+    e.version,
+    'system',
+    'backfill',
+    IF(e.event_type = 'OrderImported', JSON_ARRAY(), JSON_ARRAY(e.event_id)),
+    e.occurred_at
+FROM order_events e
+WHERE NOT EXISTS (SELECT 1 FROM order_history h WHERE h.order_id = e.order_id);
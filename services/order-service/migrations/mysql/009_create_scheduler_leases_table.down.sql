@@ -0,0 +1,2 @@
+-- Drop the scheduler_leases table
+DROP TABLE IF EXISTS scheduler_leases;
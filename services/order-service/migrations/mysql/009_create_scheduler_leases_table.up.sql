@@ -0,0 +1,7 @@
+-- Create the scheduler_leases table. A background job takes a named lease before each run
+-- so that only one order-api replica runs it at a time; an expired lease can be taken over.
+CREATE TABLE IF NOT EXISTS scheduler_leases (
+    name VARCHAR(64) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP(3) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+-- Drop the api_keys table, revoking every key
+DROP TABLE IF EXISTS api_keys;
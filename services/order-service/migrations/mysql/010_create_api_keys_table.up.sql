@@ -0,0 +1,14 @@
+-- Create the api_keys table for partner integrations. Only the SHA-256 hash of a key is stored;
+-- the key itself is shown once when it is issued. Revoked keys keep their row for auditing.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    customer_id VARCHAR(36) NULL,
+    rate_limit INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP(3) NOT NULL,
+    revoked_at TIMESTAMP(3) NULL,
+    UNIQUE KEY uq_api_keys_key_hash (key_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+-- Drop tenants. Orders and API keys of every tenant are merged back together.
+DROP INDEX idx_tenant_created_at ON orders;
+ALTER TABLE orders DROP COLUMN tenant_id;
+ALTER TABLE api_keys DROP COLUMN tenant_id;
@@ -0,0 +1,7 @@
+-- Scope orders to the storefront (tenant) they were placed in. Existing orders belong to
+-- the default tenant. Every order query filters on tenant_id, so it leads the list index.
+ALTER TABLE orders ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id;
+CREATE INDEX idx_tenant_created_at ON orders (tenant_id, created_at, id);
+
+-- API keys may be bound to one tenant; keys without one may name any tenant per request
+ALTER TABLE api_keys ADD COLUMN tenant_id VARCHAR(64) NULL AFTER customer_id;
@@ -0,0 +1,3 @@
+-- Drop the webhook tables, deliveries first as they reference their subscription
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
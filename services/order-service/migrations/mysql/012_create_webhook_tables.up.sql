@@ -0,0 +1,35 @@
+-- Create the webhook tables. Partners subscribe an endpoint to order event types and every
+-- matching event becomes one delivery, which is retried with backoff until it is delivered or,
+-- after too many attempts, marked dead. The secret signs payloads, so it is stored as given.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id VARCHAR(64) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    event_types JSON NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP(3) NOT NULL,
+    updated_at TIMESTAMP(3) NOT NULL,
+    INDEX idx_webhook_subscriptions_tenant (tenant_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id VARCHAR(36) PRIMARY KEY,
+    subscription_id VARCHAR(36) NOT NULL,
+    event_id VARCHAR(36) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSON NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    last_attempt_at TIMESTAMP(3) NULL,
+    last_status_code INT NULL,
+    last_error TEXT NULL,
+    created_at TIMESTAMP(3) NOT NULL,
+    delivered_at TIMESTAMP(3) NULL,
+    UNIQUE KEY uk_webhook_deliveries_event (subscription_id, event_id),
+    INDEX idx_webhook_deliveries_status_next_attempt (status, next_attempt_at),
+    INDEX idx_webhook_deliveries_subscription_created (subscription_id, created_at),
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id)
+        REFERENCES webhook_subscriptions (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,11 @@
+-- Drop the order-service schema on PostgreSQL, tables referencing others first
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS scheduler_leases;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS order_history;
+DROP TABLE IF EXISTS order_events;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
@@ -0,0 +1,227 @@
+package service_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+)
+
+// TestAuthentication tests bearer token verification and customer-scoped authorization
+func TestAuthentication(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	secret := []byte("test-secret-with-at-least-32-bytes!")
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "oct", "kid": "hmac-1", "alg": "HS256", "k": b64(secret)},
+	}})
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS: %v", err)
+	}
+	verifier, err := auth.LoadVerifier(auth.Config{JWKSFile: jwksFile, Issuer: "https://auth.example.com"})
+	if err != nil {
+		t.Fatalf("LoadVerifier() unexpected error = %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		var key interface{} = secret
+		if method == jwt.SigningMethodRS256 {
+			key = rsaKey
+		}
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return signed
+	}
+	claims := func(role, sub string, exp time.Duration) jwt.MapClaims {
+		return jwt.MapClaims{"iss": "https://auth.example.com", "sub": sub, "role": role, "exp": time.Now().Add(exp).Unix()}
+	}
+	customer := sign(jwt.SigningMethodHS256, "hmac-1", claims(auth.RoleCustomer, "customer-1", time.Hour))
+	admin := sign(jwt.SigningMethodRS256, "rsa-1", claims(auth.RoleAdmin, "admin-1", time.Hour))
+	expired := sign(jwt.SigningMethodRS256, "rsa-1", claims(auth.RoleAdmin, "admin-1", -time.Hour))
+	wrongKey := sign(jwt.SigningMethodHS256, "rsa-1", claims(auth.RoleAdmin, "admin-1", time.Hour))
+
+	var listed model.OrderFilter
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			if id != "order-1" {
+				return nil, repository.ErrOrderNotFound
+			}
+			return &model.Order{ID: id, CustomerID: "customer-2", Status: model.OrderStatusPending, Version: 1}, nil
+		},
+		ListFunc: func(_ context.Context, filter model.OrderFilter, _ *model.OrderCursor, _ int) ([]*model.Order, error) {
+			listed = filter
+			return nil, nil
+		},
+	}
+	h := handler.NewOrderHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()))
+	router := mux.NewRouter()
+	router.Use(handler.Authenticate(verifier, service.NewAPIKeyService(&MockAPIKeyRepository{}), false))
+	router.HandleFunc("/orders/{id}", h.GetOrder).Methods("GET")
+	router.HandleFunc("/orders", h.ListOrders).Methods("GET")
+	router.HandleFunc("/orders/{id}/confirm", handler.RequireRole(auth.RoleAdmin, auth.RoleService)(h.ConfirmOrder)).Methods("POST")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"missing token", "GET", "/orders/order-1", "", http.StatusUnauthorized},
+		{"expired token", "GET", "/orders/order-1", expired, http.StatusUnauthorized},
+		{"token signed with the wrong key", "GET", "/orders/order-1", wrongKey, http.StatusUnauthorized},
+		{"customer reads another customer's order", "GET", "/orders/order-1", customer, http.StatusForbidden},
+		{"admin reads any order", "GET", "/orders/order-1", admin, http.StatusOK},
+		{"customer lists another customer's orders", "GET", "/orders?customer_id=customer-2", customer, http.StatusForbidden},
+		{"customer lists own orders", "GET", "/orders", customer, http.StatusOK},
+		{"customer confirms an order", "POST", "/orders/order-1/confirm", customer, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body.String())
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("401 response without WWW-Authenticate header")
+			}
+			if rec.Code >= 400 && rec.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("error Content-Type = %q, want application/problem+json", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	if listed.CustomerID != "customer-1" {
+		t.Errorf("customer list filter = %+v, want customer_id scoped to customer-1", listed)
+	}
+}
+
+// TestAuthenticationWithoutJWKS tests that requests without credentials are only let through
+// when anonymous access is explicitly allowed, and never to role-guarded routes
+func TestAuthenticationWithoutJWKS(t *testing.T) {
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	newRouter := func(allowAnonymous bool) *mux.Router {
+		router := mux.NewRouter()
+		router.Use(handler.Authenticate(nil, service.NewAPIKeyService(&MockAPIKeyRepository{}), allowAnonymous))
+		router.HandleFunc("/orders", ok).Methods("GET")
+		router.HandleFunc("/orders/{id}/confirm", handler.RequireRole(auth.RoleAdmin, auth.RoleService)(ok)).Methods("POST")
+		return router
+	}
+
+	tests := []struct {
+		name           string
+		allowAnonymous bool
+		method         string
+		path           string
+		token          string
+		want           int
+	}{
+		{"no credentials", false, "GET", "/orders", "", http.StatusUnauthorized},
+		{"bearer token", false, "GET", "/orders", "token", http.StatusUnauthorized},
+		{"no credentials with anonymous access", true, "GET", "/orders", "", http.StatusOK},
+		{"bearer token with anonymous access", true, "GET", "/orders", "token", http.StatusUnauthorized},
+		{"role-guarded route with anonymous access", true, "POST", "/orders/order-1/confirm", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			newRouter(tt.allowAnonymous).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestRequireRole tests that role-guarded routes reject anonymous callers even when anonymous
+// access is allowed, and callers with the wrong role
+func TestRequireRole(t *testing.T) {
+	ctx := context.Background()
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	adminKey, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "ops", Role: auth.RoleAdmin})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	serviceKey, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "platform", Role: auth.RoleService})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+
+	h := handler.NewAPIKeyHandler(keys)
+	admin := handler.RequireRole(auth.RoleAdmin)
+	router := mux.NewRouter()
+	router.Use(handler.Authenticate(nil, keys, true))
+	router.HandleFunc("/admin/api-keys", admin(h.ListAPIKeys)).Methods("GET")
+	router.HandleFunc("/admin/api-keys", admin(h.CreateAPIKey)).Methods("POST")
+
+	tests := []struct {
+		name   string
+		method string
+		key    string
+		want   int
+	}{
+		{"anonymous caller issues a key", "POST", "", http.StatusUnauthorized},
+		{"anonymous caller lists keys", "GET", "", http.StatusUnauthorized},
+		{"service key issues a key", "POST", serviceKey.Key, http.StatusForbidden},
+		{"admin key issues a key", "POST", adminKey.Key, http.StatusCreated},
+		{"admin key lists keys", "GET", adminKey.Key, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/admin/api-keys", strings.NewReader(`{"name": "partner", "role": "admin"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.key != "" {
+				req.Header.Set(handler.APIKeyHeader, tt.key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("%s /admin/api-keys = %d, want %d: %s", tt.method, rec.Code, tt.want, rec.Body.String())
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("401 response without WWW-Authenticate header")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// TestOrderETags tests that order responses carry the version as ETag and that status
+// changes honour If-Match as a single ETag, a list of ETags or "*"
+func TestOrderETags(t *testing.T) {
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			if id != "order-123" {
+				return nil, repository.ErrOrderNotFound
+			}
+			return &model.Order{ID: id, CustomerID: "customer-123", Status: model.OrderStatusPending, Version: 3}, nil
+		},
+		GetEventsFunc: func(_ context.Context, id string) ([]*model.OrderEvent, error) {
+			if id != "order-123" {
+				return nil, repository.ErrOrderNotFound
+			}
+			imported, err := model.NewOrderEvent("event-1", id, 3, model.EventTypeOrderImported, time.Now(),
+				&model.OrderCreatedEvent{OrderID: id, CustomerID: "customer-123", Status: model.OrderStatusPending, Version: 3})
+			return []*model.OrderEvent{imported}, err
+		},
+	}
+	h := handler.NewOrderHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()))
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}", h.GetOrder).Methods("GET")
+	router.HandleFunc("/orders/{id}/confirm", h.ConfirmOrder).Methods("POST")
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/orders/order-123", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != `"3"` {
+		t.Fatalf("GET /orders/order-123 = %d with ETag %q, want 200 with ETag \"3\"", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	tests := []struct {
+		name     string
+		id       string
+		ifMatch  []string
+		want     int
+		wantETag string
+	}{
+		{name: "missing If-Match", id: "order-123", want: http.StatusPreconditionRequired},
+		{name: "current ETag", id: "order-123", ifMatch: []string{`"3"`}, want: http.StatusOK, wantETag: `"4"`},
+		{name: "stale ETag", id: "order-123", ifMatch: []string{`"2"`}, want: http.StatusPreconditionFailed},
+		{name: "weak ETag", id: "order-123", ifMatch: []string{`W/"3"`}, want: http.StatusPreconditionFailed},
+		{name: "unquoted ETag", id: "order-123", ifMatch: []string{`3`}, want: http.StatusPreconditionFailed},
+		{name: "any version", id: "order-123", ifMatch: []string{`*`}, want: http.StatusOK, wantETag: `"4"`},
+		{name: "list with the current ETag", id: "order-123", ifMatch: []string{`"1", W/"3", "3"`}, want: http.StatusOK, wantETag: `"4"`},
+		{name: "list of stale ETags", id: "order-123", ifMatch: []string{`"1","2"`}, want: http.StatusPreconditionFailed},
+		{name: "ETags in several headers", id: "order-123", ifMatch: []string{`"2"`, `"3"`}, want: http.StatusOK, wantETag: `"4"`},
+		{name: "any version of a missing order", id: "order-missing", ifMatch: []string{`*`}, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *model.Order
+			mockRepo.UpdateFunc = func(_ context.Context, order *model.Order, _ []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+				updated = order
+				order.Version++ // as the repository does
+				return nil
+			}
+
+			req := httptest.NewRequest("POST", "/orders/"+tt.id+"/confirm", nil)
+			for _, value := range tt.ifMatch {
+				req.Header.Add("If-Match", value)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("POST /orders/%s/confirm with If-Match %q = %d, want %d: %s", tt.id, tt.ifMatch, rec.Code, tt.want, rec.Body.String())
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+			if (updated != nil) != (tt.want == http.StatusOK) {
+				t.Errorf("order updated = %v, want %v", updated != nil, tt.want == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/service"
+)
+
+// TestExportOrders tests that orders stream as CSV or NDJSON with resumable cursors
+func TestExportOrders(t *testing.T) {
+	created := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	orders := []*model.Order{
+		{ID: "order-1", CustomerID: "customer-1", Status: model.OrderStatusPending, TotalAmount: model.NewMoney(1999, "USD"), Version: 1, CreatedAt: created, UpdatedAt: created},
+		{ID: "order-2", CustomerID: "customer-1", Status: model.OrderStatusShipped, TotalAmount: model.NewMoney(500, "EUR"), Version: 3, CreatedAt: created.Add(time.Minute), UpdatedAt: created.Add(time.Hour)},
+	}
+
+	var after *model.OrderCursor
+	var filter model.OrderFilter
+	mockRepo := &MockOrderRepository{
+		StreamFunc: func(_ context.Context, f model.OrderFilter, a *model.OrderCursor, fn func(*model.Order) error) error {
+			filter, after = f, a
+			for _, order := range orders {
+				if a != nil && !order.CreatedAt.After(a.CreatedAt) {
+					continue
+				}
+				if err := fn(order); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	h := handler.NewOrderHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()))
+
+	export := func(accept, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/orders/export"+query, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		h.ExportOrders(rec, req)
+		return rec
+	}
+
+	// NDJSON: one order per line, each with the cursor to resume after it
+	rec := export("application/x-ndjson", "?customer_id=customer-1")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("NDJSON export = %d %s, want 200 application/x-ndjson", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if filter.CustomerID != "customer-1" {
+		t.Errorf("NDJSON export filter = %+v, want customer_id customer-1", filter)
+	}
+	type exportLine struct {
+		ID     string `json:"id"`
+		Cursor string `json:"cursor"`
+	}
+	var lines []exportLine
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line exportLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("NDJSON export line %q is not JSON: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0].ID != "order-1" || lines[1].ID != "order-2" {
+		t.Fatalf("NDJSON export = %+v, want order-1 and order-2", lines)
+	}
+
+	// Resuming after the first line exports only the rest
+	rec = export("text/csv", "?since="+lines[0].Cursor)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("CSV export = %d %s, want 200 text/csv", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if after == nil || after.ID != "order-1" {
+		t.Errorf("CSV export resumed after %+v, want order-1", after)
+	}
+	records, err := csv.NewReader(strings.NewReader(rec.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("CSV export is not valid CSV: %v", err)
+	}
+	if len(records) != 2 || records[0][0] != "id" || records[1][0] != "order-2" || records[1][4] != "5.00" || records[1][5] != "EUR" {
+		t.Errorf("CSV export = %v, want a header and order-2 at 5.00 EUR", records)
+	}
+
+	// Errors found before streaming starts are problem responses
+	if rec := export("text/csv", "?since=not-a-cursor"); rec.Code != http.StatusBadRequest {
+		t.Errorf("export with bad cursor = %d, want 400", rec.Code)
+	}
+	if rec := export("application/xml", ""); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("export as XML = %d, want 406", rec.Code)
+	}
+}
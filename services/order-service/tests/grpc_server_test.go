@@ -0,0 +1,314 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	orderv1 "github.com/andev0x/order-service/api/order/v1"
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/grpcserver"
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/ratelimit"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCClient serves svc over an in-memory connection, authenticating calls with the
+// API keys of keys and rate limiting them with limiter if not nil, and returns a client for it
+func newTestGRPCClient(t *testing.T, svc *service.OrderService, keys *service.APIKeyService, limiter *ratelimit.Limiter) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcserver.NewServer(svc, grpcserver.AuthConfig{Keys: keys}, limiter, 10*time.Millisecond)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial gRPC server: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Shutdown(context.Background())
+	})
+	return conn
+}
+
+// withTestAPIKey issues an API key and returns a context that sends it with gRPC calls
+func withTestAPIKey(t *testing.T, keys *service.APIKeyService, req *model.CreateAPIKeyRequest) context.Context {
+	t.Helper()
+	issued, err := keys.IssueKey(context.Background(), req)
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), grpcserver.APIKeyMetadataKey, issued.Key)
+}
+
+// TestGRPCOrderService tests the gRPC API against the order service
+func TestGRPCOrderService(t *testing.T) {
+	var stored *model.Order
+	var actor model.Actor
+	versions := []*model.Order{
+		{ID: "order-1", CustomerID: "customer-1", Status: model.OrderStatusPending, Version: 1},
+		{ID: "order-1", CustomerID: "customer-1", Status: model.OrderStatusPending, Version: 1},
+		{ID: "order-1", CustomerID: "customer-1", Status: model.OrderStatusCancelled, Version: 2},
+	}
+	mockRepo := &MockOrderRepository{
+		CreateFunc: func(ctx context.Context, order *model.Order, _ []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+			stored, actor = order, model.ActorFromContext(ctx)
+			return nil
+		},
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			if id != "order-1" {
+				return nil, repository.ErrOrderNotFound
+			}
+			order := versions[0]
+			if len(versions) > 1 {
+				versions = versions[1:]
+			}
+			return order, nil
+		},
+	}
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	conn := newTestGRPCClient(t, service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()), keys, nil)
+	client := orderv1.NewOrderServiceClient(conn)
+	ctx := withTestAPIKey(t, keys, &model.CreateAPIKeyRequest{Name: "platform", Role: auth.RoleService})
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "order.v1.OrderService"})
+	if err != nil || health.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Health check = %v, %v, want SERVING", health.GetStatus(), err)
+	}
+
+	created, err := client.CreateOrder(ctx, &orderv1.CreateOrderRequest{
+		CustomerId: "customer-1",
+		Items:      []*orderv1.CreateOrderItem{{ProductId: "product-456", Quantity: 2}},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder() unexpected error = %v", err)
+	}
+	if created.GetOrder().GetId() != stored.ID || created.GetOrder().GetTotalAmount().GetAmount() != 3998 {
+		t.Errorf("CreateOrder() = %+v, want stored order with total 3998", created.GetOrder())
+	}
+	if actor.Type != model.ActorTypeAPI || actor.Name != "gRPC /order.v1.OrderService/CreateOrder" {
+		t.Errorf("CreateOrder() actor = %+v, want gRPC API actor", actor)
+	}
+
+	// Validation errors carry the invalid fields
+	_, err = client.CreateOrder(ctx, &orderv1.CreateOrderRequest{Items: []*orderv1.CreateOrderItem{{ProductId: "product-456", Quantity: 1}}})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || len(st.Details()) != 1 {
+		t.Fatalf("CreateOrder() invalid request = %v, want InvalidArgument with details", err)
+	}
+	if br, ok := st.Details()[0].(*errdetails.BadRequest); !ok || br.GetFieldViolations()[0].GetField() != "customer_id" {
+		t.Errorf("CreateOrder() details = %v, want customer_id violation", st.Details())
+	}
+
+	if _, err := client.GetOrder(ctx, &orderv1.GetOrderRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetOrder() missing order error = %v, want NotFound", err)
+	}
+
+	// WatchOrder sends each new version once and ends at a final status
+	stream, err := client.WatchOrder(ctx, &orderv1.WatchOrderRequest{Id: "order-1"})
+	if err != nil {
+		t.Fatalf("WatchOrder() unexpected error = %v", err)
+	}
+	var statuses []string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("WatchOrder() unexpected error = %v", err)
+		}
+		statuses = append(statuses, resp.GetOrder().GetStatus())
+	}
+	if len(statuses) != 2 || statuses[0] != model.OrderStatusPending || statuses[1] != model.OrderStatusCancelled {
+		t.Errorf("WatchOrder() statuses = %v, want [pending cancelled]", statuses)
+	}
+}
+
+// TestGRPCAuthentication tests that gRPC calls require the credentials of an admin or service
+func TestGRPCAuthentication(t *testing.T) {
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			return &model.Order{ID: id, CustomerID: "customer-1", Status: model.OrderStatusPending, Version: 1}, nil
+		},
+		StreamFunc: func(_ context.Context, _ model.OrderFilter, _ *model.OrderCursor, _ func(*model.Order) error) error {
+			return nil
+		},
+	}
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	conn := newTestGRPCClient(t, service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()), keys, nil)
+	client := orderv1.NewOrderServiceClient(conn)
+
+	// Health checks stay reachable without credentials
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("Health check without credentials error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want codes.Code
+	}{
+		{"no credentials", context.Background(), codes.Unauthenticated},
+		{"unknown API key", metadata.AppendToOutgoingContext(context.Background(), grpcserver.APIKeyMetadataKey, "osk_unknown"), codes.Unauthenticated},
+		{"bearer token without JWKS", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer token"), codes.Unauthenticated},
+		{"customer key", withTestAPIKey(t, keys, &model.CreateAPIKeyRequest{Name: "shop", Role: auth.RoleCustomer, CustomerID: "customer-1"}), codes.PermissionDenied},
+		{"service key", withTestAPIKey(t, keys, &model.CreateAPIKeyRequest{Name: "platform", Role: auth.RoleService}), codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.GetOrder(tt.ctx, &orderv1.GetOrderRequest{Id: "order-1"}); status.Code(err) != tt.want {
+				t.Errorf("GetOrder() error = %v, want %s", err, tt.want)
+			}
+
+			stream, err := client.ListOrders(tt.ctx, &orderv1.ListOrdersRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if code := status.Code(err); code != tt.want && !(tt.want == codes.OK && errors.Is(err, io.EOF)) {
+				t.Errorf("ListOrders() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestGRPCTenantIsolation tests that callers bound to a tenant cannot reach another tenant's orders
+func TestGRPCTenantIsolation(t *testing.T) {
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(ctx context.Context, id string) (*model.Order, error) {
+			if tenantID, _ := model.TenantFromContext(ctx); tenantID != "globex" {
+				return nil, repository.ErrOrderNotFound
+			}
+			return &model.Order{ID: id, TenantID: "globex", CustomerID: "customer-1", Status: model.OrderStatusPending, Version: 1}, nil
+		},
+	}
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	conn := newTestGRPCClient(t, service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()), keys, nil)
+	client := orderv1.NewOrderServiceClient(conn)
+
+	acme := withTestAPIKey(t, keys, &model.CreateAPIKeyRequest{Name: "acme", Role: auth.RoleService, TenantID: "acme"})
+	platform := withTestAPIKey(t, keys, &model.CreateAPIKeyRequest{Name: "platform", Role: auth.RoleService})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		tenant string
+		want   codes.Code
+	}{
+		{"bound key reads its own tenant", acme, "", codes.NotFound},
+		{"bound key naming its tenant", acme, "acme", codes.NotFound},
+		{"bound key naming another tenant", acme, "globex", codes.PermissionDenied},
+		{"unbound key naming a tenant", platform, "globex", codes.OK},
+		{"unbound key without a tenant", platform, "", codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if tt.tenant != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, grpcserver.TenantMetadataKey, tt.tenant)
+			}
+			if _, err := client.GetOrder(ctx, &orderv1.GetOrderRequest{Id: "order-1"}); status.Code(err) != tt.want {
+				t.Errorf("GetOrder() error = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestGRPCRateLimiting tests that gRPC calls are taken from the same buckets as the HTTP
+// requests of their caller
+func TestGRPCRateLimiting(t *testing.T) {
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			return &model.Order{ID: id, CustomerID: "customer-1", Status: model.OrderStatusPending, Version: 1}, nil
+		},
+	}
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	limiter := ratelimit.NewLimiter(&MockRateLimiter{}, ratelimit.Config{
+		Default: model.RateLimit{Requests: 100, Period: time.Minute},
+		Routes:  map[string]model.RateLimit{"GET /orders/{id}/events": {Requests: 1, Period: time.Minute}},
+	})
+	conn := newTestGRPCClient(t, service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()), keys, limiter)
+	client := orderv1.NewOrderServiceClient(conn)
+
+	router := mux.NewRouter()
+	router.Use(handler.Authenticate(nil, keys, false))
+	router.Use(handler.RateLimit(limiter))
+	router.HandleFunc("/orders/{id}", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }).Methods("GET")
+
+	ctx := withTestAPIKey(t, keys, &model.CreateAPIKeyRequest{Name: "partner", Role: auth.RoleService, RateLimit: 3})
+	md, _ := metadata.FromOutgoingContext(ctx)
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/orders/order-1", nil)
+		req.Header.Set(handler.APIKeyHeader, md.Get(grpcserver.APIKeyMetadataKey)[0])
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	watch := func() error {
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stream, err := client.WatchOrder(watchCtx, &orderv1.WatchOrderRequest{Id: "order-1"})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		return err
+	}
+
+	// The limit of GET /orders/{id}/events allows one WatchOrder per client
+	if err := watch(); err != nil {
+		t.Fatalf("first WatchOrder() error = %v", err)
+	}
+	err := watch()
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second WatchOrder() error = %v, want %s", err, codes.ResourceExhausted)
+	}
+	var retryAfter time.Duration
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retryAfter = info.GetRetryDelay().AsDuration()
+		}
+	}
+	if retryAfter != time.Minute {
+		t.Errorf("second WatchOrder() retry delay = %s, want 1m", retryAfter)
+	}
+
+	// The key's own limit of 3 per minute is shared by both APIs
+	if rec := get(); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("GET = %d remaining %q, want 200 with 1 of 3 left", rec.Code, rec.Header().Get("RateLimit-Remaining"))
+	}
+	if _, err := client.GetOrder(ctx, &orderv1.GetOrderRequest{Id: "order-1"}); err != nil {
+		t.Errorf("GetOrder() error = %v", err)
+	}
+	if rec := get(); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("GET over key limit = %d, want 429", rec.Code)
+	}
+	if _, err := client.GetOrder(ctx, &orderv1.GetOrderRequest{Id: "order-1"}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("GetOrder() over key limit error = %v, want %s", err, codes.ResourceExhausted)
+	}
+}
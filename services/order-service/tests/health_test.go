@@ -0,0 +1,55 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/service"
+)
+
+// TestHealthCheckReportsConsumers tests that /health is degraded while an event consumer is
+// disconnected from the broker
+func TestHealthCheckReportsConsumers(t *testing.T) {
+	tests := []struct {
+		name         string
+		consumerErr  error
+		want         int
+		wantStatus   string
+		wantConsumer string
+	}{
+		{name: "connected", want: http.StatusOK, wantStatus: "healthy", wantConsumer: "healthy"},
+		{name: "reconnecting", consumerErr: errors.New("webhook consumer: reconnecting to queue 'webhooks.orders'"),
+			want: http.StatusServiceUnavailable, wantStatus: "degraded", wantConsumer: "unhealthy: webhook consumer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handler.NewOrderHandler(service.NewOrderService(&MockOrderRepository{}, &MockOrderCache{}, newTestCalculator()))
+			h.SetHealthChecker(&handler.HealthChecker{
+				ConsumerHealthFunc: func() error { return tt.consumerErr },
+			})
+
+			rec := httptest.NewRecorder()
+			h.HealthCheck(rec, httptest.NewRequest("GET", "/health", nil))
+			if rec.Code != tt.want {
+				t.Fatalf("GET /health = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+
+			var body struct {
+				Status string            `json:"status"`
+				Checks map[string]string `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode health response: %v", err)
+			}
+			if body.Status != tt.wantStatus || !strings.HasPrefix(body.Checks["consumers"], tt.wantConsumer) {
+				t.Errorf("health = %s with consumers %q, want %s with %q", body.Status, body.Checks["consumers"], tt.wantStatus, tt.wantConsumer)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// TestOrderHistoryHandler tests that GET /orders/{id}/history returns the entries oldest first,
+// 404 for unknown orders and only the history of their own orders to customers
+func TestOrderHistoryHandler(t *testing.T) {
+	createdAt := time.Date(2026, 1, 15, 10, 30, 0, 0, time.UTC)
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			if id != "order-123" {
+				return nil, repository.ErrOrderNotFound
+			}
+			return &model.Order{ID: id, CustomerID: "customer-123", Status: model.OrderStatusConfirmed, Version: 2}, nil
+		},
+		GetHistoryFunc: func(_ context.Context, id string) ([]*model.OrderHistoryEntry, error) {
+			if id != "order-123" {
+				return nil, repository.ErrOrderNotFound
+			}
+			return []*model.OrderHistoryEntry{
+				{OrderID: id, Status: model.OrderStatusPending, Version: 1,
+					Actor: model.Actor{Type: model.ActorTypeAPI, Name: "POST /orders"}, EventIDs: []string{"event-1"}, OccurredAt: createdAt},
+				{OrderID: id, PreviousStatus: model.OrderStatusPending, Status: model.OrderStatusConfirmed, Version: 2,
+					Actor: model.Actor{Type: model.ActorTypeAPI, Name: "POST /orders/order-123/confirm"}, EventIDs: []string{"event-2"}, OccurredAt: createdAt.Add(5 * time.Minute)},
+			}, nil
+		},
+	}
+	h := handler.NewOrderHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()))
+
+	tests := []struct {
+		name      string
+		id        string
+		principal *auth.Principal
+		want      int
+	}{
+		{name: "service", id: "order-123", principal: &auth.Principal{Subject: "platform", Role: auth.RoleService}, want: http.StatusOK},
+		{name: "owning customer", id: "order-123", principal: &auth.Principal{Subject: "user-1", Role: auth.RoleCustomer, CustomerID: "customer-123"}, want: http.StatusOK},
+		{name: "other customer", id: "order-123", principal: &auth.Principal{Subject: "user-2", Role: auth.RoleCustomer, CustomerID: "customer-456"}, want: http.StatusForbidden},
+		{name: "missing order", id: "order-missing", principal: &auth.Principal{Subject: "platform", Role: auth.RoleService}, want: http.StatusNotFound},
+		{name: "missing order as customer", id: "order-missing", principal: &auth.Principal{Subject: "user-1", Role: auth.RoleCustomer, CustomerID: "customer-123"}, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), tt.principal)))
+				})
+			})
+			router.HandleFunc("/orders/{id}/history", h.GetOrderHistory).Methods("GET")
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("GET", "/orders/"+tt.id+"/history", nil))
+			if rec.Code != tt.want {
+				t.Fatalf("GET /orders/%s/history = %d, want %d: %s", tt.id, rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+
+			var history model.OrderHistory
+			if err := json.NewDecoder(rec.Body).Decode(&history); err != nil {
+				t.Fatalf("failed to decode history: %v", err)
+			}
+			if history.OrderID != tt.id || len(history.Entries) != 2 {
+				t.Fatalf("history = %+v, want 2 entries of %s", history, tt.id)
+			}
+			first, second := history.Entries[0], history.Entries[1]
+			if first.Version != 1 || first.Status != model.OrderStatusPending || first.PreviousStatus != "" {
+				t.Errorf("first entry = %+v, want creation as pending", first)
+			}
+			if second.Version != 2 || second.PreviousStatus != model.OrderStatusPending || second.Status != model.OrderStatusConfirmed ||
+				second.Actor.Name != "POST /orders/order-123/confirm" || !second.OccurredAt.After(first.OccurredAt) {
+				t.Errorf("second entry = %+v, want confirmation after creation", second)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// MockIdempotencyStore is an in-memory implementation of IdempotencyStore
+type MockIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]model.IdempotencyRecord
+	// Extended counts extensions of reservations in progress, Stale those of completed records
+	Extended, Stale int
+}
+
+func (m *MockIdempotencyStore) Reserve(_ context.Context, key, requestHash string) (*model.IdempotencyRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.records == nil {
+		m.records = make(map[string]model.IdempotencyRecord)
+	}
+	if record, ok := m.records[key]; ok {
+		return &record, nil
+	}
+	m.records[key] = model.IdempotencyRecord{RequestHash: requestHash}
+	return nil, nil
+}
+
+func (m *MockIdempotencyStore) Extend(_ context.Context, key, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.records[key].Completed {
+		m.Stale++
+	} else {
+		m.Extended++
+	}
+	return nil
+}
+
+func (m *MockIdempotencyStore) Complete(_ context.Context, key string, record *model.IdempotencyRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record.Completed = true
+	m.records[key] = *record
+	return nil
+}
+
+func (m *MockIdempotencyStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
+
+// TestIdempotency tests replay, body mismatch and serialization of concurrent duplicates
+func TestIdempotency(t *testing.T) {
+	var calls int32
+	create := func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.Header().Set("Location", "/orders/order-"+strconv.Itoa(int(n)))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"order-` + strconv.Itoa(int(n)) + `"}`))
+	}
+	wrapped := handler.NewIdempotency(&MockIdempotencyStore{}).Wrap(create)
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(handler.IdempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		wrapped(rec, req)
+		return rec
+	}
+
+	t.Run("concurrent duplicates create one order", func(t *testing.T) {
+		var wg sync.WaitGroup
+		responses := make([]*httptest.ResponseRecorder, 5)
+		for i := range responses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				responses[i] = do("key-1", `{"customer_id":"c1"}`)
+			}(i)
+		}
+		wg.Wait()
+
+		if got := atomic.LoadInt32(&calls); got != 1 {
+			t.Fatalf("handler called %d times, want 1", got)
+		}
+		for _, rec := range responses {
+			if rec.Code != http.StatusCreated || rec.Body.String() != `{"id":"order-1"}` {
+				t.Errorf("response = %d %s, want 201 {\"id\":\"order-1\"}", rec.Code, rec.Body.String())
+			}
+		}
+	})
+
+	t.Run("repeat replays original response", func(t *testing.T) {
+		rec := do("key-1", `{"customer_id":"c1"}`)
+		if rec.Code != http.StatusCreated || rec.Body.String() != `{"id":"order-1"}` {
+			t.Errorf("response = %d %s, want replayed 201", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+			t.Errorf("replayed response missing %s header", handler.IdempotentReplayedHeader)
+		}
+		if etag, location := rec.Header().Get("ETag"), rec.Header().Get("Location"); etag != `"1"` || location != "/orders/order-1" {
+			t.Errorf("replayed ETag = %q, Location = %q, want \"1\" and /orders/order-1", etag, location)
+		}
+	})
+
+	t.Run("different body is rejected", func(t *testing.T) {
+		rec := do("key-1", `{"customer_id":"c2"}`)
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("status = %d, want 422", rec.Code)
+		}
+	})
+
+	t.Run("requests without key are not deduplicated", func(t *testing.T) {
+		before := atomic.LoadInt32(&calls)
+		do("", `{"customer_id":"c1"}`)
+		do("", `{"customer_id":"c1"}`)
+		if got := atomic.LoadInt32(&calls) - before; got != 2 {
+			t.Errorf("handler called %d times, want 2", got)
+		}
+	})
+}
+
+// TestIdempotencyExtendsReservation tests that the reservation of a slow request is extended
+// until it completes, and not after
+func TestIdempotencyExtendsReservation(t *testing.T) {
+	store := &MockIdempotencyStore{}
+	idempotency := handler.NewIdempotency(store)
+	idempotency.SetRenewInterval(10 * time.Millisecond)
+	wrapped := idempotency.Wrap(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusMultiStatus)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders:batch", strings.NewReader(`{"orders":[]}`))
+	req.Header.Set(handler.IdempotencyKeyHeader, "batch-1")
+	wrapped(httptest.NewRecorder(), req)
+	time.Sleep(30 * time.Millisecond)
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.Extended < 3 || store.Stale != 0 {
+		t.Errorf("reservation extended %d times while running and %d times after, want at least 3 and 0", store.Extended, store.Stale)
+	}
+}
@@ -0,0 +1,200 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/migrations"
+	"github.com/andev0x/pkg/migrate"
+)
+
+// newTestMigrator opens an empty SQLite database and a migrator with the migrations of source
+func newTestMigrator(t *testing.T, source fs.FS) (*repository.DB, *migrate.Migrator) {
+	t.Helper()
+
+	db, err := repository.InitDB(repository.DriverSQLite, "", "", "", "", filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatalf("InitDB(sqlite) error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	migrator, err := migrate.New(db.DB, repository.DriverSQLite, source)
+	if err != nil {
+		t.Fatalf("migrate.New() error = %v", err)
+	}
+	return db, migrator
+}
+
+// appliedVersions returns the versions Status reports as applied
+func appliedVersions(t *testing.T, migrator *migrate.Migrator) []int64 {
+	t.Helper()
+
+	statuses, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	var versions []int64
+	for _, status := range statuses {
+		if status.Applied {
+			versions = append(versions, status.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigratorAppliesAndRollsBackEmbeddedMigrations(t *testing.T) {
+	ctx := context.Background()
+	db, migrator := newTestMigrator(t, migrations.FS)
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil || len(statuses) == 0 {
+		t.Fatalf("Status() = %v, %v, want pending migrations", statuses, err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil || applied != len(statuses) {
+		t.Fatalf("Up() = %d, %v, want %d", applied, err, len(statuses))
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 0 {
+		t.Errorf("second Up() = %d, %v, want nothing to apply", applied, err)
+	}
+	if _, err := db.ExecContext(ctx, `SELECT COUNT(*) FROM orders`); err != nil {
+		t.Errorf("orders table missing after Up(): %v", err)
+	}
+
+	rolledBack, err := migrator.Down(ctx, len(statuses))
+	if err != nil || rolledBack != len(statuses) {
+		t.Fatalf("Down() = %d, %v, want %d", rolledBack, err, len(statuses))
+	}
+	if _, err := db.ExecContext(ctx, `SELECT COUNT(*) FROM orders`); err == nil {
+		t.Error("orders table still exists after Down()")
+	}
+	if versions := appliedVersions(t, migrator); len(versions) != 0 {
+		t.Errorf("applied versions after Down() = %v, want none", versions)
+	}
+
+	// The down scripts leave a database the up scripts can migrate again
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Errorf("Up() after Down() error = %v", err)
+	}
+}
+
+func TestMigratorUpDownInOrder(t *testing.T) {
+	ctx := context.Background()
+	_, migrator := newTestMigrator(t, fstest.MapFS{
+		"sqlite/002_second.up.sql":   {Data: []byte(`CREATE TABLE second (id INTEGER);`)},
+		"sqlite/002_second.down.sql": {Data: []byte(`DROP TABLE second;`)},
+		"sqlite/001_first.up.sql":    {Data: []byte(`CREATE TABLE first (id INTEGER);`)},
+		"sqlite/001_first.down.sql":  {Data: []byte(`DROP TABLE first;`)},
+		"sqlite/README.md":           {Data: []byte(`not a migration`)},
+		"mysql/001_other.up.sql":     {Data: []byte(`not for sqlite`)},
+	})
+
+	if applied, err := migrator.Up(ctx); err != nil || applied != 2 {
+		t.Fatalf("Up() = %d, %v, want 2", applied, err)
+	}
+	if rolledBack, err := migrator.Down(ctx, 1); err != nil || rolledBack != 1 {
+		t.Fatalf("Down(1) = %d, %v, want 1", rolledBack, err)
+	}
+	if versions := appliedVersions(t, migrator); len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("applied versions = %v, want [1]", versions)
+	}
+	if rolledBack, err := migrator.Down(ctx, 5); err != nil || rolledBack != 1 {
+		t.Errorf("Down(5) = %d, %v, want the 1 migration left", rolledBack, err)
+	}
+}
+
+func TestMigratorRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db, migrator := newTestMigrator(t, fstest.MapFS{
+		"sqlite/001_first.up.sql":  {Data: []byte(`CREATE TABLE first (id INTEGER);`)},
+		"sqlite/002_broken.up.sql": {Data: []byte(`CREATE TABLE second (id INTEGER); INSERT INTO missing VALUES (1);`)},
+	})
+
+	applied, err := migrator.Up(ctx)
+	if err == nil || applied != 1 {
+		t.Fatalf("Up() = %d, %v, want 1 applied and an error", applied, err)
+	}
+	if versions := appliedVersions(t, migrator); len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("applied versions = %v, want [1]", versions)
+	}
+	// SQLite rolls back schema changes, so the failed migration left nothing behind
+	if _, err := db.ExecContext(ctx, `SELECT COUNT(*) FROM second`); err == nil {
+		t.Error("table of the failed migration exists")
+	}
+}
+
+func TestMigratorForceClearsDirtyState(t *testing.T) {
+	ctx := context.Background()
+	db, migrator := newTestMigrator(t, fstest.MapFS{
+		"sqlite/001_first.up.sql":  {Data: []byte(`CREATE TABLE first (id INTEGER);`)},
+		"sqlite/002_second.up.sql": {Data: []byte(`CREATE TABLE IF NOT EXISTS second (id INTEGER);`)},
+	})
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// A migration that failed halfway on MySQL stays recorded as dirty
+	if _, err := db.ExecContext(ctx, `UPDATE schema_migrations SET dirty = TRUE WHERE version = 2`); err != nil {
+		t.Fatalf("failed to mark migration dirty: %v", err)
+	}
+	if _, err := migrator.Up(ctx); !errors.Is(err, migrate.ErrDirty) {
+		t.Errorf("Up() error = %v, want ErrDirty", err)
+	}
+	if _, err := migrator.Down(ctx, 1); !errors.Is(err, migrate.ErrDirty) {
+		t.Errorf("Down() error = %v, want ErrDirty", err)
+	}
+
+	if err := migrator.Force(ctx, 1); err != nil {
+		t.Fatalf("Force(1) error = %v", err)
+	}
+	if versions := appliedVersions(t, migrator); len(versions) != 1 || versions[0] != 1 {
+		t.Errorf("applied versions after Force(1) = %v, want [1]", versions)
+	}
+	if applied, err := migrator.Up(ctx); err != nil || applied != 1 {
+		t.Errorf("Up() after Force(1) = %d, %v, want 1", applied, err)
+	}
+
+	if err := migrator.Force(ctx, 0); err != nil {
+		t.Fatalf("Force(0) error = %v", err)
+	}
+	if versions := appliedVersions(t, migrator); len(versions) != 0 {
+		t.Errorf("applied versions after Force(0) = %v, want none", versions)
+	}
+	if err := migrator.Force(ctx, 3); err == nil {
+		t.Error("Force(3) of an unknown version succeeded")
+	}
+}
+
+func TestNewMigratorRejectsInvalidMigrations(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver string
+		source fstest.MapFS
+	}{
+		{"unsupported driver", "oracle", fstest.MapFS{"oracle/001_first.up.sql": {}}},
+		{"down without up", repository.DriverSQLite, fstest.MapFS{"sqlite/001_first.down.sql": {Data: []byte(`SELECT 1;`)}}},
+		{"two names for one version", repository.DriverSQLite, fstest.MapFS{
+			"sqlite/001_first.up.sql": {Data: []byte(`SELECT 1;`)},
+			"sqlite/001_other.up.sql": {Data: []byte(`SELECT 1;`)},
+		}},
+		{"missing directory", repository.DriverSQLite, fstest.MapFS{"mysql/001_first.up.sql": {}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := migrate.New(nil, tt.driver, tt.source); err == nil {
+				t.Error("New() succeeded, want an error")
+			}
+		})
+	}
+}
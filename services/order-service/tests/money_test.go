@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/andev0x/order-service/internal/model"
+)
+
+// TestParseMoney tests parsing decimal amounts into minor units
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{input: "19.99", currency: "USD", want: 1999},
+		{input: "0.1", currency: "USD", want: 10},
+		{input: "5", currency: "USD", want: 500},
+		{input: "1500", currency: "JPY", want: 1500},
+		{input: "1.234", currency: "KWD", want: 1234},
+		{input: "19.999", currency: "USD", wantErr: true},
+		{input: "1.5", currency: "JPY", wantErr: true},
+		{input: "abc", currency: "USD", wantErr: true},
+		{input: "1.00", currency: "XXX", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input+" "+tt.currency, func(t *testing.T) {
+			got, err := model.ParseMoney(tt.input, tt.currency)
+			if tt.wantErr {
+				if !errors.Is(err, model.ErrInvalidAmount) {
+					t.Errorf("ParseMoney() error = %v, want ErrInvalidAmount", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney() unexpected error = %v", err)
+			}
+			if got.Amount != tt.want || got.Currency != tt.currency {
+				t.Errorf("ParseMoney() = %v, want %d %s", got, tt.want, tt.currency)
+			}
+		})
+	}
+}
+
+// TestMoneyArithmetic tests rounding, currency checks and JSON compatibility
+func TestMoneyArithmetic(t *testing.T) {
+	if got, err := model.NewMoney(550, "USD").Percent(10); err != nil || got.Amount != 55 {
+		t.Errorf("Percent() = %d, %v, want 55", got.Amount, err)
+	}
+	if got, err := model.NewMoney(125, "USD").Percent(10); err != nil || got.Amount != 13 {
+		t.Errorf("Percent() = %d, %v, want 13 (half rounds away from zero)", got.Amount, err)
+	}
+	if got, err := model.NewMoney(-125, "USD").Percent(10); err != nil || got.Amount != -13 {
+		t.Errorf("Percent() = %d, %v, want -13 (half rounds away from zero)", got.Amount, err)
+	}
+	if _, err := model.NewMoney(100, "USD").Add(model.NewMoney(100, "EUR")); !errors.Is(err, model.ErrCurrencyMismatch) {
+		t.Errorf("Add() error = %v, want ErrCurrencyMismatch", err)
+	}
+	if got, err := model.NewMoney(1999, "USD").Mul(3); err != nil || got.Amount != 5997 {
+		t.Errorf("Mul() = %d, %v, want 5997", got.Amount, err)
+	}
+	if got := model.NewMoney(1999, "USD").String(); got != "19.99 USD" {
+		t.Errorf("String() = %q, want %q", got, "19.99 USD")
+	}
+
+	var legacy model.Money
+	if err := json.Unmarshal([]byte(`39.98`), &legacy); err != nil || legacy != model.NewMoney(3998, "USD") {
+		t.Errorf("Unmarshal(39.98) = %v, %v; want 3998 USD", legacy, err)
+	}
+}
+
+// TestMoneyOverflow tests that arithmetic past the int64 range fails with a validation error
+func TestMoneyOverflow(t *testing.T) {
+	huge := model.NewMoney(math.MaxInt64/2+1, "USD")
+	_, mulErr := huge.Mul(2)
+	_, addErr := huge.Add(huge)
+	_, subErr := model.NewMoney(math.MinInt64/2-1, "USD").Sub(huge)
+	_, percentErr := model.NewMoney(math.MaxInt64/1000, "USD").Percent(50)
+
+	for name, err := range map[string]error{"Mul": mulErr, "Add": addErr, "Sub": subErr, "Percent": percentErr} {
+		if !errors.Is(err, model.ErrAmountOverflow) || !errors.Is(err, model.ErrValidation) {
+			t.Errorf("%s() error = %v, want ErrAmountOverflow", name, err)
+		}
+	}
+
+	if got, err := model.NewMoney(math.MinInt64, "USD").Mul(-1); err == nil {
+		t.Errorf("Mul(-1) of the minimum amount = %d, want an overflow error", got.Amount)
+	}
+	if got, err := model.NewMoney(math.MaxInt64, "USD").Sub(model.NewMoney(0, "USD")); err != nil || got.Amount != math.MaxInt64 {
+		t.Errorf("Sub(0) of the maximum amount = %d, %v, want it unchanged", got.Amount, err)
+	}
+}
@@ -2,23 +2,46 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/andev0x/order-service/internal/cache"
 	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/pricing"
+	"github.com/andev0x/order-service/internal/repository"
 	"github.com/andev0x/order-service/internal/service"
 )
 
 // MockOrderRepository is a mock implementation of OrderRepository
 type MockOrderRepository struct {
-	CreateFunc  func(ctx context.Context, order *model.Order) error
-	GetByIDFunc func(ctx context.Context, id string) (*model.Order, error)
-	ListFunc    func(ctx context.Context, limit, offset int) ([]*model.Order, error)
+	CreateFunc      func(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error
+	CreateBatchFunc func(ctx context.Context, writes []*model.OrderWrite) error
+	GetByIDFunc     func(ctx context.Context, id string) (*model.Order, error)
+	GetEventsFunc   func(ctx context.Context, id string) ([]*model.OrderEvent, error)
+	GetHistoryFunc  func(ctx context.Context, id string) ([]*model.OrderHistoryEntry, error)
+	ListFunc        func(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, limit int) ([]*model.Order, error)
+	StreamFunc      func(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, fn func(*model.Order) error) error
+	UpdateFunc      func(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error
+
+	GetCustomerEventsAfterFunc func(ctx context.Context, customerID, afterEventID string, limit int) ([]*model.OrderEvent, error)
 }
 
-func (m *MockOrderRepository) Create(ctx context.Context, order *model.Order) error {
+func (m *MockOrderRepository) Create(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error {
 	if m.CreateFunc != nil {
-		return m.CreateFunc(ctx, order)
+		return m.CreateFunc(ctx, order, events, messages, entry)
+	}
+	return nil
+}
+
+func (m *MockOrderRepository) CreateBatch(ctx context.Context, writes []*model.OrderWrite) error {
+	if m.CreateBatchFunc != nil {
+		return m.CreateBatchFunc(ctx, writes)
 	}
 	return nil
 }
@@ -30,18 +53,116 @@ func (m *MockOrderRepository) GetByID(ctx context.Context, id string) (*model.Or
 	return nil, errors.New("not implemented")
 }
 
-func (m *MockOrderRepository) List(ctx context.Context, limit, offset int) ([]*model.Order, error) {
+func (m *MockOrderRepository) GetEvents(ctx context.Context, id string) ([]*model.OrderEvent, error) {
+	if m.GetEventsFunc != nil {
+		return m.GetEventsFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrderRepository) GetCustomerEventsAfter(ctx context.Context, customerID, afterEventID string, limit int) ([]*model.OrderEvent, error) {
+	if m.GetCustomerEventsAfterFunc != nil {
+		return m.GetCustomerEventsAfterFunc(ctx, customerID, afterEventID, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockOrderRepository) GetHistory(ctx context.Context, id string) ([]*model.OrderHistoryEntry, error) {
+	if m.GetHistoryFunc != nil {
+		return m.GetHistoryFunc(ctx, id)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *MockOrderRepository) List(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, limit int) ([]*model.Order, error) {
 	if m.ListFunc != nil {
-		return m.ListFunc(ctx, limit, offset)
+		return m.ListFunc(ctx, filter, after, limit)
 	}
 	return nil, errors.New("not implemented")
 }
 
+func (m *MockOrderRepository) Stream(ctx context.Context, filter model.OrderFilter, after *model.OrderCursor, fn func(*model.Order) error) error {
+	if m.StreamFunc != nil {
+		return m.StreamFunc(ctx, filter, after, fn)
+	}
+	return errors.New("not implemented")
+}
+
+func (m *MockOrderRepository) Update(ctx context.Context, order *model.Order, events []*model.OrderEvent, messages []*model.OutboxMessage, entry *model.OrderHistoryEntry) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ctx, order, events, messages, entry)
+	}
+	return nil
+}
+
+// MockProductRepository is an in-memory implementation of ProductRepository
+type MockProductRepository struct {
+	Products map[string]*model.Product
+}
+
+func (m *MockProductRepository) Create(_ context.Context, product *model.Product) error {
+	m.Products[product.ID] = product
+	return nil
+}
+
+func (m *MockProductRepository) GetByID(_ context.Context, id string) (*model.Product, error) {
+	if product, ok := m.Products[id]; ok {
+		return product, nil
+	}
+	return nil, repository.ErrProductNotFound
+}
+
+func (m *MockProductRepository) GetByIDs(_ context.Context, ids []string) (map[string]*model.Product, error) {
+	products := make(map[string]*model.Product)
+	for _, id := range ids {
+		if product, ok := m.Products[id]; ok {
+			products[id] = product
+		}
+	}
+	return products, nil
+}
+
+func (m *MockProductRepository) List(_ context.Context) ([]*model.Product, error) {
+	var products []*model.Product
+	for _, product := range m.Products {
+		products = append(products, product)
+	}
+	return products, nil
+}
+
+func (m *MockProductRepository) Update(_ context.Context, product *model.Product) error {
+	m.Products[product.ID] = product
+	return nil
+}
+
+func (m *MockProductRepository) Delete(_ context.Context, id string) error {
+	delete(m.Products, id)
+	return nil
+}
+
+// usd returns a pointer to an amount of US cents, for optional request fields
+func usd(cents int64) *model.Money {
+	m := model.NewMoney(cents, "USD")
+	return &m
+}
+
+// newTestCalculator returns a price calculator over a small fixed price table
+func newTestCalculator() *pricing.Calculator {
+	return pricing.NewCalculator(&MockProductRepository{Products: map[string]*model.Product{
+		"product-456": {ID: "product-456", UnitPrice: model.NewMoney(1999, "USD"), Active: true},
+		"product-789": {ID: "product-789", UnitPrice: model.NewMoney(550, "USD"), DiscountPercent: 10, Active: true},
+		"product-eur": {ID: "product-eur", UnitPrice: model.NewMoney(1000, "EUR"), Active: true},
+		"product-old": {ID: "product-old", UnitPrice: model.NewMoney(100, "USD"), Active: false},
+		"product-jet": {ID: "product-jet", UnitPrice: model.NewMoney(math.MaxInt64/100, "USD"), Active: true},
+	}})
+}
+
 // MockOrderCache is a mock implementation of OrderCache
 type MockOrderCache struct {
-	GetFunc    func(ctx context.Context, id string) (*model.Order, error)
-	SetFunc    func(ctx context.Context, order *model.Order) error
-	DeleteFunc func(ctx context.Context, id string) error
+	GetFunc         func(ctx context.Context, id string) (*model.Order, error)
+	SetFunc         func(ctx context.Context, order *model.Order) error
+	SetNotFoundFunc func(ctx context.Context, id string) error
+	DeleteFunc      func(ctx context.Context, id string) error
 }
 
 func (m *MockOrderCache) Get(ctx context.Context, id string) (*model.Order, error) {
@@ -58,29 +179,20 @@ func (m *MockOrderCache) Set(ctx context.Context, order *model.Order) error {
 	return nil
 }
 
-func (m *MockOrderCache) Delete(ctx context.Context, id string) error {
-	if m.DeleteFunc != nil {
-		return m.DeleteFunc(ctx, id)
+func (m *MockOrderCache) SetNotFound(ctx context.Context, id string) error {
+	if m.SetNotFoundFunc != nil {
+		return m.SetNotFoundFunc(ctx, id)
 	}
 	return nil
 }
 
-// MockEventPublisher is a mock implementation of EventPublisher
-type MockEventPublisher struct {
-	PublishOrderCreatedFunc func(ctx context.Context, event *model.OrderCreatedEvent) error
-}
-
-func (m *MockEventPublisher) PublishOrderCreated(ctx context.Context, event *model.OrderCreatedEvent) error {
-	if m.PublishOrderCreatedFunc != nil {
-		return m.PublishOrderCreatedFunc(ctx, event)
+func (m *MockOrderCache) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
 	}
 	return nil
 }
 
-func (m *MockEventPublisher) Close() error {
-	return nil
-}
-
 // TestCreateOrder tests the CreateOrder method
 func TestCreateOrder(t *testing.T) {
 	tests := []struct {
@@ -95,17 +207,62 @@ func TestCreateOrder(t *testing.T) {
 				CustomerID:  "customer-123",
 				ProductID:   "product-456",
 				Quantity:    2,
-				TotalAmount: 99.99,
+				TotalAmount: usd(3998),
 			},
 			wantErr: false,
 		},
+		{
+			name: "total disagrees with price table",
+			request: &model.CreateOrderRequest{
+				CustomerID:  "customer-123",
+				ProductID:   "product-456",
+				Quantity:    2,
+				TotalAmount: usd(1),
+			},
+			wantErr:     true,
+			errContains: "price mismatch",
+		},
+		{
+			name: "unit price disagrees with price table",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-456", Quantity: 1, UnitPrice: usd(1)},
+				},
+			},
+			wantErr:     true,
+			errContains: "items[0].unit_price",
+		},
+		{
+			name: "items priced in different currencies",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-456", Quantity: 1},
+					{ProductID: "product-eur", Quantity: 1},
+				},
+			},
+			wantErr:     true,
+			errContains: "currency mismatch",
+		},
+		{
+			name: "inactive product",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-old", Quantity: 1},
+				},
+			},
+			wantErr:     true,
+			errContains: "unknown product",
+		},
 		{
 			name: "missing customer_id",
 			request: &model.CreateOrderRequest{
 				CustomerID:  "",
 				ProductID:   "product-456",
 				Quantity:    2,
-				TotalAmount: 99.99,
+				TotalAmount: usd(9999),
 			},
 			wantErr:     true,
 			errContains: "customer_id",
@@ -116,7 +273,7 @@ func TestCreateOrder(t *testing.T) {
 				CustomerID:  "customer-123",
 				ProductID:   "",
 				Quantity:    2,
-				TotalAmount: 99.99,
+				TotalAmount: usd(9999),
 			},
 			wantErr:     true,
 			errContains: "product_id",
@@ -127,7 +284,7 @@ func TestCreateOrder(t *testing.T) {
 				CustomerID:  "customer-123",
 				ProductID:   "product-456",
 				Quantity:    0,
-				TotalAmount: 99.99,
+				TotalAmount: usd(9999),
 			},
 			wantErr:     true,
 			errContains: "quantity",
@@ -138,17 +295,86 @@ func TestCreateOrder(t *testing.T) {
 				CustomerID:  "customer-123",
 				ProductID:   "product-456",
 				Quantity:    2,
-				TotalAmount: 0,
+				TotalAmount: usd(0),
 			},
 			wantErr:     true,
 			errContains: "total_amount",
 		},
+		{
+			name: "invalid item quantity",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-456", Quantity: 1, UnitPrice: usd(1000)},
+					{ProductID: "product-789", Quantity: 0, UnitPrice: usd(500)},
+				},
+			},
+			wantErr:     true,
+			errContains: "items[1].quantity",
+		},
+		{
+			name: "item quantity over the limit",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-456", Quantity: model.MaxItemQuantity + 1},
+				},
+			},
+			wantErr:     true,
+			errContains: "items[0].quantity",
+		},
+		{
+			name: "legacy quantity over the limit",
+			request: &model.CreateOrderRequest{
+				CustomerID:  "customer-123",
+				ProductID:   "product-456",
+				Quantity:    model.MaxItemQuantity + 1,
+				TotalAmount: usd(9999),
+			},
+			wantErr:     true,
+			errContains: "quantity",
+		},
+		{
+			name: "line total overflows",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-jet", Quantity: 101},
+				},
+			},
+			wantErr:     true,
+			errContains: "amount out of range",
+		},
+		{
+			name: "order total overflows",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+				Items: []model.CreateOrderItemRequest{
+					{ProductID: "product-jet", Quantity: 60},
+					{ProductID: "product-jet", Quantity: 60},
+				},
+			},
+			wantErr:     true,
+			errContains: "order subtotal",
+		},
+		{
+			name: "no items",
+			request: &model.CreateOrderRequest{
+				CustomerID: "customer-123",
+			},
+			wantErr:     true,
+			errContains: "items",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			var events []*model.OrderEvent
+			var messages []*model.OutboxMessage
 			mockRepo := &MockOrderRepository{
-				CreateFunc: func(_ context.Context, _ *model.Order) error {
+				CreateFunc: func(_ context.Context, _ *model.Order, evts []*model.OrderEvent, msgs []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+					events = evts
+					messages = msgs
 					return nil
 				},
 			}
@@ -159,13 +385,7 @@ func TestCreateOrder(t *testing.T) {
 				},
 			}
 
-			mockPublisher := &MockEventPublisher{
-				PublishOrderCreatedFunc: func(_ context.Context, _ *model.OrderCreatedEvent) error {
-					return nil
-				},
-			}
-
-			svc := service.NewOrderService(mockRepo, mockCache, mockPublisher)
+			svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
 
 			order, err := svc.CreateOrder(context.Background(), tt.request)
 
@@ -187,6 +407,9 @@ func TestCreateOrder(t *testing.T) {
 						t.Errorf("CreateOrder() error = %v, want error containing %v", err, tt.errContains)
 					}
 				}
+				if err != nil && !errors.Is(err, model.ErrValidation) {
+					t.Errorf("CreateOrder() error = %v, want a validation error", err)
+				}
 			} else {
 				if err != nil {
 					t.Errorf("CreateOrder() unexpected error = %v", err)
@@ -197,11 +420,170 @@ func TestCreateOrder(t *testing.T) {
 				if order != nil && order.Status != model.OrderStatusPending {
 					t.Errorf("CreateOrder() status = %v, want %v", order.Status, model.OrderStatusPending)
 				}
+				if len(messages) != 1 || messages[0].RoutingKey != model.RoutingKeyOrderCreated {
+					t.Errorf("CreateOrder() did not write an order.created outbox message")
+				}
+				if len(events) != 1 || events[0].EventType != model.EventTypeOrderCreated || events[0].Version != 1 {
+					t.Errorf("CreateOrder() did not log an OrderCreated event at version 1")
+				}
 			}
 		})
 	}
 }
 
+// TestCreateOrderWithItems tests that line items are persisted and summarized on the order
+func TestCreateOrderWithItems(t *testing.T) {
+	var created *model.Order
+	mockRepo := &MockOrderRepository{
+		CreateFunc: func(_ context.Context, order *model.Order, _ []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+			created = order
+			return nil
+		},
+	}
+
+	svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+
+	order, err := svc.CreateOrder(context.Background(), &model.CreateOrderRequest{
+		CustomerID: "customer-123",
+		Items: []model.CreateOrderItemRequest{
+			{ProductID: "product-456", Quantity: 2},
+			{ProductID: "product-789", Quantity: 1, UnitPrice: usd(550)},
+		},
+		TotalAmount: usd(4493),
+	})
+	if err != nil {
+		t.Fatalf("CreateOrder() unexpected error = %v", err)
+	}
+	if created == nil || len(created.Items) != 2 {
+		t.Fatalf("CreateOrder() did not persist both items")
+	}
+	if order.Items[0].UnitPrice != model.NewMoney(1999, "USD") || order.Items[0].LineTotal != model.NewMoney(3998, "USD") {
+		t.Errorf("CreateOrder() item 0 = %v x %v, want 19.99 x 2 = 39.98", order.Items[0].UnitPrice, order.Items[0].LineTotal)
+	}
+	if order.Items[1].Discount != model.NewMoney(55, "USD") || order.Items[1].LineTotal != model.NewMoney(495, "USD") {
+		t.Errorf("CreateOrder() item 1 discount/total = %v/%v, want 0.55/4.95", order.Items[1].Discount, order.Items[1].LineTotal)
+	}
+	if order.TotalAmount != model.NewMoney(4493, "USD") {
+		t.Errorf("CreateOrder() total = %v, want 44.93", order.TotalAmount)
+	}
+	if order.ProductID != "product-456" || order.Quantity != 3 {
+		t.Errorf("CreateOrder() summary = %s x%d, want product-456 x3", order.ProductID, order.Quantity)
+	}
+}
+
+// TestCreateOrders tests batch creation with partial-failure and all-or-nothing semantics
+func TestCreateOrders(t *testing.T) {
+	reqs := []model.CreateOrderRequest{
+		{CustomerID: "customer-1", Items: []model.CreateOrderItemRequest{{ProductID: "product-456", Quantity: 1}}},
+		{CustomerID: "customer-2", Items: []model.CreateOrderItemRequest{{ProductID: "product-unknown", Quantity: 1}}},
+		{CustomerID: "customer-3", Items: []model.CreateOrderItemRequest{{ProductID: "product-789", Quantity: 2}}},
+	}
+
+	t.Run("partial", func(t *testing.T) {
+		var written []*model.OrderWrite
+		mockRepo := &MockOrderRepository{
+			CreateBatchFunc: func(_ context.Context, writes []*model.OrderWrite) error {
+				written = writes
+				return nil
+			},
+		}
+		svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+
+		results, err := svc.CreateOrders(context.Background(), reqs, false)
+		if err != nil {
+			t.Fatalf("CreateOrders() unexpected error = %v", err)
+		}
+		if results[0].Order == nil || results[2].Order == nil {
+			t.Errorf("CreateOrders() did not create the valid orders: %+v", results)
+		}
+		if !errors.Is(results[1].Err, model.ErrValidation) {
+			t.Errorf("CreateOrders() invalid order error = %v, want validation error", results[1].Err)
+		}
+		if len(written) != 2 || len(written[0].Messages) != 1 || written[0].History == nil {
+			t.Errorf("CreateOrders() wrote %d orders, want 2 with their outbox messages and history", len(written))
+		}
+	})
+
+	t.Run("partial with database errors", func(t *testing.T) {
+		var created []string
+		mockRepo := &MockOrderRepository{
+			CreateBatchFunc: func(_ context.Context, _ []*model.OrderWrite) error {
+				return errors.New("duplicate entry")
+			},
+			CreateFunc: func(_ context.Context, order *model.Order, _ []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+				if order.CustomerID == "customer-3" {
+					return errors.New("duplicate entry")
+				}
+				created = append(created, order.CustomerID)
+				return nil
+			},
+		}
+		svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+
+		results, err := svc.CreateOrders(context.Background(), reqs, false)
+		if err != nil {
+			t.Fatalf("CreateOrders() unexpected error = %v", err)
+		}
+		if results[0].Err != nil || results[0].Order == nil || len(created) != 1 || created[0] != "customer-1" {
+			t.Errorf("CreateOrders() first order = %+v, created %v, want customer-1 created", results[0], created)
+		}
+		if !errors.Is(results[1].Err, model.ErrValidation) {
+			t.Errorf("CreateOrders() invalid order error = %v, want validation error", results[1].Err)
+		}
+		if results[2].Err == nil || results[2].Order != nil {
+			t.Errorf("CreateOrders() rejected order = %+v, want the database error", results[2])
+		}
+	})
+
+	t.Run("atomic with a database error", func(t *testing.T) {
+		mockRepo := &MockOrderRepository{
+			CreateBatchFunc: func(_ context.Context, _ []*model.OrderWrite) error {
+				return errors.New("connection refused")
+			},
+			CreateFunc: func(_ context.Context, _ *model.Order, _ []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+				t.Errorf("CreateOrders() created an order of a failed atomic batch")
+				return nil
+			},
+		}
+		svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+
+		valid := []model.CreateOrderRequest{reqs[0], reqs[2]}
+		if _, err := svc.CreateOrders(context.Background(), valid, true); err == nil {
+			t.Errorf("CreateOrders() expected an error for a failed atomic batch")
+		}
+	})
+
+	t.Run("atomic", func(t *testing.T) {
+		mockRepo := &MockOrderRepository{
+			CreateBatchFunc: func(_ context.Context, _ []*model.OrderWrite) error {
+				t.Errorf("CreateOrders() wrote orders from a failed atomic batch")
+				return nil
+			},
+		}
+		svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+
+		results, err := svc.CreateOrders(context.Background(), reqs, true)
+		if err != nil {
+			t.Fatalf("CreateOrders() unexpected error = %v", err)
+		}
+		if !errors.Is(results[0].Err, service.ErrBatchAborted) || !errors.Is(results[2].Err, service.ErrBatchAborted) {
+			t.Errorf("CreateOrders() valid order errors = %v, %v, want ErrBatchAborted", results[0].Err, results[2].Err)
+		}
+		if !errors.Is(results[1].Err, model.ErrValidation) {
+			t.Errorf("CreateOrders() invalid order error = %v, want validation error", results[1].Err)
+		}
+	})
+
+	t.Run("too large", func(t *testing.T) {
+		svc := service.NewOrderService(&MockOrderRepository{}, &MockOrderCache{}, newTestCalculator())
+		svc.SetMaxBatchSize(2)
+
+		if _, err := svc.CreateOrders(context.Background(), reqs, false); !errors.Is(err, model.ErrValidation) {
+			t.Errorf("CreateOrders() oversized batch error = %v, want validation error", err)
+		}
+	})
+}
+
 // TestGetOrderByID tests the GetOrderByID method
 func TestGetOrderByID(t *testing.T) {
 	testOrder := &model.Order{
@@ -209,7 +591,7 @@ func TestGetOrderByID(t *testing.T) {
 		CustomerID:  "customer-123",
 		ProductID:   "product-456",
 		Quantity:    2,
-		TotalAmount: 99.99,
+		TotalAmount: model.NewMoney(9999, "USD"),
 		Status:      model.OrderStatusPending,
 	}
 
@@ -220,9 +602,7 @@ func TestGetOrderByID(t *testing.T) {
 				return testOrder, nil
 			},
 		}
-		mockPublisher := &MockEventPublisher{}
-
-		svc := service.NewOrderService(mockRepo, mockCache, mockPublisher)
+		svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
 
 		order, err := svc.GetOrderByID(context.Background(), "order-123")
 		if err != nil {
@@ -247,9 +627,7 @@ func TestGetOrderByID(t *testing.T) {
 				return nil
 			},
 		}
-		mockPublisher := &MockEventPublisher{}
-
-		svc := service.NewOrderService(mockRepo, mockCache, mockPublisher)
+		svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
 
 		order, err := svc.GetOrderByID(context.Background(), "order-123")
 		if err != nil {
@@ -259,4 +637,370 @@ func TestGetOrderByID(t *testing.T) {
 			t.Errorf("GetOrderByID() returned wrong order")
 		}
 	})
+
+	t.Run("db miss is cached as not found", func(t *testing.T) {
+		var notFound []string
+		mockRepo := &MockOrderRepository{
+			GetByIDFunc: func(_ context.Context, _ string) (*model.Order, error) {
+				return nil, repository.ErrOrderNotFound
+			},
+		}
+		mockCache := &MockOrderCache{
+			SetNotFoundFunc: func(_ context.Context, id string) error {
+				notFound = append(notFound, id)
+				return nil
+			},
+		}
+		svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
+
+		if _, err := svc.GetOrderByID(context.Background(), "order-404"); !errors.Is(err, model.ErrNotFound) {
+			t.Errorf("GetOrderByID() error = %v, want not found", err)
+		}
+		if len(notFound) != 1 || notFound[0] != "order-404" {
+			t.Errorf("SetNotFound() calls = %v, want [order-404]", notFound)
+		}
+	})
+
+	t.Run("cached not found skips db", func(t *testing.T) {
+		mockRepo := &MockOrderRepository{
+			GetByIDFunc: func(_ context.Context, _ string) (*model.Order, error) {
+				t.Error("GetByID() called for an order cached as not found")
+				return nil, repository.ErrOrderNotFound
+			},
+		}
+		mockCache := &MockOrderCache{
+			GetFunc: func(_ context.Context, _ string) (*model.Order, error) {
+				return nil, cache.ErrNotFound
+			},
+		}
+		svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
+
+		if _, err := svc.GetOrderByID(context.Background(), "order-404"); !errors.Is(err, model.ErrNotFound) {
+			t.Errorf("GetOrderByID() error = %v, want not found", err)
+		}
+	})
+}
+
+// TestGetOrderByIDCoalescesMisses tests that concurrent cache misses share one database read
+func TestGetOrderByIDCoalescesMisses(t *testing.T) {
+	const callers = 10
+	var reads atomic.Int32
+	release := make(chan struct{})
+	mockRepo := &MockOrderRepository{
+		GetByIDFunc: func(_ context.Context, id string) (*model.Order, error) {
+			reads.Add(1)
+			<-release
+			return &model.Order{ID: id, Status: model.OrderStatusPending}, nil
+		},
+	}
+	var waiting sync.WaitGroup
+	waiting.Add(callers)
+	mockCache := &MockOrderCache{
+		GetFunc: func(_ context.Context, _ string) (*model.Order, error) {
+			waiting.Done()
+			return nil, cache.ErrMiss
+		},
+	}
+	svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
+
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			order, err := svc.GetOrderByID(context.Background(), "order-123")
+			if err == nil && order.ID != "order-123" {
+				err = fmt.Errorf("got order %s", order.ID)
+			}
+			errs <- err
+		}()
+	}
+
+	// Give every caller time to join the read in flight before it completes
+	waiting.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("GetOrderByID() error = %v", err)
+		}
+	}
+	if n := reads.Load(); n != 1 {
+		t.Errorf("GetByID() called %d times, want 1", n)
+	}
+}
+
+// TestListOrders tests filter validation and keyset pagination
+func TestListOrders(t *testing.T) {
+	base := time.Date(2026, 1, 9, 12, 0, 0, 0, time.UTC)
+	var stored []*model.Order
+	for i := 0; i < 5; i++ {
+		stored = append(stored, &model.Order{ID: fmt.Sprintf("order-%d", 5-i), CreatedAt: base.Add(-time.Duration(i) * time.Minute)})
+	}
+
+	var gotFilter model.OrderFilter
+	mockRepo := &MockOrderRepository{
+		ListFunc: func(_ context.Context, filter model.OrderFilter, after *model.OrderCursor, limit int) ([]*model.Order, error) {
+			gotFilter = filter
+			start := 0
+			if after != nil {
+				for start < len(stored) && stored[start].ID != after.ID {
+					start++
+				}
+				start++
+			}
+			end := start + limit
+			if end > len(stored) {
+				end = len(stored)
+			}
+			return stored[start:end], nil
+		},
+	}
+	svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+	filter := model.OrderFilter{CustomerID: "customer-123", Status: model.OrderStatusPending}
+
+	var seen []string
+	cursor := ""
+	for pages := 0; pages < 3; pages++ {
+		page, err := svc.ListOrders(context.Background(), filter, cursor, 2)
+		if err != nil {
+			t.Fatalf("ListOrders() unexpected error = %v", err)
+		}
+		if page.Filters != filter || gotFilter != filter {
+			t.Errorf("ListOrders() filters = %+v, want %+v", page.Filters, filter)
+		}
+		for _, order := range page.Orders {
+			seen = append(seen, order.ID)
+		}
+		cursor = page.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	if fmt.Sprint(seen) != "[order-5 order-4 order-3 order-2 order-1]" || cursor != "" {
+		t.Errorf("ListOrders() pages = %v (next %q), want all five orders once", seen, cursor)
+	}
+
+	if _, err := svc.ListOrders(context.Background(), model.OrderFilter{}, "not-a-cursor", 2); !errors.Is(err, model.ErrInvalidQuery) {
+		t.Errorf("ListOrders() bad cursor error = %v, want ErrInvalidQuery", err)
+	}
+	if _, err := svc.ListOrders(context.Background(), model.OrderFilter{Status: "lost"}, "", 2); !errors.Is(err, model.ErrInvalidQuery) {
+		t.Errorf("ListOrders() bad status error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+// TestTransitionOrder tests the order lifecycle state machine
+func TestTransitionOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		fromStatus string
+		toStatus   string
+		version    int64
+		wantErr    error
+	}{
+		{name: "confirm pending", fromStatus: model.OrderStatusPending, toStatus: model.OrderStatusConfirmed},
+		{name: "cancel pending", fromStatus: model.OrderStatusPending, toStatus: model.OrderStatusCancelled},
+		{name: "ship confirmed", fromStatus: model.OrderStatusConfirmed, toStatus: model.OrderStatusShipped},
+		{name: "cancel confirmed", fromStatus: model.OrderStatusConfirmed, toStatus: model.OrderStatusCancelled},
+		{name: "deliver shipped", fromStatus: model.OrderStatusShipped, toStatus: model.OrderStatusDelivered},
+		{name: "refund delivered", fromStatus: model.OrderStatusDelivered, toStatus: model.OrderStatusRefunded},
+		{name: "ship pending", fromStatus: model.OrderStatusPending, toStatus: model.OrderStatusShipped, wantErr: model.ErrInvalidTransition},
+		{name: "cancel shipped", fromStatus: model.OrderStatusShipped, toStatus: model.OrderStatusCancelled, wantErr: model.ErrInvalidTransition},
+		{name: "confirm cancelled", fromStatus: model.OrderStatusCancelled, toStatus: model.OrderStatusConfirmed, wantErr: model.ErrInvalidTransition},
+		{name: "refund pending", fromStatus: model.OrderStatusPending, toStatus: model.OrderStatusRefunded, wantErr: model.ErrInvalidTransition},
+		{name: "stale version", fromStatus: model.OrderStatusPending, toStatus: model.OrderStatusConfirmed, version: 2, wantErr: repository.ErrVersionConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *model.Order
+			var events []*model.OrderEvent
+			var messages []*model.OutboxMessage
+			var entry *model.OrderHistoryEntry
+			deleted := ""
+
+			mockRepo := &MockOrderRepository{
+				GetEventsFunc: func(_ context.Context, id string) ([]*model.OrderEvent, error) {
+					imported, err := model.NewOrderEvent("event-1", id, 3, model.EventTypeOrderImported, time.Now(),
+						&model.OrderCreatedEvent{OrderID: id, CustomerID: "customer-123", Status: tt.fromStatus, Version: 3})
+					return []*model.OrderEvent{imported}, err
+				},
+				UpdateFunc: func(_ context.Context, order *model.Order, evts []*model.OrderEvent, msgs []*model.OutboxMessage, e *model.OrderHistoryEntry) error {
+					updated = order
+					events = evts
+					messages = msgs
+					entry = e
+					return nil
+				},
+			}
+			mockCache := &MockOrderCache{
+				DeleteFunc: func(_ context.Context, id string) error {
+					deleted = id
+					return nil
+				},
+			}
+
+			svc := service.NewOrderService(mockRepo, mockCache, newTestCalculator())
+
+			version := tt.version
+			if version == 0 {
+				version = 3
+			}
+
+			actor := model.Actor{Type: model.ActorTypeAPI, Name: "POST /orders/order-123/" + tt.toStatus}
+			ctx := model.WithActor(context.Background(), actor)
+
+			order, err := svc.TransitionOrder(ctx, "order-123", tt.toStatus, model.MatchVersion(version))
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("TransitionOrder() error = %v, want %v", err, tt.wantErr)
+				}
+				if updated != nil {
+					t.Errorf("TransitionOrder() updated order on rejected transition")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("TransitionOrder() unexpected error = %v", err)
+			}
+			if order.Status != tt.toStatus {
+				t.Errorf("TransitionOrder() status = %v, want %v", order.Status, tt.toStatus)
+			}
+			if updated == nil || updated.Status != tt.toStatus {
+				t.Errorf("TransitionOrder() did not persist new status")
+			}
+			if deleted != "order-123" {
+				t.Errorf("TransitionOrder() did not invalidate cache")
+			}
+			if len(events) != 1 || events[0].Version != 4 {
+				t.Errorf("TransitionOrder() did not log a single event at version 4")
+			}
+
+			if len(messages) != 1 {
+				t.Fatalf("TransitionOrder() wrote %d outbox messages, want 1", len(messages))
+			}
+			if messages[0].RoutingKey != model.StatusRoutingKey(tt.toStatus) {
+				t.Errorf("TransitionOrder() routing key = %v, want %v", messages[0].RoutingKey, model.StatusRoutingKey(tt.toStatus))
+			}
+
+			var event model.OrderStatusChangedEvent
+			if err := json.Unmarshal(messages[0].Payload, &event); err != nil {
+				t.Fatalf("TransitionOrder() wrote invalid payload: %v", err)
+			}
+			if event.PreviousStatus != tt.fromStatus || event.Status != tt.toStatus {
+				t.Errorf("TransitionOrder() event = %s -> %s, want %s -> %s",
+					event.PreviousStatus, event.Status, tt.fromStatus, tt.toStatus)
+			}
+
+			if entry == nil {
+				t.Fatalf("TransitionOrder() did not record history")
+			}
+			if entry.PreviousStatus != tt.fromStatus || entry.Status != tt.toStatus || entry.Version != 4 {
+				t.Errorf("TransitionOrder() history = %s -> %s at version %d, want %s -> %s at version 4",
+					entry.PreviousStatus, entry.Status, entry.Version, tt.fromStatus, tt.toStatus)
+			}
+			if entry.Actor != actor {
+				t.Errorf("TransitionOrder() history actor = %+v, want %+v", entry.Actor, actor)
+			}
+			if len(entry.EventIDs) != 1 || entry.EventIDs[0] != event.EventID {
+				t.Errorf("TransitionOrder() history event ids = %v, want [%s]", entry.EventIDs, event.EventID)
+			}
+		})
+	}
+}
+
+// TestReplayOrder tests that an order is rebuilt by folding its events
+func TestReplayOrder(t *testing.T) {
+	created := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	changed := created.Add(time.Hour)
+
+	createdEvent, err := model.NewOrderEvent("event-1", "order-123", 1, model.EventTypeOrderCreated, created,
+		&model.OrderCreatedEvent{OrderID: "order-123", CustomerID: "customer-123", Status: model.OrderStatusPending,
+			TotalAmount: model.NewMoney(1999, "USD"), Version: 1, CreatedAt: created})
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmedEvent, err := model.NewOrderEvent("event-2", "order-123", 2, model.EventTypeOrderStatusChanged, changed,
+		&model.OrderStatusChangedEvent{OrderID: "order-123", PreviousStatus: model.OrderStatusPending,
+			Status: model.OrderStatusConfirmed, Version: 2, ChangedAt: changed})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := model.ReplayOrder([]*model.OrderEvent{createdEvent, confirmedEvent})
+	if err != nil {
+		t.Fatalf("ReplayOrder() unexpected error = %v", err)
+	}
+	if order.Status != model.OrderStatusConfirmed || order.Version != 2 || order.TotalAmount.Amount != 1999 {
+		t.Errorf("ReplayOrder() = %s at version %d (%s), want confirmed at version 2 (19.99 USD)",
+			order.Status, order.Version, order.TotalAmount)
+	}
+	if !order.CreatedAt.Equal(created) || !order.UpdatedAt.Equal(changed) {
+		t.Errorf("ReplayOrder() timestamps = %v / %v, want %v / %v", order.CreatedAt, order.UpdatedAt, created, changed)
+	}
+
+	// Replaying out of order or with a gap must fail rather than guess
+	if _, err := model.ReplayOrder([]*model.OrderEvent{confirmedEvent, createdEvent}); !errors.Is(err, model.ErrCorruptEventStream) {
+		t.Errorf("ReplayOrder() out of order error = %v, want ErrCorruptEventStream", err)
+	}
+	confirmedEvent.Version = 3
+	if _, err := model.ReplayOrder([]*model.OrderEvent{createdEvent, confirmedEvent}); !errors.Is(err, model.ErrCorruptEventStream) {
+		t.Errorf("ReplayOrder() version gap error = %v, want ErrCorruptEventStream", err)
+	}
+}
+
+// TestExpirePendingOrders tests that stale pending orders are cancelled with an order.expired event
+func TestExpirePendingOrders(t *testing.T) {
+	cutoff := time.Now().Add(-time.Hour)
+	statuses := map[string]string{
+		"order-stale":     model.OrderStatusPending,
+		"order-confirmed": model.OrderStatusConfirmed,
+	}
+
+	var filter model.OrderFilter
+	var messages []*model.OutboxMessage
+	var entries []*model.OrderHistoryEntry
+	mockRepo := &MockOrderRepository{
+		ListFunc: func(_ context.Context, f model.OrderFilter, _ *model.OrderCursor, _ int) ([]*model.Order, error) {
+			filter = f
+			// order-confirmed was confirmed after the projection was read
+			return []*model.Order{{ID: "order-stale", Version: 1}, {ID: "order-confirmed", Version: 1}}, nil
+		},
+		GetEventsFunc: func(_ context.Context, id string) ([]*model.OrderEvent, error) {
+			imported, err := model.NewOrderEvent("event-"+id, id, 1, model.EventTypeOrderImported, cutoff,
+				&model.OrderCreatedEvent{OrderID: id, Status: statuses[id], Version: 1})
+			return []*model.OrderEvent{imported}, err
+		},
+		UpdateFunc: func(_ context.Context, _ *model.Order, _ []*model.OrderEvent, msgs []*model.OutboxMessage, e *model.OrderHistoryEntry) error {
+			messages = append(messages, msgs...)
+			entries = append(entries, e)
+			return nil
+		},
+	}
+
+	svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+
+	ctx := model.WithActor(context.Background(), model.Actor{Type: model.ActorTypeScheduler, Name: "order-expiry"})
+	expired, err := svc.ExpirePendingOrders(ctx, cutoff, 10)
+	if err != nil {
+		t.Fatalf("ExpirePendingOrders() unexpected error = %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("ExpirePendingOrders() expired %d orders, want 1", expired)
+	}
+	if filter.Status != model.OrderStatusPending || filter.CreatedTo == nil || !filter.CreatedTo.Equal(cutoff) {
+		t.Errorf("ExpirePendingOrders() listed with filter %+v, want pending orders created before %v", filter, cutoff)
+	}
+
+	if len(messages) != 1 || messages[0].AggregateID != "order-stale" {
+		t.Fatalf("ExpirePendingOrders() wrote %d outbox messages, want 1 for order-stale", len(messages))
+	}
+	if messages[0].RoutingKey != model.RoutingKeyOrderExpired || messages[0].EventType != model.EventTypeOrderExpired {
+		t.Errorf("ExpirePendingOrders() message = %s/%s, want %s/%s",
+			messages[0].EventType, messages[0].RoutingKey, model.EventTypeOrderExpired, model.RoutingKeyOrderExpired)
+	}
+	if entries[0].Status != model.OrderStatusCancelled || entries[0].Actor.Type != model.ActorTypeScheduler {
+		t.Errorf("ExpirePendingOrders() history = %s by %s, want cancelled by scheduler", entries[0].Status, entries[0].Actor.Type)
+	}
 }
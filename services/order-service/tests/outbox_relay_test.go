@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/outbox"
+)
+
+// MockOutboxRepository is a mock implementation of OutboxRepository
+type MockOutboxRepository struct {
+	Pending []*model.OutboxMessage
+	Sent    []int64
+	Failed  map[int64]time.Time
+	// SentAt holds the time messages that were already published were sent
+	SentAt map[int64]time.Time
+}
+
+func (m *MockOutboxRepository) ClaimPending(_ context.Context, limit int, _ time.Duration) ([]*model.OutboxMessage, error) {
+	if limit > len(m.Pending) {
+		limit = len(m.Pending)
+	}
+	claimed := m.Pending[:limit]
+	m.Pending = m.Pending[limit:]
+	return claimed, nil
+}
+
+func (m *MockOutboxRepository) MarkSent(_ context.Context, ids []int64) error {
+	m.Sent = append(m.Sent, ids...)
+	return nil
+}
+
+func (m *MockOutboxRepository) MarkFailed(_ context.Context, id int64, nextAttemptAt time.Time, _ error) error {
+	if m.Failed == nil {
+		m.Failed = make(map[int64]time.Time)
+	}
+	m.Failed[id] = nextAttemptAt
+	return nil
+}
+
+func (m *MockOutboxRepository) Backlog(_ context.Context) (*model.OutboxBacklog, error) {
+	return &model.OutboxBacklog{Pending: len(m.Pending)}, nil
+}
+
+func (m *MockOutboxRepository) DeleteSent(_ context.Context, sentBefore time.Time) (int64, error) {
+	var deleted int64
+	for id, sentAt := range m.SentAt {
+		if sentAt.Before(sentBefore) {
+			delete(m.SentAt, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// MockEventPublisher is a mock implementation of EventPublisher
+type MockEventPublisher struct {
+	PublishFunc func(ctx context.Context, msg *model.OutboxMessage) error
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, msg *model.OutboxMessage) error {
+	if m.PublishFunc != nil {
+		return m.PublishFunc(ctx, msg)
+	}
+	return nil
+}
+
+func (m *MockEventPublisher) PublishBatch(ctx context.Context, msgs []*model.OutboxMessage) []error {
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		errs[i] = m.Publish(ctx, msg)
+	}
+	return errs
+}
+
+func (m *MockEventPublisher) Close() error {
+	return nil
+}
+
+// TestRelayBatch tests that the relay marks published messages sent and schedules retries for failures
+func TestRelayBatch(t *testing.T) {
+	repo := &MockOutboxRepository{
+		Pending: []*model.OutboxMessage{
+			{ID: 1, EventID: "event-1", RoutingKey: model.RoutingKeyOrderCreated},
+			{ID: 2, EventID: "event-2", RoutingKey: model.RoutingKeyOrderCreated, Attempts: 2},
+			{ID: 3, EventID: "event-3", RoutingKey: model.StatusRoutingKey(model.OrderStatusConfirmed)},
+		},
+	}
+	publisher := &MockEventPublisher{
+		PublishFunc: func(_ context.Context, msg *model.OutboxMessage) error {
+			if msg.EventID == "event-2" {
+				return errors.New("broker unavailable")
+			}
+			return nil
+		},
+	}
+
+	config := outbox.DefaultConfig()
+	config.MinBackoff = time.Second
+	config.MaxBackoff = time.Minute
+	relay := outbox.NewRelay(repo, publisher, config)
+
+	before := time.Now()
+	n, err := relay.RelayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("RelayBatch() unexpected error = %v", err)
+	}
+	if n != 3 {
+		t.Errorf("RelayBatch() claimed %d messages, want 3", n)
+	}
+	if len(repo.Sent) != 2 || repo.Sent[0] != 1 || repo.Sent[1] != 3 {
+		t.Errorf("RelayBatch() sent = %v, want [1 3]", repo.Sent)
+	}
+
+	next, ok := repo.Failed[2]
+	if !ok {
+		t.Fatalf("RelayBatch() did not record failure for message 2")
+	}
+	// Third attempt: 1s * 2^2
+	if delay := next.Sub(before); delay < 4*time.Second || delay > 5*time.Second {
+		t.Errorf("RelayBatch() retry delay = %v, want ~4s", delay)
+	}
+}
+
+// TestRelayBackoff tests exponential backoff capping
+func TestRelayBackoff(t *testing.T) {
+	config := outbox.DefaultConfig()
+	config.MinBackoff = time.Second
+	config.MaxBackoff = 10 * time.Second
+	relay := outbox.NewRelay(&MockOutboxRepository{}, &MockEventPublisher{}, config)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 50, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := relay.Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// TestRelayPrune tests that only messages sent before the retention period are deleted
+func TestRelayPrune(t *testing.T) {
+	now := time.Now()
+	repo := &MockOutboxRepository{
+		SentAt: map[int64]time.Time{
+			1: now.Add(-10 * 24 * time.Hour),
+			2: now.Add(-8 * 24 * time.Hour),
+			3: now.Add(-time.Hour),
+		},
+	}
+
+	config := outbox.DefaultConfig()
+	config.Retention = 7 * 24 * time.Hour
+	relay := outbox.NewRelay(repo, &MockEventPublisher{}, config)
+
+	deleted, err := relay.Prune(context.Background())
+	if err != nil {
+		t.Fatalf("Prune() unexpected error = %v", err)
+	}
+	if _, kept := repo.SentAt[3]; deleted != 2 || len(repo.SentAt) != 1 || !kept {
+		t.Errorf("Prune() deleted %d, kept %v, want 2 deleted and message 3 kept", deleted, repo.SentAt)
+	}
+}
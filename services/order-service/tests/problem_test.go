@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// TestProblemResponses tests that domain errors map to status codes and RFC 7807 bodies
+func TestProblemResponses(t *testing.T) {
+	tests := []struct {
+		name       string
+		repoErr    error
+		method     string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{name: "order not found", repoErr: repository.ErrOrderNotFound, method: http.MethodGet, wantStatus: http.StatusNotFound},
+		{name: "database outage", repoErr: fmt.Errorf("failed to get order: %w", model.ErrUnavailable), method: http.MethodGet, wantStatus: http.StatusServiceUnavailable},
+		{name: "unexpected failure", repoErr: fmt.Errorf("failed to scan order"), method: http.MethodGet, wantStatus: http.StatusInternalServerError},
+		{name: "validation failure", method: http.MethodPost, body: `{"items": [{"product_id": "product-456", "quantity": 1}]}`, wantStatus: http.StatusBadRequest, wantField: "customer_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &MockOrderRepository{
+				GetByIDFunc: func(_ context.Context, _ string) (*model.Order, error) {
+					return nil, tt.repoErr
+				},
+			}
+			h := handler.NewOrderHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()))
+
+			rec := httptest.NewRecorder()
+			if tt.method == http.MethodGet {
+				req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/orders/order-123", nil), map[string]string{"id": "order-123"})
+				h.GetOrder(rec, req)
+			} else {
+				h.CreateOrder(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body)))
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", ct)
+			}
+
+			var problem handler.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid problem body: %v", err)
+			}
+			if problem.Status != tt.wantStatus || problem.Title != http.StatusText(tt.wantStatus) {
+				t.Errorf("problem = %+v, want status %d", problem, tt.wantStatus)
+			}
+			if tt.wantField != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tt.wantField) {
+				t.Errorf("problem errors = %+v, want field %s", problem.Errors, tt.wantField)
+			}
+		})
+	}
+}
+
+// TestCreateOrderRequestValidation tests that every invalid field is reported at once
+// and that unknown fields and oversized bodies are rejected
+func TestCreateOrderRequestValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields []string
+	}{
+		{
+			name:       "all field errors collected",
+			body:       `{"items": [{"product_id": "product-456", "quantity": 0}, {"quantity": 1, "unit_price": 0}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"customer_id", "items[0].quantity", "items[1].product_id", "items[1].unit_price"},
+		},
+		{
+			name:       "legacy field errors collected",
+			body:       `{"customer_id": "customer-123", "quantity": -1}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"product_id", "quantity", "total_amount"},
+		},
+		{
+			name:       "unknown field",
+			body:       `{"customer_id": "customer-123", "items": [{"product_id": "product-456", "quantity": 1}], "coupon": "FREE"}`,
+			wantStatus: http.StatusBadRequest,
+			wantFields: []string{"coupon"},
+		},
+		{
+			name:       "oversized body",
+			body:       `{"customer_id": "` + strings.Repeat("x", 2<<20) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handler.NewOrderHandler(service.NewOrderService(&MockOrderRepository{}, &MockOrderCache{}, newTestCalculator()))
+
+			rec := httptest.NewRecorder()
+			h.CreateOrder(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+
+			var problem handler.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("invalid problem body: %v", err)
+			}
+			var fields []string
+			for _, fe := range problem.Errors {
+				fields = append(fields, fe.Field)
+			}
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) && len(tt.wantFields) > 0 {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
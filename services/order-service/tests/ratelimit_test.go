@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/ratelimit"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// MockAPIKeyRepository is an in-memory implementation of APIKeyRepository
+type MockAPIKeyRepository struct {
+	Keys []*model.APIKey
+}
+
+func (m *MockAPIKeyRepository) Create(_ context.Context, key *model.APIKey) error {
+	m.Keys = append(m.Keys, key)
+	return nil
+}
+
+func (m *MockAPIKeyRepository) GetByHash(_ context.Context, hash string) (*model.APIKey, error) {
+	for _, key := range m.Keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+func (m *MockAPIKeyRepository) List(_ context.Context) ([]*model.APIKey, error) {
+	return m.Keys, nil
+}
+
+func (m *MockAPIKeyRepository) Revoke(_ context.Context, id string, at time.Time) (*model.APIKey, error) {
+	for _, key := range m.Keys {
+		if key.ID == id {
+			key.RevokedAt = &at
+			return key, nil
+		}
+	}
+	return nil, repository.ErrAPIKeyNotFound
+}
+
+// MockRateLimiter is an in-memory fixed-capacity implementation of RateLimiter that never refills
+type MockRateLimiter struct {
+	Taken map[string]int
+}
+
+func (m *MockRateLimiter) Allow(_ context.Context, key string, limit model.RateLimit) (*model.RateLimitResult, error) {
+	if m.Taken == nil {
+		m.Taken = make(map[string]int)
+	}
+	result := &model.RateLimitResult{Limit: limit, Reset: limit.Period}
+	if m.Taken[key] >= limit.Requests {
+		result.RetryAfter = limit.Period / time.Duration(limit.Requests)
+		return result, nil
+	}
+	m.Taken[key]++
+	result.Allowed = true
+	result.Remaining = limit.Requests - m.Taken[key]
+	return result, nil
+}
+
+// TestAPIKeysAndRateLimiting tests API key authentication, revocation and per-key and per-route rate limits
+func TestAPIKeysAndRateLimiting(t *testing.T) {
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	ctx := context.Background()
+
+	if _, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "partner", Role: auth.RoleCustomer}); err == nil {
+		t.Errorf("IssueKey() customer key without customer_id: expected error")
+	}
+	partner, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "partner", Role: auth.RoleService, RateLimit: 3})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	other, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "other", Role: auth.RoleService})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	if partner.Hash == "" || partner.Hash == partner.Key || partner.Prefix != partner.Key[:len(partner.Prefix)] {
+		t.Errorf("IssueKey() stored hash %q and prefix %q for key %q", partner.Hash, partner.Prefix, partner.Key)
+	}
+
+	router := mux.NewRouter()
+	router.Use(handler.Authenticate(nil, keys, false))
+	router.Use(handler.RateLimit(ratelimit.NewLimiter(&MockRateLimiter{}, ratelimit.Config{
+		Default: model.RateLimit{Requests: 100, Period: time.Minute},
+		Routes:  map[string]model.RateLimit{"POST /orders": {Requests: 1, Period: time.Minute}},
+	})))
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.HandleFunc("/orders", ok).Methods("GET", "POST")
+
+	send := func(method, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/orders", nil)
+		req.Header.Set(handler.APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("GET", "osk_unknown"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key = %d, want 401", rec.Code)
+	}
+
+	// The route limit allows one POST per client
+	if rec := send("POST", partner.Key); rec.Code != http.StatusOK {
+		t.Errorf("first POST = %d, want 200", rec.Code)
+	}
+	rec := send("POST", partner.Key)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("second POST = %d Retry-After %q, want 429 after 60s", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if rec := send("POST", other.Key); rec.Code != http.StatusOK {
+		t.Errorf("POST with another key = %d, want 200", rec.Code)
+	}
+
+	// The key's own limit of 3 per minute overrides the default across routes
+	rec = send("GET", partner.Key)
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "3" || rec.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("GET = %d limit %q remaining %q, want 200 with 1 of 3 left", rec.Code,
+			rec.Header().Get("RateLimit-Limit"), rec.Header().Get("RateLimit-Remaining"))
+	}
+	send("GET", partner.Key)
+	if rec := send("GET", partner.Key); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("GET over key limit = %d, want 429", rec.Code)
+	}
+
+	if _, err := keys.RevokeKey(ctx, other.ID); err != nil {
+		t.Fatalf("RevokeKey() unexpected error = %v", err)
+	}
+	if rec := send("GET", other.Key); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked key = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,646 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/migrations"
+	"github.com/andev0x/pkg/migrate"
+	"github.com/google/uuid"
+)
+
+// conformanceBackends are the databases the repository conformance suite runs against. A backend
+// is skipped unless its environment variable holds the data source name of a database the suite
+// may create tables in. MySQL data source names need parseTime=true&multiStatements=true.
+// SQLite always runs, on a new database file.
+var conformanceBackends = []struct {
+	driver string
+	env    string
+}{
+	{repository.DriverMySQL, "TEST_MYSQL_DSN"},
+	{repository.DriverPostgres, "TEST_POSTGRES_DSN"},
+	{repository.DriverSQLite, ""},
+}
+
+// TestRepositoryConformance runs the same repository tests against every database backend
+func TestRepositoryConformance(t *testing.T) {
+	for _, backend := range conformanceBackends {
+		t.Run(backend.driver, func(t *testing.T) {
+			var db *repository.DB
+			if backend.env == "" {
+				db = openSQLiteDB(t)
+			} else {
+				dsn := os.Getenv(backend.env)
+				if dsn == "" {
+					t.Skipf("%s is not set", backend.env)
+				}
+				db = openConformanceDB(t, backend.driver, dsn)
+			}
+
+			t.Run("OrderRepository", func(t *testing.T) { testOrderRepository(t, repository.NewSQLOrderRepository(db)) })
+			t.Run("OrderEventStore", func(t *testing.T) { testOrderEventStore(t, repository.NewSQLOrderRepository(db)) })
+			t.Run("OutboxRepository", func(t *testing.T) { testOutboxRepository(t, db) })
+			t.Run("LeaseRepository", func(t *testing.T) { testLeaseRepository(t, repository.NewSQLLeaseRepository(db)) })
+			t.Run("WebhookRepository", func(t *testing.T) { testWebhookRepository(t, repository.NewSQLWebhookRepository(db)) })
+		})
+	}
+}
+
+// openConformanceDB connects to a backend and applies its pending migrations
+func openConformanceDB(t *testing.T, driver, dsn string) *repository.DB {
+	t.Helper()
+
+	db, err := repository.OpenDB(driver, dsn)
+	if err != nil {
+		t.Fatalf("OpenDB(%s) error = %v", driver, err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	migrateUp(t, db, driver)
+	return db
+}
+
+// openSQLiteDB creates a migrated SQLite database in a temporary directory
+func openSQLiteDB(t *testing.T) *repository.DB {
+	t.Helper()
+
+	db, err := repository.InitDB(repository.DriverSQLite, "", "", "", "", filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatalf("InitDB(sqlite) error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	})
+
+	migrateUp(t, db, repository.DriverSQLite)
+	return db
+}
+
+// migrateUp applies the embedded migrations of driver to db
+func migrateUp(t *testing.T, db *repository.DB, driver string) {
+	t.Helper()
+
+	migrator, err := migrate.New(db.DB, driver, migrations.FS)
+	if err != nil {
+		t.Fatalf("migrate.New(%s) error = %v", driver, err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+}
+
+// conformanceTenant returns a context scoped to a tenant of its own, so runs against a shared
+// database do not see each other's rows
+func conformanceTenant() (context.Context, string) {
+	tenantID := "conformance-" + uuid.NewString()[:8]
+	return model.WithTenant(context.Background(), tenantID), tenantID
+}
+
+// newConformanceOrder builds a pending two-item order together with the writes that create it
+func newConformanceOrder(t *testing.T, tenantID, customerID string, createdAt time.Time) *model.OrderWrite {
+	t.Helper()
+
+	order := &model.Order{
+		ID:          uuid.NewString(),
+		TenantID:    tenantID,
+		CustomerID:  customerID,
+		ProductID:   "product-1",
+		Quantity:    3,
+		TotalAmount: model.NewMoney(2500, "EUR"),
+		Status:      model.OrderStatusPending,
+		Items: []model.OrderItem{
+			{ProductID: "product-1", Quantity: 1, UnitPrice: model.NewMoney(1000, "EUR"),
+				Discount: model.NewMoney(0, "EUR"), LineTotal: model.NewMoney(1000, "EUR")},
+			{ProductID: "product-2", Quantity: 2, UnitPrice: model.NewMoney(800, "EUR"),
+				Discount: model.NewMoney(100, "EUR"), LineTotal: model.NewMoney(1500, "EUR")},
+		},
+		Version:   1,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	created := &model.OrderCreatedEvent{
+		EventID:     uuid.NewString(),
+		OrderID:     order.ID,
+		TenantID:    tenantID,
+		CustomerID:  customerID,
+		ProductID:   order.ProductID,
+		Quantity:    order.Quantity,
+		TotalAmount: order.TotalAmount,
+		Status:      order.Status,
+		Items:       order.Items,
+		Version:     1,
+		CreatedAt:   createdAt,
+		EventType:   model.EventTypeOrderCreated,
+	}
+	event, err := model.NewOrderEvent(created.EventID, order.ID, 1, model.EventTypeOrderCreated, createdAt, created)
+	if err != nil {
+		t.Fatalf("NewOrderEvent() error = %v", err)
+	}
+	message, err := model.NewOutboxMessage(created.EventID, order.ID, model.EventTypeOrderCreated, model.RoutingKeyOrderCreated, created)
+	if err != nil {
+		t.Fatalf("NewOutboxMessage() error = %v", err)
+	}
+
+	return &model.OrderWrite{
+		Order:    order,
+		Events:   []*model.OrderEvent{event},
+		Messages: []*model.OutboxMessage{message},
+		History: &model.OrderHistoryEntry{
+			OrderID:    order.ID,
+			Status:     order.Status,
+			Version:    1,
+			Actor:      model.Actor{Type: model.ActorTypeAPI, Name: "POST /orders"},
+			EventIDs:   []string{created.EventID},
+			OccurredAt: createdAt,
+		},
+	}
+}
+
+// newConformanceStatusChange builds the writes that move order to status
+func newConformanceStatusChange(t *testing.T, order *model.Order, status string, at time.Time) ([]*model.OrderEvent, *model.OrderHistoryEntry) {
+	t.Helper()
+
+	changed := &model.OrderStatusChangedEvent{
+		EventID:        uuid.NewString(),
+		OrderID:        order.ID,
+		TenantID:       order.TenantID,
+		CustomerID:     order.CustomerID,
+		PreviousStatus: order.Status,
+		Status:         status,
+		Version:        order.Version + 1,
+		ChangedAt:      at,
+		EventType:      model.EventTypeOrderStatusChanged,
+	}
+	event, err := model.NewOrderEvent(changed.EventID, order.ID, changed.Version, model.EventTypeOrderStatusChanged, at, changed)
+	if err != nil {
+		t.Fatalf("NewOrderEvent() error = %v", err)
+	}
+	return []*model.OrderEvent{event}, &model.OrderHistoryEntry{
+		OrderID:        order.ID,
+		PreviousStatus: order.Status,
+		Status:         status,
+		Version:        changed.Version,
+		Actor:          model.Actor{Type: model.ActorTypeSystem},
+		EventIDs:       []string{changed.EventID},
+		OccurredAt:     at,
+	}
+}
+
+// assertSameOrder compares an order read back from a repository with the one written
+func assertSameOrder(t *testing.T, got, want *model.Order) {
+	t.Helper()
+
+	if got.ID != want.ID || got.TenantID != want.TenantID || got.CustomerID != want.CustomerID ||
+		got.ProductID != want.ProductID || got.Quantity != want.Quantity || got.TotalAmount != want.TotalAmount ||
+		got.Status != want.Status || got.Version != want.Version {
+		t.Errorf("order = %+v, want %+v", got, want)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("order timestamps = %v, %v, want %v, %v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+	}
+	if !reflect.DeepEqual(got.Items, want.Items) {
+		t.Errorf("order items = %+v, want %+v", got.Items, want.Items)
+	}
+}
+
+// assertSameJSON compares two JSON documents regardless of formatting and key order
+func assertSameJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &wantValue); err != nil {
+		t.Fatalf("invalid JSON %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+// testOrderRepository checks the behaviour every OrderRepository must share
+func testOrderRepository(t *testing.T, repo repository.OrderRepository) {
+	// MySQL stores order timestamps with second precision
+	base := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("create and read back", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		write := newConformanceOrder(t, tenantID, "customer-1", base)
+		if err := repo.Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, write.Order.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertSameOrder(t, got, write.Order)
+
+		events, err := repo.GetEvents(ctx, write.Order.ID)
+		if err != nil || len(events) != 1 {
+			t.Fatalf("GetEvents() = %d events, %v, want 1", len(events), err)
+		}
+		if events[0].EventID != write.Events[0].EventID || events[0].Version != 1 || events[0].ID == 0 {
+			t.Errorf("event = %+v, want %+v with a log position", events[0], write.Events[0])
+		}
+		assertSameJSON(t, events[0].Payload, write.Events[0].Payload)
+
+		history, err := repo.GetHistory(ctx, write.Order.ID)
+		if err != nil || len(history) != 1 {
+			t.Fatalf("GetHistory() = %d entries, %v, want 1", len(history), err)
+		}
+		if history[0].Actor != write.History.Actor || !reflect.DeepEqual(history[0].EventIDs, write.History.EventIDs) ||
+			history[0].PreviousStatus != "" {
+			t.Errorf("history entry = %+v, want %+v", history[0], write.History)
+		}
+	})
+
+	t.Run("missing and foreign orders are not found", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		write := newConformanceOrder(t, tenantID, "customer-1", base)
+		if err := repo.Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		otherCtx, _ := conformanceTenant()
+		if _, err := repo.GetByID(otherCtx, write.Order.ID); !errors.Is(err, repository.ErrOrderNotFound) {
+			t.Errorf("GetByID() from another tenant error = %v, want ErrOrderNotFound", err)
+		}
+		if _, err := repo.GetEvents(otherCtx, write.Order.ID); !errors.Is(err, repository.ErrOrderNotFound) {
+			t.Errorf("GetEvents() from another tenant error = %v, want ErrOrderNotFound", err)
+		}
+		if _, err := repo.GetByID(ctx, uuid.NewString()); !errors.Is(err, repository.ErrOrderNotFound) {
+			t.Errorf("GetByID() of a missing order error = %v, want ErrOrderNotFound", err)
+		}
+		if _, err := repo.GetByID(model.WithAllTenants(context.Background()), write.Order.ID); err != nil {
+			t.Errorf("GetByID() across tenants error = %v", err)
+		}
+	})
+
+	t.Run("update checks the version", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		write := newConformanceOrder(t, tenantID, "customer-1", base)
+		if err := repo.Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		order := write.Order
+		stale := *order
+		changedAt := base.Add(time.Minute)
+		events, entry := newConformanceStatusChange(t, order, model.OrderStatusConfirmed, changedAt)
+		order.Status, order.UpdatedAt = model.OrderStatusConfirmed, changedAt
+		if err := repo.Update(ctx, order, events, nil, entry); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if order.Version != 2 {
+			t.Errorf("Update() left version %d, want 2", order.Version)
+		}
+
+		got, err := repo.GetByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		assertSameOrder(t, got, order)
+
+		events, entry = newConformanceStatusChange(t, &stale, model.OrderStatusCancelled, changedAt)
+		stale.Status = model.OrderStatusCancelled
+		if err := repo.Update(ctx, &stale, events, nil, entry); !errors.Is(err, repository.ErrVersionConflict) {
+			t.Errorf("Update() of a stale version error = %v, want ErrVersionConflict", err)
+		}
+
+		missing := *order
+		missing.ID = uuid.NewString()
+		events, entry = newConformanceStatusChange(t, &missing, model.OrderStatusShipped, changedAt)
+		if err := repo.Update(ctx, &missing, events, nil, entry); !errors.Is(err, repository.ErrOrderNotFound) {
+			t.Errorf("Update() of a missing order error = %v, want ErrOrderNotFound", err)
+		}
+
+		history, err := repo.GetHistory(ctx, order.ID)
+		if err != nil || len(history) != 2 {
+			t.Fatalf("GetHistory() = %d entries, %v, want 2", len(history), err)
+		}
+		if history[1].PreviousStatus != model.OrderStatusPending || history[1].Status != model.OrderStatusConfirmed {
+			t.Errorf("history entry = %+v, want pending -> confirmed", history[1])
+		}
+	})
+
+	t.Run("list filters and paginates", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		var writes []*model.OrderWrite
+		for i := 0; i < 5; i++ {
+			customerID := "customer-a"
+			if i%2 == 1 {
+				customerID = "customer-b"
+			}
+			writes = append(writes, newConformanceOrder(t, tenantID, customerID, base.Add(time.Duration(i)*time.Second)))
+		}
+		if err := repo.CreateBatch(ctx, writes); err != nil {
+			t.Fatalf("CreateBatch() error = %v", err)
+		}
+
+		first, err := repo.List(ctx, model.OrderFilter{}, nil, 3)
+		if err != nil || len(first) != 3 {
+			t.Fatalf("List() = %d orders, %v, want 3", len(first), err)
+		}
+		rest, err := repo.List(ctx, model.OrderFilter{}, model.CursorAfter(first[2]), 3)
+		if err != nil || len(rest) != 2 {
+			t.Fatalf("List() after cursor = %d orders, %v, want 2", len(rest), err)
+		}
+		listed := append(first, rest...)
+		for i, order := range listed {
+			assertSameOrder(t, order, writes[len(writes)-1-i].Order)
+		}
+
+		filtered, err := repo.List(ctx, model.OrderFilter{CustomerID: "customer-b"}, nil, 10)
+		if err != nil || len(filtered) != 2 {
+			t.Fatalf("List(customer-b) = %d orders, %v, want 2", len(filtered), err)
+		}
+		from := base.Add(2 * time.Second)
+		ranged, err := repo.List(ctx, model.OrderFilter{CreatedFrom: &from, Currency: "EUR"}, nil, 10)
+		if err != nil || len(ranged) != 3 {
+			t.Errorf("List(created_from) = %d orders, %v, want 3", len(ranged), err)
+		}
+	})
+
+	t.Run("stream reads oldest first with items", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		writes := []*model.OrderWrite{
+			newConformanceOrder(t, tenantID, "customer-1", base),
+			newConformanceOrder(t, tenantID, "customer-1", base.Add(time.Second)),
+		}
+		writes[1].Order.Items = writes[1].Order.Items[:1]
+		if err := repo.CreateBatch(ctx, writes); err != nil {
+			t.Fatalf("CreateBatch() error = %v", err)
+		}
+
+		var streamed []*model.Order
+		err := repo.Stream(ctx, model.OrderFilter{}, nil, func(order *model.Order) error {
+			streamed = append(streamed, order)
+			return nil
+		})
+		if err != nil || len(streamed) != 2 {
+			t.Fatalf("Stream() = %d orders, %v, want 2", len(streamed), err)
+		}
+		for i, order := range streamed {
+			assertSameOrder(t, order, writes[i].Order)
+		}
+
+		streamed = nil
+		err = repo.Stream(ctx, model.OrderFilter{}, model.CursorAfter(writes[0].Order), func(order *model.Order) error {
+			streamed = append(streamed, order)
+			return nil
+		})
+		if err != nil || len(streamed) != 1 || streamed[0].ID != writes[1].Order.ID {
+			t.Errorf("Stream() after cursor = %d orders, %v, want the second order", len(streamed), err)
+		}
+	})
+
+	t.Run("stream leaves the database to other requests", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		exported := newConformanceOrder(t, tenantID, "customer-1", base)
+		if err := repo.Create(ctx, exported.Order, exported.Events, exported.Messages, exported.History); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+
+		// Requests made while the export holds its cursor open must not wait for it to finish
+		err := repo.Stream(ctx, model.OrderFilter{}, nil, func(order *model.Order) error {
+			if order.ID != exported.Order.ID {
+				return nil
+			}
+			reqCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+
+			if _, err := repo.GetByID(reqCtx, order.ID); err != nil {
+				return fmt.Errorf("GetByID() during export: %w", err)
+			}
+			if _, err := repo.List(reqCtx, model.OrderFilter{}, nil, 10); err != nil {
+				return fmt.Errorf("List() during export: %w", err)
+			}
+			write := newConformanceOrder(t, tenantID, "customer-2", base.Add(time.Second))
+			if err := repo.Create(reqCtx, write.Order, write.Events, write.Messages, write.History); err != nil {
+				return fmt.Errorf("Create() during export: %w", err)
+			}
+			events, entry := newConformanceStatusChange(t, order, model.OrderStatusConfirmed, base.Add(time.Minute))
+			order.Status = model.OrderStatusConfirmed
+			if err := repo.Update(reqCtx, order, events, nil, entry); err != nil {
+				return fmt.Errorf("Update() during export: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Stream() error = %v", err)
+		}
+
+		got, err := repo.GetByID(ctx, exported.Order.ID)
+		if err != nil || got.Status != model.OrderStatusConfirmed {
+			t.Errorf("GetByID() after export = %+v, %v, want the confirmed order", got, err)
+		}
+	})
+
+	t.Run("customer events after a log position", func(t *testing.T) {
+		ctx, tenantID := conformanceTenant()
+		write := newConformanceOrder(t, tenantID, "customer-1", base)
+		if err := repo.Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		events, entry := newConformanceStatusChange(t, write.Order, model.OrderStatusConfirmed, base.Add(time.Minute))
+		write.Order.Status = model.OrderStatusConfirmed
+		if err := repo.Update(ctx, write.Order, events, nil, entry); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+
+		after, err := repo.GetCustomerEventsAfter(ctx, "customer-1", write.Events[0].EventID, 10)
+		if err != nil || len(after) != 1 || after[0].EventID != events[0].EventID {
+			t.Errorf("GetCustomerEventsAfter() = %d events, %v, want the status change", len(after), err)
+		}
+		unknown, err := repo.GetCustomerEventsAfter(ctx, "customer-1", uuid.NewString(), 10)
+		if err != nil || len(unknown) != 0 {
+			t.Errorf("GetCustomerEventsAfter(unknown event) = %d events, %v, want none", len(unknown), err)
+		}
+	})
+}
+
+// testOrderEventStore checks that projections are rebuilt the same way on every backend
+func testOrderEventStore(t *testing.T, store *repository.SQLOrderRepository) {
+	base := time.Now().UTC().Truncate(time.Second)
+	ctx, tenantID := conformanceTenant()
+
+	write := newConformanceOrder(t, tenantID, "customer-1", base)
+	if err := store.Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	ids, err := store.ListOrderIDs(ctx, "", 10)
+	if err != nil || len(ids) != 1 || ids[0] != write.Order.ID {
+		t.Fatalf("ListOrderIDs() = %v, %v, want [%s]", ids, err, write.Order.ID)
+	}
+
+	// Overwrite an existing projection
+	rebuilt := *write.Order
+	rebuilt.Status = model.OrderStatusConfirmed
+	rebuilt.Version = 2
+	rebuilt.Items = rebuilt.Items[1:]
+	rebuilt.UpdatedAt = base.Add(time.Minute)
+	if err := store.SaveProjection(ctx, &rebuilt); err != nil {
+		t.Fatalf("SaveProjection() error = %v", err)
+	}
+	got, err := store.GetByID(ctx, rebuilt.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	assertSameOrder(t, got, &rebuilt)
+
+	// Insert a missing one
+	missing := newConformanceOrder(t, tenantID, "customer-2", base).Order
+	if err := store.SaveProjection(ctx, missing); err != nil {
+		t.Fatalf("SaveProjection() of a missing order error = %v", err)
+	}
+	got, err = store.GetByID(ctx, missing.ID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	assertSameOrder(t, got, missing)
+}
+
+// testOutboxRepository checks that only messages sent before the given time are deleted
+func testOutboxRepository(t *testing.T, db *repository.DB) {
+	ctx, tenantID := conformanceTenant()
+	outbox := repository.NewSQLOutboxRepository(db)
+	write := newConformanceOrder(t, tenantID, "customer-1", time.Now().UTC())
+	if err := repository.NewSQLOrderRepository(db).Create(ctx, write.Order, write.Events, write.Messages, write.History); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	eventID := write.Messages[0].EventID
+
+	// Other runs against a shared database may have pending messages of their own
+	claimed, err := outbox.ClaimPending(ctx, 1000, time.Minute)
+	if err != nil {
+		t.Fatalf("ClaimPending() error = %v", err)
+	}
+	var id int64
+	for _, msg := range claimed {
+		if msg.EventID == eventID {
+			id = msg.ID
+		}
+	}
+	if id == 0 {
+		t.Fatalf("ClaimPending() = %d messages without %s", len(claimed), eventID)
+	}
+	if err := outbox.MarkSent(ctx, []int64{id}); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+
+	stored := func() int {
+		t.Helper()
+		var n int
+		if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE event_id = ?`, eventID).Scan(&n); err != nil {
+			t.Fatalf("count outbox messages: %v", err)
+		}
+		return n
+	}
+
+	if _, err := outbox.DeleteSent(ctx, time.Now().Add(-time.Hour)); err != nil || stored() != 1 {
+		t.Fatalf("DeleteSent() before the message was sent = %v, want it kept", err)
+	}
+	if _, err := outbox.DeleteSent(ctx, time.Now().Add(time.Hour)); err != nil || stored() != 0 {
+		t.Errorf("DeleteSent() after the message was sent = %v, want it deleted", err)
+	}
+}
+
+// testLeaseRepository checks that a lease is held by one holder at a time
+func testLeaseRepository(t *testing.T, leases repository.LeaseRepository) {
+	ctx := context.Background()
+	name := "conformance-" + uuid.NewString()[:8]
+
+	acquire := func(holder string, ttl time.Duration, want bool) {
+		t.Helper()
+		got, err := leases.Acquire(ctx, name, holder, ttl)
+		if err != nil || got != want {
+			t.Fatalf("Acquire(%s) = %v, %v, want %v", holder, got, err, want)
+		}
+	}
+
+	acquire("replica-a", time.Minute, true)
+	acquire("replica-b", time.Minute, false)
+	acquire("replica-a", time.Minute, true)
+
+	if err := leases.Release(ctx, name, "replica-b"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	acquire("replica-b", time.Minute, false)
+
+	if err := leases.Release(ctx, name, "replica-a"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	acquire("replica-b", time.Millisecond, true)
+
+	time.Sleep(50 * time.Millisecond)
+	acquire("replica-a", time.Minute, true)
+}
+
+// testWebhookRepository checks subscriptions and that deliveries of an event are only created once
+func testWebhookRepository(t *testing.T, repo *repository.SQLWebhookRepository) {
+	ctx, tenantID := conformanceTenant()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	sub := &model.WebhookSubscription{
+		ID:         uuid.NewString(),
+		TenantID:   tenantID,
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{model.RoutingKeyOrderCreated},
+		Secret:     "whsec_conformance",
+		Active:     true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := repo.CreateSubscription(ctx, sub); err != nil {
+		t.Fatalf("CreateSubscription() error = %v", err)
+	}
+
+	matching, err := repo.MatchingSubscriptions(ctx, tenantID, model.RoutingKeyOrderCreated)
+	if err != nil || len(matching) != 1 || !reflect.DeepEqual(matching[0].EventTypes, sub.EventTypes) {
+		t.Fatalf("MatchingSubscriptions() = %v, %v, want the subscription", matching, err)
+	}
+
+	delivery := func() *model.WebhookDelivery {
+		return &model.WebhookDelivery{
+			ID:             uuid.NewString(),
+			SubscriptionID: sub.ID,
+			EventID:        "event-1",
+			EventType:      model.RoutingKeyOrderCreated,
+			Payload:        json.RawMessage(`{"order_id":"order-1"}`),
+			CreatedAt:      now,
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := repo.CreateDeliveries(ctx, []*model.WebhookDelivery{delivery()}); err != nil {
+			t.Fatalf("CreateDeliveries() error = %v", err)
+		}
+	}
+
+	deliveries, err := repo.ListDeliveries(ctx, sub.ID, "", 10)
+	if err != nil || len(deliveries) != 1 {
+		t.Fatalf("ListDeliveries() = %d deliveries, %v, want 1", len(deliveries), err)
+	}
+	if deliveries[0].Status != model.WebhookDeliveryPending {
+		t.Errorf("delivery status = %q, want %q", deliveries[0].Status, model.WebhookDeliveryPending)
+	}
+	assertSameJSON(t, deliveries[0].Payload, []byte(`{"order_id":"order-1"}`))
+}
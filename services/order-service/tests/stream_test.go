@@ -0,0 +1,303 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/andev0x/order-service/internal/stream"
+	"github.com/gorilla/mux"
+)
+
+// sseEvent is one Server-Sent Event read from a stream
+type sseEvent struct {
+	ID     string
+	Event  string
+	Data   string
+	Update model.OrderStatusUpdate
+}
+
+// readSSE reads events and heartbeat comments from a stream into a channel until it ends
+func readSSE(t *testing.T, resp *http.Response) <-chan sseEvent {
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var event sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.Data != "" {
+					if err := json.Unmarshal([]byte(event.Data), &event.Update); err != nil {
+						t.Errorf("malformed event data %q: %v", event.Data, err)
+					}
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, ": "):
+				events <- sseEvent{Event: "heartbeat"}
+			case strings.HasPrefix(line, "id: "):
+				event.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return events
+}
+
+// nextUpdate returns the next status event from a stream, skipping heartbeats
+func nextUpdate(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("stream ended, want a status event")
+			}
+			if event.Event != "heartbeat" {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("no status event within 2s")
+		}
+	}
+}
+
+// statusEvents returns the event log of an order moved through the given statuses
+func statusEvents(t *testing.T, orderID, customerID string, statuses ...string) []*model.OrderEvent {
+	created, err := model.NewOrderEvent("event-1", orderID, 1, model.EventTypeOrderCreated, time.Now(),
+		&model.OrderCreatedEvent{EventID: "event-1", OrderID: orderID, CustomerID: customerID, Status: model.OrderStatusPending, Version: 1})
+	if err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	events := []*model.OrderEvent{created}
+	previous := model.OrderStatusPending
+	for i, status := range statuses {
+		version := int64(i + 2)
+		eventID := fmt.Sprintf("event-%d", version)
+		changed, err := model.NewOrderEvent(eventID, orderID, version, model.EventTypeOrderStatusChanged, time.Now(),
+			&model.OrderStatusChangedEvent{EventID: eventID, OrderID: orderID, CustomerID: customerID,
+				PreviousStatus: previous, Status: status, Version: version, EventType: model.EventTypeOrderStatusChanged})
+		if err != nil {
+			t.Fatalf("failed to create event: %v", err)
+		}
+		events = append(events, changed)
+		previous = status
+	}
+	return events
+}
+
+// TestOrderEventStream tests that an order stream resumes after Last-Event-ID, pushes live status
+// changes once each and sends heartbeats
+func TestOrderEventStream(t *testing.T) {
+	mockRepo := &MockOrderRepository{
+		GetEventsFunc: func(_ context.Context, id string) ([]*model.OrderEvent, error) {
+			if id != "order-1" {
+				return nil, repository.ErrOrderNotFound
+			}
+			return statusEvents(t, id, "customer-1", model.OrderStatusConfirmed), nil
+		},
+	}
+	hub := stream.NewHub(stream.DefaultBufferSize)
+	h := handler.NewStreamHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()), hub, 20*time.Millisecond)
+	router := mux.NewRouter()
+	router.HandleFunc("/orders/{id}/events", h.OrderEvents).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer hub.Close()
+
+	open := func(id, lastEventID string) *http.Response {
+		req, _ := http.NewRequest("GET", server.URL+"/orders/"+id+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET /orders/%s/events: %v", id, err)
+		}
+		return resp
+	}
+
+	missing := open("order-missing", "")
+	missing.Body.Close()
+	if missing.StatusCode != http.StatusNotFound {
+		t.Errorf("GET events of a missing order = %d, want %d", missing.StatusCode, http.StatusNotFound)
+	}
+
+	fresh := open("order-1", "")
+	defer fresh.Body.Close()
+	if ct := fresh.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if event := nextUpdate(t, readSSE(t, fresh)); event.ID != "event-2" || event.Update.Status != model.OrderStatusConfirmed {
+		t.Errorf("first event of a new stream = %+v, want the current status", event)
+	}
+
+	resumed := open("order-1", "event-1")
+	defer resumed.Body.Close()
+	events := readSSE(t, resumed)
+	if event := nextUpdate(t, events); event.ID != "event-2" || event.Update.PreviousStatus != model.OrderStatusPending {
+		t.Errorf("first event after Last-Event-ID event-1 = %+v, want event-2", event)
+	}
+
+	publish := func(eventID, status string, version int64, orderID string) {
+		body, _ := json.Marshal(&model.OrderStatusChangedEvent{EventID: eventID, OrderID: orderID, CustomerID: "customer-1",
+			Status: status, Version: version, EventType: model.EventTypeOrderStatusChanged})
+		if err := hub.HandleEvent(context.Background(), model.StatusRoutingKey(status), body); err != nil {
+			t.Fatalf("HandleEvent() unexpected error = %v", err)
+		}
+	}
+	publish("event-2", model.OrderStatusConfirmed, 2, "order-1") // already replayed
+	publish("event-x", model.OrderStatusShipped, 2, "order-2")   // another order
+	publish("event-3", model.OrderStatusShipped, 3, "order-1")
+
+	if event := nextUpdate(t, events); event.ID != "event-3" || event.Update.Status != model.OrderStatusShipped {
+		t.Errorf("live event = %+v, want event-3", event)
+	}
+
+	heartbeat := time.After(2 * time.Second)
+	for got := false; !got; {
+		select {
+		case event := <-events:
+			if event.Event != "heartbeat" {
+				t.Fatalf("unexpected event %+v, want only heartbeats", event)
+			}
+			got = true
+		case <-heartbeat:
+			t.Fatalf("no heartbeat on an idle stream")
+		}
+	}
+}
+
+// TestCustomerOrderStream tests that customer streams replay from the log after Last-Event-ID, only carry
+// the customer's orders and are closed to other customers
+func TestCustomerOrderStream(t *testing.T) {
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	customerKey, err := keys.IssueKey(context.Background(), &model.CreateAPIKeyRequest{Name: "storefront", Role: auth.RoleCustomer, CustomerID: "customer-1"})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+
+	var replayedAfter string
+	mockRepo := &MockOrderRepository{
+		GetCustomerEventsAfterFunc: func(_ context.Context, customerID, afterEventID string, _ int) ([]*model.OrderEvent, error) {
+			replayedAfter = afterEventID
+			return statusEvents(t, "order-1", customerID, model.OrderStatusConfirmed)[1:], nil
+		},
+	}
+	hub := stream.NewHub(stream.DefaultBufferSize)
+	h := handler.NewStreamHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()), hub, time.Minute)
+	router := mux.NewRouter()
+	router.Use(handler.Authenticate(nil, keys, false))
+	router.Use(handler.ResolveTenant)
+	router.HandleFunc("/customers/{id}/orders/stream", h.CustomerOrderStream).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer hub.Close()
+
+	open := func(customerID string) *http.Response {
+		req, _ := http.NewRequest("GET", server.URL+"/customers/"+customerID+"/orders/stream", nil)
+		req.Header.Set(handler.APIKeyHeader, customerKey.Key)
+		req.Header.Set("Last-Event-ID", "event-1")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET customer stream: %v", err)
+		}
+		return resp
+	}
+
+	forbidden := open("customer-2")
+	forbidden.Body.Close()
+	if forbidden.StatusCode != http.StatusForbidden {
+		t.Errorf("GET another customer's stream = %d, want %d", forbidden.StatusCode, http.StatusForbidden)
+	}
+
+	resp := open("customer-1")
+	defer resp.Body.Close()
+	events := readSSE(t, resp)
+	if event := nextUpdate(t, events); event.ID != "event-2" || replayedAfter != "event-1" {
+		t.Errorf("replayed event = %+v after %q, want event-2 after event-1", event, replayedAfter)
+	}
+
+	for _, created := range []*model.OrderCreatedEvent{
+		{EventID: "event-a", OrderID: "order-a", CustomerID: "customer-2", Status: model.OrderStatusPending, Version: 1, EventType: model.EventTypeOrderCreated},
+		{EventID: "event-b", OrderID: "order-b", CustomerID: "customer-1", TenantID: "acme", Status: model.OrderStatusPending, Version: 1, EventType: model.EventTypeOrderCreated},
+		{EventID: "event-c", OrderID: "order-c", CustomerID: "customer-1", Status: model.OrderStatusPending, Version: 1, EventType: model.EventTypeOrderCreated},
+	} {
+		body, _ := json.Marshal(created)
+		if err := hub.HandleEvent(context.Background(), model.RoutingKeyOrderCreated, body); err != nil {
+			t.Fatalf("HandleEvent() unexpected error = %v", err)
+		}
+	}
+	if event := nextUpdate(t, events); event.ID != "event-c" || event.Update.TenantID != model.DefaultTenantID {
+		t.Errorf("live event = %+v, want the new order of customer-1 in the default tenant", event)
+	}
+}
+
+// TestHubDropsSlowSubscribers tests that a subscriber that stops reading is closed rather than blocking the hub
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	hub := stream.NewHub(2)
+	slow := hub.Subscribe(stream.Filter{TenantID: model.DefaultTenantID, OrderID: "order-1"})
+	other := hub.Subscribe(stream.Filter{TenantID: model.DefaultTenantID, OrderID: "order-2"})
+	defer other.Close()
+
+	for version := int64(1); version <= 3; version++ {
+		hub.Publish(&model.OrderStatusUpdate{OrderID: "order-1", TenantID: model.DefaultTenantID, Version: version})
+	}
+
+	received := 0
+	for range slow.Updates() {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("slow subscriber received %d updates before being closed, want 2", received)
+	}
+	slow.Close() // closing twice is harmless
+
+	select {
+	case update := <-other.Updates():
+		t.Errorf("subscriber of order-2 received %+v", update)
+	default:
+	}
+}
+
+// TestHubCloseEndsOpenStreams tests that closing the hub, as when its consumer reconnects, ends the
+// open subscriptions and leaves the hub serving new ones
+func TestHubCloseEndsOpenStreams(t *testing.T) {
+	hub := stream.NewHub(stream.DefaultBufferSize)
+	filter := stream.Filter{TenantID: model.DefaultTenantID, OrderID: "order-1"}
+	open := hub.Subscribe(filter)
+
+	hub.Close()
+	if _, ok := <-open.Updates(); ok {
+		t.Fatalf("subscription open before Close() received an update, want it closed")
+	}
+
+	reopened := hub.Subscribe(filter)
+	defer reopened.Close()
+	hub.Publish(&model.OrderStatusUpdate{OrderID: "order-1", TenantID: model.DefaultTenantID, Version: 2})
+	select {
+	case update := <-reopened.Updates():
+		if update.Version != 2 {
+			t.Errorf("update = %+v, want version 2", update)
+		}
+	default:
+		t.Errorf("subscription opened after Close() received no update")
+	}
+}
@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/auth"
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/gorilla/mux"
+)
+
+// TestTenantScoping tests that requests are scoped to the tenant of their API key or X-Tenant-ID
+// header, and that only admins and services may choose the tenant
+func TestTenantScoping(t *testing.T) {
+	keys := service.NewAPIKeyService(&MockAPIKeyRepository{})
+	ctx := context.Background()
+	if _, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "bad", Role: auth.RoleService, TenantID: "not a tenant"}); err == nil {
+		t.Errorf("IssueKey() with malformed tenant_id: expected error")
+	}
+	bound, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "acme", Role: auth.RoleService, TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	unbound, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "platform", Role: auth.RoleService})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	customer, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "shop", Role: auth.RoleCustomer, CustomerID: "customer-1"})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+	boundCustomer, err := keys.IssueKey(ctx, &model.CreateAPIKeyRequest{Name: "acme shop", Role: auth.RoleCustomer, CustomerID: "customer-1", TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("IssueKey() unexpected error = %v", err)
+	}
+
+	var created *model.Order
+	var event model.OrderCreatedEvent
+	mockRepo := &MockOrderRepository{
+		CreateFunc: func(_ context.Context, order *model.Order, events []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+			created = order
+			return json.Unmarshal(events[0].Payload, &event)
+		},
+	}
+	h := handler.NewOrderHandler(service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator()))
+	router := mux.NewRouter()
+	router.Use(handler.Authenticate(nil, keys, false))
+	router.Use(handler.ResolveTenant)
+	router.HandleFunc("/orders", h.CreateOrder).Methods("POST")
+
+	tests := []struct {
+		name       string
+		key        string
+		tenant     string
+		want       int
+		wantTenant string
+	}{
+		{"bound key", bound.Key, "", http.StatusCreated, "acme"},
+		{"bound key naming its tenant", bound.Key, "acme", http.StatusCreated, "acme"},
+		{"bound key naming another tenant", bound.Key, "globex", http.StatusForbidden, ""},
+		{"unbound key naming a tenant", unbound.Key, "globex", http.StatusCreated, "globex"},
+		{"unbound key", unbound.Key, "", http.StatusCreated, model.DefaultTenantID},
+		{"malformed tenant", unbound.Key, "globex:orders", http.StatusBadRequest, ""},
+		{"unbound customer key", customer.Key, "", http.StatusCreated, model.DefaultTenantID},
+		{"unbound customer key naming the default tenant", customer.Key, model.DefaultTenantID, http.StatusCreated, model.DefaultTenantID},
+		{"unbound customer key naming a tenant", customer.Key, "globex", http.StatusForbidden, ""},
+		{"bound customer key", boundCustomer.Key, "", http.StatusCreated, "acme"},
+		{"bound customer key naming another tenant", boundCustomer.Key, "globex", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, event = nil, model.OrderCreatedEvent{}
+			req := httptest.NewRequest("POST", "/orders",
+				strings.NewReader(`{"customer_id":"customer-1","items":[{"product_id":"product-456","quantity":1}]}`))
+			req.Header.Set(handler.APIKeyHeader, tt.key)
+			if tt.tenant != "" {
+				req.Header.Set(handler.TenantHeader, tt.tenant)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("POST /orders = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+			if tt.wantTenant == "" {
+				if created != nil {
+					t.Errorf("POST /orders created order %s in rejected request", created.ID)
+				}
+				return
+			}
+			if created.TenantID != tt.wantTenant || event.TenantID != tt.wantTenant {
+				t.Errorf("order tenant = %q, event tenant = %q, want %q", created.TenantID, event.TenantID, tt.wantTenant)
+			}
+		})
+	}
+}
+
+// TestExpirePendingOrdersAcrossTenants tests that the expiry sweep lists every tenant and expires
+// each order within its own tenant
+func TestExpirePendingOrdersAcrossTenants(t *testing.T) {
+	cutoff := time.Now().Add(-time.Hour)
+	listScoped := true
+	expiredIn := map[string]string{}
+	mockRepo := &MockOrderRepository{
+		ListFunc: func(ctx context.Context, _ model.OrderFilter, _ *model.OrderCursor, _ int) ([]*model.Order, error) {
+			_, listScoped = model.TenantFromContext(ctx)
+			return []*model.Order{
+				{ID: "order-acme", TenantID: "acme", Version: 1},
+				{ID: "order-globex", TenantID: "globex", Version: 1},
+			}, nil
+		},
+		GetEventsFunc: func(_ context.Context, id string) ([]*model.OrderEvent, error) {
+			tenantID := strings.TrimPrefix(id, "order-")
+			created, err := model.NewOrderEvent("event-"+id, id, 1, model.EventTypeOrderCreated, cutoff,
+				&model.OrderCreatedEvent{OrderID: id, TenantID: tenantID, Status: model.OrderStatusPending, Version: 1})
+			return []*model.OrderEvent{created}, err
+		},
+		UpdateFunc: func(ctx context.Context, order *model.Order, _ []*model.OrderEvent, _ []*model.OutboxMessage, _ *model.OrderHistoryEntry) error {
+			expiredIn[order.ID], _ = model.TenantFromContext(ctx)
+			return nil
+		},
+	}
+
+	svc := service.NewOrderService(mockRepo, &MockOrderCache{}, newTestCalculator())
+	expired, err := svc.ExpirePendingOrders(context.Background(), cutoff, 10)
+	if err != nil || expired != 2 {
+		t.Fatalf("ExpirePendingOrders() = %d, %v, want 2 orders expired", expired, err)
+	}
+	if listScoped {
+		t.Errorf("ExpirePendingOrders() listed pending orders of a single tenant, want all tenants")
+	}
+	if expiredIn["order-acme"] != "acme" || expiredIn["order-globex"] != "globex" {
+		t.Errorf("ExpirePendingOrders() expired orders in tenants %v, want each in its own", expiredIn)
+	}
+}
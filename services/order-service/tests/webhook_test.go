@@ -0,0 +1,399 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andev0x/order-service/internal/handler"
+	"github.com/andev0x/order-service/internal/model"
+	"github.com/andev0x/order-service/internal/repository"
+	"github.com/andev0x/order-service/internal/service"
+	"github.com/andev0x/order-service/internal/webhook"
+	"github.com/gorilla/mux"
+)
+
+// MockWebhookRepository is an in-memory implementation of WebhookRepository and WebhookDeliveryStore
+type MockWebhookRepository struct {
+	mu         sync.Mutex
+	Subs       []*model.WebhookSubscription
+	Deliveries []*model.WebhookDelivery
+}
+
+func (m *MockWebhookRepository) CreateSubscription(_ context.Context, sub *model.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Subs = append(m.Subs, sub)
+	return nil
+}
+
+func (m *MockWebhookRepository) GetSubscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.subscription(ctx, id)
+}
+
+func (m *MockWebhookRepository) ListSubscriptions(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenantID, _ := model.TenantFromContext(ctx)
+	subs := []*model.WebhookSubscription{}
+	for _, sub := range m.Subs {
+		if sub.TenantID == tenantID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (m *MockWebhookRepository) UpdateSubscription(ctx context.Context, sub *model.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.subscription(ctx, sub.ID)
+	return err
+}
+
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.subscription(ctx, id); err != nil {
+		return err
+	}
+	for i, sub := range m.Subs {
+		if sub.ID == id {
+			m.Subs = append(m.Subs[:i], m.Subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.subscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	deliveries := []*model.WebhookDelivery{}
+	for _, d := range m.Deliveries {
+		if d.SubscriptionID == subscriptionID && (status == "" || d.Status == status) && len(deliveries) < limit {
+			copied := *d
+			deliveries = append(deliveries, &copied)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *MockWebhookRepository) Redeliver(ctx context.Context, subscriptionID, id string, at time.Time) (*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, err := m.subscription(ctx, subscriptionID); err != nil {
+		return nil, repository.ErrWebhookDeliveryNotFound
+	}
+	for _, d := range m.Deliveries {
+		if d.ID == id && d.SubscriptionID == subscriptionID {
+			d.Status, d.Attempts, d.NextAttemptAt = model.WebhookDeliveryPending, 0, &at
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrWebhookDeliveryNotFound
+}
+
+func (m *MockWebhookRepository) MatchingSubscriptions(_ context.Context, tenantID, eventType string) ([]*model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var subs []*model.WebhookSubscription
+	for _, sub := range m.Subs {
+		if sub.TenantID == tenantID && sub.Active && sub.Matches(eventType) {
+			subs = append(subs, sub)
+		}
+	}
+	return subs, nil
+}
+
+func (m *MockWebhookRepository) CreateDeliveries(_ context.Context, deliveries []*model.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+next:
+	for _, d := range deliveries {
+		for _, existing := range m.Deliveries {
+			if existing.SubscriptionID == d.SubscriptionID && existing.EventID == d.EventID {
+				continue next
+			}
+		}
+		at := d.CreatedAt
+		d.NextAttemptAt = &at
+		m.Deliveries = append(m.Deliveries, d)
+	}
+	return nil
+}
+
+func (m *MockWebhookRepository) ClaimDue(_ context.Context, limit int, lease time.Duration) ([]*model.WebhookDispatch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var dispatches []*model.WebhookDispatch
+	for _, d := range m.Deliveries {
+		if len(dispatches) == limit || d.Status != model.WebhookDeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		for _, sub := range m.Subs {
+			if sub.ID == d.SubscriptionID && sub.Active {
+				copied := *d
+				dispatches = append(dispatches, &model.WebhookDispatch{WebhookDelivery: &copied, URL: sub.URL, Secret: sub.Secret})
+				leased := now.Add(lease)
+				d.NextAttemptAt = &leased
+			}
+		}
+	}
+	return dispatches, nil
+}
+
+func (m *MockWebhookRepository) RecordAttempt(_ context.Context, id string, attempt *model.WebhookAttempt, status string, nextAttemptAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.Deliveries {
+		if d.ID == id {
+			d.Status, d.NextAttemptAt = status, &nextAttemptAt
+			d.Attempts++
+			d.LastAttemptAt, d.LastStatusCode = &attempt.At, attempt.StatusCode
+			if attempt.Err != nil {
+				d.LastError = attempt.Err.Error()
+			}
+			if status == model.WebhookDeliveryDelivered {
+				d.DeliveredAt = &attempt.At
+			}
+		}
+	}
+	return nil
+}
+
+// delivery returns a copy of the first delivery to a subscription
+func (m *MockWebhookRepository) delivery(subscriptionID string) *model.WebhookDelivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.Deliveries {
+		if d.SubscriptionID == subscriptionID {
+			copied := *d
+			return &copied
+		}
+	}
+	return nil
+}
+
+// subscription finds a subscription in the tenant of ctx; callers hold mu
+func (m *MockWebhookRepository) subscription(ctx context.Context, id string) (*model.WebhookSubscription, error) {
+	tenantID, _ := model.TenantFromContext(ctx)
+	for _, sub := range m.Subs {
+		if sub.ID == id && sub.TenantID == tenantID {
+			return sub, nil
+		}
+	}
+	return nil, repository.ErrWebhookNotFound
+}
+
+// TestWebhookDelivery tests that order events are signed and delivered to matching subscriptions,
+// retried with backoff until dead, and sent again on redelivery
+func TestWebhookDelivery(t *testing.T) {
+	var mu sync.Mutex
+	failing := true
+	received := map[string][]string{}
+	var secrets map[string]string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if err := webhook.VerifySignature(secrets[r.URL.Path], r.Header.Get(webhook.SignatureHeader), body, time.Minute); err != nil {
+			t.Errorf("receiver %s: %v", r.URL.Path, err)
+		}
+		received[r.URL.Path] = append(received[r.URL.Path], r.Header.Get(webhook.EventHeader))
+		if r.URL.Path == "/flaky" && failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	repo := &MockWebhookRepository{}
+	svc := service.NewWebhookService(repo)
+	acme := model.WithTenant(context.Background(), "acme")
+	create := func(path string, eventTypes ...string) *model.CreatedWebhookSubscription {
+		sub, err := svc.CreateSubscription(acme, &model.WebhookRequest{URL: receiver.URL + path, EventTypes: eventTypes})
+		if err != nil {
+			t.Fatalf("CreateSubscription(%s) unexpected error = %v", path, err)
+		}
+		return sub
+	}
+	all := create("/all", model.WebhookEventAll)
+	flaky := create("/flaky", model.RoutingKeyOrderCreated)
+	confirmed := create("/confirmed", model.StatusRoutingKey(model.OrderStatusConfirmed))
+	secrets = map[string]string{"/all": all.Secret, "/flaky": flaky.Secret, "/confirmed": confirmed.Secret}
+
+	fanout := webhook.NewFanout(repo)
+	for _, event := range []struct{ tenant, eventID string }{
+		{"acme", "event-1"},
+		{"acme", "event-1"}, // redelivered by the broker
+		{"globex", "event-2"},
+	} {
+		body := `{"event_id":"` + event.eventID + `","tenant_id":"` + event.tenant + `","order_id":"order-1"}`
+		if err := fanout.HandleEvent(context.Background(), model.RoutingKeyOrderCreated, []byte(body)); err != nil {
+			t.Fatalf("HandleEvent() unexpected error = %v", err)
+		}
+	}
+	if len(repo.Deliveries) != 2 {
+		t.Fatalf("HandleEvent() created %d deliveries, want 2 (one per matching subscription of acme)", len(repo.Deliveries))
+	}
+
+	config := webhook.DefaultConfig()
+	config.MaxAttempts = 2
+	config.MinBackoff = time.Millisecond
+	dispatcher := webhook.NewDispatcher(repo, config)
+	dispatch := func() {
+		if _, err := dispatcher.DispatchBatch(context.Background()); err != nil {
+			t.Fatalf("DispatchBatch() unexpected error = %v", err)
+		}
+	}
+
+	dispatch()
+	if d := repo.delivery(all.ID); d.Status != model.WebhookDeliveryDelivered || d.DeliveredAt == nil || d.LastStatusCode != http.StatusNoContent {
+		t.Errorf("delivery to /all = %+v, want delivered", d)
+	}
+	if d := repo.delivery(flaky.ID); d.Status != model.WebhookDeliveryPending || d.Attempts != 1 || d.LastStatusCode != http.StatusServiceUnavailable {
+		t.Errorf("delivery to /flaky after a 503 = %+v, want pending for a retry", d)
+	}
+	if len(received["/confirmed"]) != 0 {
+		t.Errorf("subscription to %s received %v", model.StatusRoutingKey(model.OrderStatusConfirmed), received["/confirmed"])
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	dispatch()
+	if d := repo.delivery(flaky.ID); d.Status != model.WebhookDeliveryDead || d.Attempts != 2 {
+		t.Errorf("delivery to /flaky after %d failures = %+v, want dead", config.MaxAttempts, d)
+	}
+	dispatch()
+	if got := len(received["/flaky"]); got != 2 {
+		t.Errorf("/flaky received %d requests, want no attempts after the delivery is dead", got)
+	}
+
+	// Redeliver the dead delivery through the API
+	h := handler.NewWebhookHandler(svc)
+	router := mux.NewRouter()
+	router.Use(handler.ResolveTenant)
+	router.HandleFunc("/webhooks/{id}/deliveries", h.ListDeliveries).Methods("GET")
+	router.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/redeliver", h.Redeliver).Methods("POST")
+	serve := func(method, path, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(handler.TenantHeader, tenant)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("GET", "/webhooks/"+flaky.ID+"/deliveries?status=dead", "acme")
+	var dead []*model.WebhookDelivery
+	if err := json.Unmarshal(rec.Body.Bytes(), &dead); rec.Code != http.StatusOK || err != nil || len(dead) != 1 {
+		t.Fatalf("GET deliveries?status=dead = %d %s, want the dead delivery", rec.Code, rec.Body.String())
+	}
+	redeliver := "/webhooks/" + flaky.ID + "/deliveries/" + dead[0].ID + "/redeliver"
+	if rec := serve("POST", redeliver, "globex"); rec.Code != http.StatusNotFound {
+		t.Errorf("POST redeliver from another tenant = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := serve("POST", redeliver, "acme"); rec.Code != http.StatusAccepted {
+		t.Fatalf("POST redeliver = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	dispatch()
+	if d := repo.delivery(flaky.ID); d.Status != model.WebhookDeliveryDelivered || d.Attempts != 1 {
+		t.Errorf("redelivered delivery = %+v, want delivered on its first new attempt", d)
+	}
+}
+
+// TestWebhookSubscriptionAPI tests validation, secret handling and tenant isolation of the subscription endpoints
+func TestWebhookSubscriptionAPI(t *testing.T) {
+	h := handler.NewWebhookHandler(service.NewWebhookService(&MockWebhookRepository{}))
+	router := mux.NewRouter()
+	router.Use(handler.ResolveTenant)
+	router.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST")
+	router.HandleFunc("/webhooks/{id}", h.GetWebhook).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", h.UpdateWebhook).Methods("PUT")
+	router.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE")
+	serve := func(method, path, tenant, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(handler.TenantHeader, tenant)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	invalid := []string{
+		`{"url":"ftp://example.com/hook","event_types":["order.created"]}`,
+		`{"url":"https://example.com/hook","event_types":["order.updated"]}`,
+		`{"url":"https://example.com/hook","event_types":[]}`,
+		`{"url":"https://example.com/hook","event_types":["order.*"],"secret":"short"}`,
+	}
+	for _, body := range invalid {
+		if rec := serve("POST", "/webhooks", "acme", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /webhooks %s = %d, want %d", body, rec.Code, http.StatusBadRequest)
+		}
+	}
+
+	rec := serve("POST", "/webhooks", "acme", `{"url":"https://example.com/hook","event_types":["order.*"]}`)
+	var created model.CreatedWebhookSubscription
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); rec.Code != http.StatusCreated || err != nil {
+		t.Fatalf("POST /webhooks = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	if !strings.HasPrefix(created.Secret, "whsec_") || !created.Active || created.TenantID != "acme" {
+		t.Errorf("POST /webhooks = %s, want an active acme subscription with a generated secret", rec.Body.String())
+	}
+
+	rec = serve("GET", "/webhooks/"+created.ID, "acme", "")
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), created.Secret) {
+		t.Errorf("GET /webhooks/{id} = %d %s, want the subscription without its secret", rec.Code, rec.Body.String())
+	}
+	if rec := serve("GET", "/webhooks/"+created.ID, "globex", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /webhooks/{id} from another tenant = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	rec = serve("PUT", "/webhooks/"+created.ID, "acme", `{"url":"https://example.com/v2","event_types":["order.shipped"],"active":false}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"active":false`) {
+		t.Errorf("PUT /webhooks/{id} = %d %s, want the inactive subscription", rec.Code, rec.Body.String())
+	}
+
+	if rec := serve("DELETE", "/webhooks/"+created.ID, "acme", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE /webhooks/{id} = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+	if rec := serve("GET", "/webhooks/"+created.ID, "acme", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET deleted webhook = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+// TestWebhookSignature tests that signatures only verify for the signed body, secret and time window
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"event_id":"event-1"}`)
+	header := webhook.Sign("secret-1", time.Now(), body)
+
+	if err := webhook.VerifySignature("secret-1", header, body, time.Minute); err != nil {
+		t.Errorf("VerifySignature() unexpected error = %v", err)
+	}
+	if err := webhook.VerifySignature("secret-2", header, body, time.Minute); err != webhook.ErrInvalidSignature {
+		t.Errorf("VerifySignature() with the wrong secret = %v, want %v", err, webhook.ErrInvalidSignature)
+	}
+	if err := webhook.VerifySignature("secret-1", header, []byte(`{"event_id":"event-2"}`), time.Minute); err != webhook.ErrInvalidSignature {
+		t.Errorf("VerifySignature() of a tampered body = %v, want %v", err, webhook.ErrInvalidSignature)
+	}
+	old := webhook.Sign("secret-1", time.Now().Add(-time.Hour), body)
+	if err := webhook.VerifySignature("secret-1", old, body, time.Minute); err != webhook.ErrSignatureExpired {
+		t.Errorf("VerifySignature() of an old signature = %v, want %v", err, webhook.ErrSignatureExpired)
+	}
+}
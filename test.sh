@@ -59,6 +59,18 @@ else
     exit 1
 fi
 
+# Seed the price table (existing products return 409, which is fine)
+print_info "Seeding product prices..."
+curl -s -X POST http://localhost:8080/admin/products \
+    -H "Content-Type: application/json" \
+    -d '{"id": "product-123", "name": "Test Product", "unit_price": 49.99}' > /dev/null
+for i in {1..3}; do
+    curl -s -X POST http://localhost:8080/admin/products \
+        -H "Content-Type: application/json" \
+        -d "{\"id\": \"product-$i\", \"name\": \"Product $i\", \"unit_price\": 50.00}" > /dev/null
+done
+print_success "Product prices seeded"
+
 # Test 3: Create an order
 print_info "Creating test order..."
 ORDER_RESPONSE=$(curl -s -X POST http://localhost:8080/orders \
@@ -67,7 +79,7 @@ ORDER_RESPONSE=$(curl -s -X POST http://localhost:8080/orders \
         "customer_id": "customer-001",
         "product_id": "product-123",
         "quantity": 3,
-        "total_amount": 149.99
+        "total_amount": 149.97
     }')
 
 ORDER_ID=$(echo "$ORDER_RESPONSE" | grep -o '"id":"[^"]*' | cut -d'"' -f4)